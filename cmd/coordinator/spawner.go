@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/travelreys/travelreys/pkg/common"
+	"github.com/travelreys/travelreys/pkg/finance"
 	"github.com/travelreys/travelreys/pkg/maps"
 	"github.com/travelreys/travelreys/pkg/media"
 	"github.com/travelreys/travelreys/pkg/storage"
@@ -54,9 +55,18 @@ func MakeCoordinatorSpanwer(logger *zap.Logger) (*trips.Spawner, error) {
 		return nil, err
 	}
 
+	// Finance
+	fxProvider, err := finance.NewDefaultFxProvider(logger)
+	if err != nil {
+		logger.Error("unable to make fx provider", zap.Error(err))
+		return nil, err
+	}
+
 	return trips.NewSpawner(
 		mapsSvc,
 		media.NewService(mediaStore, mediaCDNProvider, storageSvc, logger),
+		finance.NewService(finance.NewStore(rdb, logger), fxProvider, logger),
+		storageSvc,
 		trips.NewStore(ctx, db, logger),
 		trips.NewSessionStore(rdb, logger),
 		trips.NewSyncMsgStore(nc, logger),
@@ -9,6 +9,8 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/travelreys/travelreys/pkg/api"
 	"github.com/travelreys/travelreys/pkg/auth"
+	"github.com/travelreys/travelreys/pkg/checklists"
+	"github.com/travelreys/travelreys/pkg/comments"
 	"github.com/travelreys/travelreys/pkg/common"
 	"github.com/travelreys/travelreys/pkg/email"
 	"github.com/travelreys/travelreys/pkg/finance"
@@ -16,7 +18,9 @@ import (
 	"github.com/travelreys/travelreys/pkg/invites"
 	"github.com/travelreys/travelreys/pkg/maps"
 	"github.com/travelreys/travelreys/pkg/media"
+	"github.com/travelreys/travelreys/pkg/notifications"
 	"github.com/travelreys/travelreys/pkg/ogp"
+	"github.com/travelreys/travelreys/pkg/sharelinks"
 	"github.com/travelreys/travelreys/pkg/social"
 	"github.com/travelreys/travelreys/pkg/storage"
 	"github.com/travelreys/travelreys/pkg/trips"
@@ -84,7 +88,12 @@ func MakeAPIServer(cfg ServerConfig, logger *zap.Logger) (*http.Server, error) {
 
 	// Finance
 	finStore := finance.NewStore(rdb, logger)
-	finSvc := finance.NewService(finStore, logger)
+	fxProvider, err := finance.NewDefaultFxProvider(logger)
+	if err != nil {
+		logger.Error("unable to make fx provider", zap.Error(err))
+		return nil, err
+	}
+	finSvc := finance.NewService(finStore, fxProvider, logger)
 	finSvcForAPI := finance.SvcWithRBACMw(finSvc, logger)
 
 	// Ogp
@@ -103,36 +112,89 @@ func MakeAPIServer(cfg ServerConfig, logger *zap.Logger) (*http.Server, error) {
 
 	// Trips
 	tripStore := trips.NewStore(ctx, db, logger)
+	if err := tripStore.Migrate(ctx); err != nil {
+		logger.Error("unable to migrate trips", zap.Error(err))
+		return nil, err
+	}
+	tripSyncMsgStore := trips.NewSyncMsgStore(nc, logger)
+	tripSyncSvc := trips.NewSyncService(
+		tripStore,
+		trips.NewSessionStore(rdb, logger),
+		tripSyncMsgStore,
+	)
 	tripSvc := trips.NewService(
 		tripStore,
+		tripSyncSvc,
 		authSvcWithVal,
 		imageSvc,
 		mediaSvc,
 		storageSvc,
+		finSvc,
+		mailSvc,
 		logger,
 	)
 	tripSvcWithVal := trips.SvcWithValidationMw(tripSvc, logger)
 	tripSvcForAPI := trips.SvcWithRBACMw(tripSvc, logger)
 	tripSvcForAPI = trips.SvcWithValidationMw(tripSvcForAPI, logger)
-	tripSyncSvc := trips.NewSyncService(
-		tripStore,
-		trips.NewSessionStore(rdb, logger),
-		trips.NewSyncMsgStore(nc, logger),
-	)
 	wsSvr := trips.NewWebsocketServer(tripSyncSvc, logger)
 
+	// Notifications
+	notifStore := notifications.NewStore(ctx, db, logger)
+	notifSvc := notifications.NewService(
+		notifStore,
+		notifications.NewPushStore(nc, logger),
+		authSvcWithVal,
+		tripSvcWithVal,
+		mailSvc,
+		logger,
+	)
+	notifSvc = notifications.SvcWithValidationMw(notifSvc, logger)
+	go notifications.RunTripUpdates(ctx, notifSvc, tripSyncMsgStore, logger)
+	go notifications.RunDigests(ctx, notifSvc, notifications.DefaultDigestsInterval, logger)
+	notifSvcForAPI := notifications.SvcWithRBACMw(notifSvc, logger)
+	notifWsSvr := notifications.NewWebsocketServer(notifSvcForAPI, logger)
+
 	// Trips Invite
 	inviteStore := invites.NewStore(ctx, db, logger)
 	inviteSvc := invites.NewService(
 		authSvcWithVal,
 		tripSyncSvc,
 		mailSvc,
+		notifSvc,
 		inviteStore,
 		logger,
 	)
 	inviteSvc = invites.SvcWithValidationMw(inviteSvc, logger)
 	inviteSvc = invites.SvcWithRBACMw(inviteSvc, tripSvcWithVal, authSvcWithVal, logger)
 
+	// Comments
+	commentStore := comments.NewStore(ctx, db, logger)
+	commentSvc := comments.NewService(
+		authSvcWithVal,
+		tripSyncSvc,
+		mailSvc,
+		notifSvc,
+		commentStore,
+		logger,
+	)
+	commentSvc = comments.SvcWithValidationMw(commentSvc, logger)
+	commentSvc = comments.SvcWithRBACMw(commentSvc, tripSvcWithVal, logger)
+
+	// Checklists
+	checklistStore := checklists.NewStore(ctx, db, logger)
+	checklistSvc := checklists.NewService(
+		authSvcWithVal,
+		tripSvcWithVal,
+		tripStore,
+		tripSyncSvc,
+		mailSvc,
+		checklistStore,
+		logger,
+	)
+	go checklists.RunReminders(ctx, checklistSvc, checklists.DefaultRemindersInterval, logger)
+	checklistSvcForAPI := checklists.SvcWithValidationMw(checklistSvc, logger)
+	checklistSvcForAPI = checklists.SvcWithRBACMw(checklistSvcForAPI, tripSvcWithVal, logger)
+
 	// Social
 	socialStore := social.NewStore(ctx, db, logger)
 	socialSvc := social.NewService(
@@ -140,10 +202,36 @@ func MakeAPIServer(cfg ServerConfig, logger *zap.Logger) (*http.Server, error) {
 		authSvcWithVal,
 		tripSvcWithVal,
 		mailSvc,
+		notifSvc,
 		logger,
 	)
 	socialSvcForAPI := social.SvcWithRBACMw(socialSvc, tripSvcWithVal, logger)
 
+	// Share Links
+	shareLinkStore := sharelinks.NewStore(ctx, db, logger)
+	shareLinkSvc := sharelinks.NewService(
+		tripSvcWithVal,
+		tripSyncSvc,
+		shareLinkStore,
+		logger,
+	)
+	shareLinkSvcForAPI := sharelinks.SvcWithValidationMw(shareLinkSvc, logger)
+	shareLinkSvcForAPI = sharelinks.SvcWithRBACMw(shareLinkSvcForAPI, tripSvcWithVal, logger)
+
+	// Trash
+	go trips.RunPurge(
+		ctx,
+		tripSvc,
+		trips.DefaultPurgeInterval,
+		[]trips.PurgeHook{
+			inviteStore.DeleteByTripID,
+			commentStore.DeleteByTripID,
+			shareLinkStore.DeleteByTripID,
+			notifStore.DeleteByTripID,
+		},
+		logger,
+	)
+
 	r := mux.NewRouter()
 	securityMW := api.NewSecureHeadersMiddleware(cfg.CORSOrigin)
 	wrwMW := api.NewWrappedReponseWriterMiddleware()
@@ -158,6 +246,7 @@ func MakeAPIServer(cfg ServerConfig, logger *zap.Logger) (*http.Server, error) {
 	r.Handle("/metrics", promhttp.Handler())
 	r.HandleFunc("/healthz", api.HealthzHandler)
 	r.HandleFunc("/ws", wsSvr.HandleFunc)
+	r.HandleFunc("/ws/notifications", notifWsSvr.HandleFunc)
 
 	r.PathPrefix("/api/v1/auth").Handler(auth.MakeHandler(authSvcForAPI))
 	r.PathPrefix("/api/v1/images").Handler(images.MakeHandler(imageSvcForAPI))
@@ -167,6 +256,10 @@ func MakeAPIServer(cfg ServerConfig, logger *zap.Logger) (*http.Server, error) {
 	r.PathPrefix("/api/v1/social").Handler(social.MakeHandler(socialSvcForAPI))
 	r.PathPrefix("/api/v1/trips").Handler(trips.MakeHandler(tripSvcForAPI))
 	r.PathPrefix("/api/v1/invites").Handler(invites.MakeHandler(inviteSvc))
+	r.PathPrefix("/api/v1/comments").Handler(comments.MakeHandler(commentSvc))
+	r.PathPrefix("/api/v1/checklists").Handler(checklists.MakeHandler(checklistSvcForAPI))
+	r.PathPrefix("/api/v1/sharelinks").Handler(sharelinks.MakeHandler(shareLinkSvcForAPI))
+	r.PathPrefix("/api/v1/notifications").Handler(notifications.MakeHandler(notifSvcForAPI))
 
 	return &http.Server{
 		Handler: r,
@@ -6,6 +6,7 @@ require (
 	cloud.google.com/go/storage v1.23.0
 	github.com/evanphx/json-patch/v5 v5.6.0
 	github.com/go-kit/kit v0.12.0
+	github.com/go-pdf/fpdf v0.6.0
 	github.com/go-redis/redis/v9 v9.0.0-rc.2
 	github.com/golang-jwt/jwt/v4 v4.4.3
 	github.com/google/uuid v1.3.0
@@ -0,0 +1,83 @@
+package checklists
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/travelreys/travelreys/pkg/common"
+	"github.com/travelreys/travelreys/pkg/trips"
+)
+
+var (
+	ErrChecklistNotFound = errors.New("checklists.ErrChecklistNotFound")
+)
+
+const (
+	maxTemplateItems       = 200
+	maxTemplateTitleLength = 200
+)
+
+type TemplateItem struct {
+	Title string `json:"title" bson:"title"`
+
+	// DueOffsetDays is the number of days from the trip's start date
+	// the item is due, negative before the trip starts.
+	DueOffsetDays *int `json:"dueOffsetDays,omitempty" bson:"dueOffsetDays,omitempty"`
+}
+
+// Template is a personal checklist a user reuses across trips.
+type Template struct {
+	ID      string         `json:"id" bson:"id"`
+	OwnerID string         `json:"ownerID" bson:"ownerID"`
+	Title   string         `json:"title" bson:"title"`
+	Items   []TemplateItem `json:"items" bson:"items"`
+
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+type TemplatesList []Template
+
+func NewTemplate(ownerID, title string, items []TemplateItem) Template {
+	if items == nil {
+		items = []TemplateItem{}
+	}
+	return Template{
+		ID:        uuid.NewString(),
+		OwnerID:   ownerID,
+		Title:     title,
+		Items:     items,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+// NewTemplateFromChecklist makes a template of the items of a trip's
+// checklist, in their order, without their assignees and progress.
+func NewTemplateFromChecklist(ownerID string, cl trips.Checklist) Template {
+	items := []TemplateItem{}
+	for _, item := range cl.SortItems() {
+		items = append(items, TemplateItem{
+			Title:         item.Title,
+			DueOffsetDays: item.DueOffsetDays,
+		})
+	}
+	return NewTemplate(ownerID, cl.Title, items)
+}
+
+// ToChecklist makes a new trip checklist out of the template.
+func (t Template) ToChecklist() (trips.Checklist, error) {
+	cl := trips.NewChecklist(t.Title)
+	fIndexes, err := common.GenerateNFracIndexesBetween("", "", len(t.Items))
+	if err != nil {
+		return trips.Checklist{}, err
+	}
+	for i, ti := range t.Items {
+		item := trips.NewChecklistItem(ti.Title)
+		item.DueOffsetDays = ti.DueOffsetDays
+		item.Labels[trips.LabelFractionalIndex] = fIndexes[i]
+		cl.Items[item.ID] = &item
+	}
+	return cl, nil
+}
@@ -0,0 +1,26 @@
+package checklists
+
+import (
+	"context"
+	"errors"
+
+	"github.com/travelreys/travelreys/pkg/common"
+)
+
+var (
+	ErrTemplateInfoNotSet = errors.New("checklists.ErrTemplateInfoNotSet")
+)
+
+func ContextWithTemplateInfo(ctx context.Context, tmpl Template) context.Context {
+	return context.WithValue(ctx, common.ContextKeyChecklistTemplateInfo, tmpl)
+}
+
+func TemplateInfoFromCtx(ctx context.Context) (Template, error) {
+	val := ctx.Value(common.ContextKeyChecklistTemplateInfo)
+	if val == nil {
+		return Template{}, ErrTemplateInfoNotSet
+	}
+
+	tmpl, _ := val.(Template)
+	return tmpl, nil
+}
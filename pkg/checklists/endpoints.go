@@ -0,0 +1,166 @@
+package checklists
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/travelreys/travelreys/pkg/common"
+	"github.com/travelreys/travelreys/pkg/trips"
+)
+
+type CreateTemplateRequest struct {
+	OwnerID string         `json:"ownerID"`
+	Title   string         `json:"title"`
+	Items   []TemplateItem `json:"items"`
+
+	// TripID and ChecklistID make the template from a trip's checklist.
+	TripID      string `json:"tripID"`
+	ChecklistID string `json:"checklistID"`
+}
+
+type CreateTemplateResponse struct {
+	Template Template `json:"template"`
+	Err      error    `json:"error,omitempty"`
+}
+
+func (r CreateTemplateResponse) Error() error {
+	return r.Err
+}
+
+func NewCreateTemplateEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(CreateTemplateRequest)
+		if !ok {
+			return CreateTemplateResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		if req.TripID != "" || req.ChecklistID != "" {
+			tmpl, err := svc.CreateTemplateFromChecklist(ctx, req.OwnerID, req.TripID, req.ChecklistID)
+			return CreateTemplateResponse{Template: tmpl, Err: err}, nil
+		}
+		tmpl, err := svc.CreateTemplate(ctx, req.OwnerID, req.Title, req.Items)
+		return CreateTemplateResponse{Template: tmpl, Err: err}, nil
+	}
+}
+
+type ReadTemplateRequest struct {
+	ID string `json:"id"`
+}
+
+type ReadTemplateResponse struct {
+	Template Template `json:"template"`
+	Err      error    `json:"error,omitempty"`
+}
+
+func (r ReadTemplateResponse) Error() error {
+	return r.Err
+}
+
+func NewReadTemplateEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(ReadTemplateRequest)
+		if !ok {
+			return ReadTemplateResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		tmpl, err := svc.ReadTemplate(ctx, req.ID)
+		return ReadTemplateResponse{Template: tmpl, Err: err}, nil
+	}
+}
+
+type ListTemplatesRequest struct {
+	OwnerID string `json:"ownerID"`
+}
+
+type ListTemplatesResponse struct {
+	Templates TemplatesList `json:"templates"`
+	Err       error         `json:"error,omitempty"`
+}
+
+func (r ListTemplatesResponse) Error() error {
+	return r.Err
+}
+
+func NewListTemplatesEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(ListTemplatesRequest)
+		if !ok {
+			return ListTemplatesResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		templates, err := svc.ListTemplates(ctx, req.OwnerID)
+		return ListTemplatesResponse{Templates: templates, Err: err}, nil
+	}
+}
+
+type UpdateTemplateRequest struct {
+	ID    string         `json:"id"`
+	Title string         `json:"title"`
+	Items []TemplateItem `json:"items"`
+}
+
+type UpdateTemplateResponse struct {
+	Template Template `json:"template"`
+	Err      error    `json:"error,omitempty"`
+}
+
+func (r UpdateTemplateResponse) Error() error {
+	return r.Err
+}
+
+func NewUpdateTemplateEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(UpdateTemplateRequest)
+		if !ok {
+			return UpdateTemplateResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		tmpl, err := svc.UpdateTemplate(ctx, req.ID, req.Title, req.Items)
+		return UpdateTemplateResponse{Template: tmpl, Err: err}, nil
+	}
+}
+
+type DeleteTemplateRequest struct {
+	ID string `json:"id"`
+}
+
+type DeleteTemplateResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r DeleteTemplateResponse) Error() error {
+	return r.Err
+}
+
+func NewDeleteTemplateEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(DeleteTemplateRequest)
+		if !ok {
+			return DeleteTemplateResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		err := svc.DeleteTemplate(ctx, req.ID)
+		return DeleteTemplateResponse{Err: err}, nil
+	}
+}
+
+type ApplyTemplateRequest struct {
+	ID       string `json:"id"`
+	TripID   string `json:"tripID"`
+	MemberID string `json:"memberID"`
+}
+
+type ApplyTemplateResponse struct {
+	Checklist trips.Checklist `json:"checklist"`
+	Err       error           `json:"error,omitempty"`
+}
+
+func (r ApplyTemplateResponse) Error() error {
+	return r.Err
+}
+
+func NewApplyTemplateEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(ApplyTemplateRequest)
+		if !ok {
+			return ApplyTemplateResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		cl, err := svc.ApplyTemplate(ctx, req.ID, req.TripID, req.MemberID)
+		return ApplyTemplateResponse{Checklist: cl, Err: err}, nil
+	}
+}
@@ -0,0 +1,209 @@
+package checklists
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/travelreys/travelreys/pkg/common"
+	"github.com/travelreys/travelreys/pkg/reqctx"
+	"github.com/travelreys/travelreys/pkg/trips"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrRBAC = errors.New("checklists.ErrRBAC")
+)
+
+type validationMiddleware struct {
+	next   Service
+	logger *zap.Logger
+}
+
+func SvcWithValidationMw(svc Service, logger *zap.Logger) Service {
+	return &validationMiddleware{svc, logger.Named("checklists.validationMiddleware")}
+}
+
+func isValidTitle(title string) bool {
+	title = strings.TrimSpace(title)
+	return title != "" && utf8.RuneCountInString(title) <= maxTemplateTitleLength
+}
+
+func isValidTemplate(title string, items []TemplateItem) bool {
+	if !isValidTitle(title) || len(items) > maxTemplateItems {
+		return false
+	}
+	for _, item := range items {
+		if !isValidTitle(item.Title) {
+			return false
+		}
+	}
+	return true
+}
+
+func (mw *validationMiddleware) CreateTemplate(ctx context.Context, ownerID, title string, items []TemplateItem) (Template, error) {
+	if ownerID == "" || !isValidTemplate(title, items) {
+		mw.logger.Warn("CreateTemplate")
+		return Template{}, common.ErrValidation
+	}
+	return mw.next.CreateTemplate(ctx, ownerID, title, items)
+}
+
+func (mw *validationMiddleware) CreateTemplateFromChecklist(ctx context.Context, ownerID, tripID, checklistID string) (Template, error) {
+	if ownerID == "" || tripID == "" || checklistID == "" {
+		mw.logger.Warn("CreateTemplateFromChecklist")
+		return Template{}, common.ErrValidation
+	}
+	return mw.next.CreateTemplateFromChecklist(ctx, ownerID, tripID, checklistID)
+}
+
+func (mw *validationMiddleware) ReadTemplate(ctx context.Context, ID string) (Template, error) {
+	if ID == "" {
+		mw.logger.Warn("ReadTemplate")
+		return Template{}, common.ErrValidation
+	}
+	return mw.next.ReadTemplate(ctx, ID)
+}
+
+func (mw *validationMiddleware) ListTemplates(ctx context.Context, ownerID string) (TemplatesList, error) {
+	if ownerID == "" {
+		mw.logger.Warn("ListTemplates")
+		return nil, common.ErrValidation
+	}
+	return mw.next.ListTemplates(ctx, ownerID)
+}
+
+func (mw *validationMiddleware) UpdateTemplate(ctx context.Context, ID, title string, items []TemplateItem) (Template, error) {
+	if ID == "" || !isValidTemplate(title, items) {
+		mw.logger.Warn("UpdateTemplate")
+		return Template{}, common.ErrValidation
+	}
+	return mw.next.UpdateTemplate(ctx, ID, title, items)
+}
+
+func (mw *validationMiddleware) DeleteTemplate(ctx context.Context, ID string) error {
+	if ID == "" {
+		mw.logger.Warn("DeleteTemplate")
+		return common.ErrValidation
+	}
+	return mw.next.DeleteTemplate(ctx, ID)
+}
+
+func (mw *validationMiddleware) ApplyTemplate(ctx context.Context, ID, tripID, memberID string) (trips.Checklist, error) {
+	if ID == "" || tripID == "" || memberID == "" {
+		mw.logger.Warn("ApplyTemplate")
+		return trips.Checklist{}, common.ErrValidation
+	}
+	return mw.next.ApplyTemplate(ctx, ID, tripID, memberID)
+}
+
+func (mw *validationMiddleware) SendReminders(ctx context.Context, now time.Time) error {
+	return mw.next.SendReminders(ctx, now)
+}
+
+type rbacMiddleware struct {
+	next    Service
+	tripSvc trips.Service
+	logger  *zap.Logger
+}
+
+func SvcWithRBACMw(svc Service, tripSvc trips.Service, logger *zap.Logger) Service {
+	return &rbacMiddleware{svc, tripSvc, logger.Named("checklists.rbacMiddleware")}
+}
+
+// readOwnTemplate reads the template if it belongs to the user.
+func (mw *rbacMiddleware) readOwnTemplate(ctx context.Context, ID string) (Template, reqctx.ClientInfo, error) {
+	ci, err := reqctx.ClientInfoFromCtx(ctx)
+	if err != nil || ci.HasEmptyID() {
+		return Template{}, ci, ErrRBAC
+	}
+	tmpl, err := mw.next.ReadTemplate(ctx, ID)
+	if err != nil {
+		return Template{}, ci, err
+	}
+	if tmpl.OwnerID != ci.UserID {
+		return Template{}, ci, ErrRBAC
+	}
+	return tmpl, ci, nil
+}
+
+// readTripWithPermission reads the trip if the user's role in it grants the permission.
+func (mw *rbacMiddleware) readTripWithPermission(ctx context.Context, tripID, userID, permission string) (*trips.Trip, error) {
+	trip, err := mw.tripSvc.Read(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	if !trip.HasPermission(userID, permission) {
+		return nil, ErrRBAC
+	}
+	return trip, nil
+}
+
+func (mw *rbacMiddleware) CreateTemplate(ctx context.Context, ownerID, title string, items []TemplateItem) (Template, error) {
+	ci, err := reqctx.ClientInfoFromCtx(ctx)
+	if err != nil || ci.HasEmptyID() || ci.UserID != ownerID {
+		return Template{}, ErrRBAC
+	}
+	return mw.next.CreateTemplate(ctx, ownerID, title, items)
+}
+
+func (mw *rbacMiddleware) CreateTemplateFromChecklist(ctx context.Context, ownerID, tripID, checklistID string) (Template, error) {
+	ci, err := reqctx.ClientInfoFromCtx(ctx)
+	if err != nil || ci.HasEmptyID() || ci.UserID != ownerID {
+		return Template{}, ErrRBAC
+	}
+	trip, err := mw.readTripWithPermission(ctx, tripID, ci.UserID, trips.PermissionView)
+	if err != nil {
+		return Template{}, err
+	}
+	return mw.next.CreateTemplateFromChecklist(trips.ContextWithTripInfo(ctx, trip), ownerID, tripID, checklistID)
+}
+
+func (mw *rbacMiddleware) ReadTemplate(ctx context.Context, ID string) (Template, error) {
+	tmpl, _, err := mw.readOwnTemplate(ctx, ID)
+	return tmpl, err
+}
+
+func (mw *rbacMiddleware) ListTemplates(ctx context.Context, ownerID string) (TemplatesList, error) {
+	ci, err := reqctx.ClientInfoFromCtx(ctx)
+	if err != nil || ci.HasEmptyID() || ci.UserID != ownerID {
+		return nil, ErrRBAC
+	}
+	return mw.next.ListTemplates(ctx, ownerID)
+}
+
+func (mw *rbacMiddleware) UpdateTemplate(ctx context.Context, ID, title string, items []TemplateItem) (Template, error) {
+	tmpl, _, err := mw.readOwnTemplate(ctx, ID)
+	if err != nil {
+		return Template{}, err
+	}
+	return mw.next.UpdateTemplate(ContextWithTemplateInfo(ctx, tmpl), ID, title, items)
+}
+
+func (mw *rbacMiddleware) DeleteTemplate(ctx context.Context, ID string) error {
+	if _, _, err := mw.readOwnTemplate(ctx, ID); err != nil {
+		return err
+	}
+	return mw.next.DeleteTemplate(ctx, ID)
+}
+
+func (mw *rbacMiddleware) ApplyTemplate(ctx context.Context, ID, tripID, memberID string) (trips.Checklist, error) {
+	tmpl, ci, err := mw.readOwnTemplate(ctx, ID)
+	if err != nil {
+		return trips.Checklist{}, err
+	}
+	if memberID != ci.UserID {
+		return trips.Checklist{}, ErrRBAC
+	}
+	if _, err := mw.readTripWithPermission(ctx, tripID, ci.UserID, trips.PermissionEditItinerary); err != nil {
+		return trips.Checklist{}, err
+	}
+	return mw.next.ApplyTemplate(ContextWithTemplateInfo(ctx, tmpl), ID, tripID, memberID)
+}
+
+// SendReminders is run by the server, not on behalf of a user.
+func (mw *rbacMiddleware) SendReminders(ctx context.Context, now time.Time) error {
+	return ErrRBAC
+}
@@ -0,0 +1,182 @@
+package checklists
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"sort"
+	"time"
+
+	"github.com/travelreys/travelreys/pkg/auth"
+	"github.com/travelreys/travelreys/pkg/common"
+	"github.com/travelreys/travelreys/pkg/trips"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultRemindersInterval = time.Hour
+
+	// reminderLeadTime is how long before its due date an item is reminded.
+	reminderLeadTime = 48 * time.Hour
+
+	// Trips are searched for due items within these bounds of their start
+	// date, which covers items due up to a year before or a month after.
+	remindersTripsLookBehind = 31 * 24 * time.Hour
+	remindersTripsLookAhead  = 366 * 24 * time.Hour
+	remindersTripsPageSize   = 100
+
+	defaultReminderSender = "notifications@travelreys.com"
+
+	reminderTmplFilePath  = "assets/checklistReminderEmail.tmpl.html"
+	reminderTmplFileName  = "checklistReminderEmail.tmpl.html"
+	reminderDueDateFormat = "Mon, 2 Jan"
+)
+
+type dueItem struct {
+	Checklist string
+	Title     string
+	DueDate   string
+	dueAt     time.Time
+}
+
+// RunReminders sends the checklist reminders every interval
+// until the context is done.
+func RunReminders(ctx context.Context, svc Service, interval time.Duration, logger *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := svc.SendReminders(ctx, time.Now()); err != nil {
+			logger.Error("SendReminders", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (svc *service) SendReminders(ctx context.Context, now time.Time) error {
+	startsAfter := now.Add(-remindersTripsLookBehind)
+	startsBefore := now.Add(remindersTripsLookAhead)
+	archived := false
+	ff := trips.ListFilter{
+		Archived:     &archived,
+		StartsAfter:  &startsAfter,
+		StartsBefore: &startsBefore,
+		Limit:        remindersTripsPageSize,
+	}
+	for {
+		tripsList, err := svc.tripStore.List(ctx, ff)
+		if err != nil {
+			return err
+		}
+		for _, trip := range tripsList {
+			svc.sendTripReminders(ctx, trip, now)
+		}
+		ff.Cursor = ff.NextCursor(tripsList)
+		if ff.Cursor == "" {
+			return nil
+		}
+	}
+}
+
+// sendTripReminders emails every member the unchecked items due soon
+// which are assigned to them, or to no one, and were not reminded yet.
+func (svc *service) sendTripReminders(ctx context.Context, trip *trips.Trip, now time.Time) {
+	memberIDs := trip.GetMemberIDs()
+	dueItems := map[string][]dueItem{}
+
+	for _, cl := range trip.Checklists {
+		for _, item := range cl.Items {
+			dueAt, ok := item.DueDate(trip.StartDate)
+			if !ok || item.Checked || dueAt.Before(now) || dueAt.Sub(now) > reminderLeadTime {
+				continue
+			}
+			key := fmt.Sprintf("%s|%s|%s|%s", trip.ID, cl.ID, item.ID, dueAt.Format(time.RFC3339))
+			isNew, err := svc.store.SaveReminder(ctx, key)
+			if err != nil || !isNew {
+				continue
+			}
+
+			due := dueItem{
+				Checklist: cl.Title,
+				Title:     item.Title,
+				DueDate:   dueAt.Format(reminderDueDateFormat),
+				dueAt:     dueAt,
+			}
+			recipients := memberIDs
+			if common.StringContains(memberIDs, item.AssigneeID) {
+				recipients = []string{item.AssigneeID}
+			}
+			for _, memberID := range recipients {
+				dueItems[memberID] = append(dueItems[memberID], due)
+			}
+		}
+	}
+	if len(dueItems) == 0 {
+		return
+	}
+
+	userIDs := []string{}
+	for memberID := range dueItems {
+		userIDs = append(userIDs, memberID)
+	}
+	users, err := svc.authSvc.List(ctx, auth.ListFilter{IDs: userIDs})
+	if err != nil {
+		svc.logger.Error("sendTripReminders", zap.String("tripID", trip.ID), zap.Error(err))
+		return
+	}
+	for _, usr := range users {
+		items := dueItems[usr.ID]
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].dueAt.Before(items[j].dueAt)
+		})
+		svc.sendReminderEmail(ctx, trip, usr, items)
+	}
+}
+
+func (svc *service) sendReminderEmail(
+	ctx context.Context,
+	trip *trips.Trip,
+	user auth.User,
+	items []dueItem,
+) {
+	svc.logger.Info("sending checklist reminder email", zap.String("to", user.Email))
+	t, err := template.
+		New(reminderTmplFileName).
+		ParseFiles(reminderTmplFilePath)
+	if err != nil {
+		svc.logger.Error("sendReminderEmail", zap.Error(err))
+		return
+	}
+
+	var doc bytes.Buffer
+	data := struct {
+		TripID   string
+		TripName string
+		Items    []dueItem
+	}{trip.ID, trip.Name, items}
+	if err := t.Execute(&doc, data); err != nil {
+		svc.logger.Error("sendReminderEmail", zap.Error(err))
+		return
+	}
+
+	mailBody, err := svc.mailSvc.InsertContentOnTemplate(doc.String())
+	if err != nil {
+		svc.logger.Error("sendReminderEmail", zap.Error(err))
+		return
+	}
+
+	subj := fmt.Sprintf("To-dos due soon for %s", trip.Name)
+	if err := svc.mailSvc.SendMail(
+		ctx,
+		user.Email,
+		defaultReminderSender,
+		subj,
+		mailBody,
+	); err != nil {
+		svc.logger.Error("sendReminderEmail", zap.Error(err))
+	}
+}
@@ -0,0 +1,133 @@
+package checklists
+
+import (
+	"context"
+	"time"
+
+	"github.com/travelreys/travelreys/pkg/auth"
+	"github.com/travelreys/travelreys/pkg/email"
+	"github.com/travelreys/travelreys/pkg/trips"
+	"go.uber.org/zap"
+)
+
+type Service interface {
+	CreateTemplate(ctx context.Context, ownerID, title string, items []TemplateItem) (Template, error)
+	CreateTemplateFromChecklist(ctx context.Context, ownerID, tripID, checklistID string) (Template, error)
+	ReadTemplate(ctx context.Context, ID string) (Template, error)
+	ListTemplates(ctx context.Context, ownerID string) (TemplatesList, error)
+	UpdateTemplate(ctx context.Context, ID, title string, items []TemplateItem) (Template, error)
+	DeleteTemplate(ctx context.Context, ID string) error
+
+	// ApplyTemplate adds a new checklist made from the template to the trip.
+	ApplyTemplate(ctx context.Context, ID, tripID, memberID string) (trips.Checklist, error)
+
+	// SendReminders emails the members the checklist items due soon.
+	SendReminders(ctx context.Context, now time.Time) error
+}
+
+type service struct {
+	authSvc   auth.Service
+	tripSvc   trips.Service
+	tripStore trips.Store
+	syncSvc   trips.SyncService
+	mailSvc   email.Service
+	store     Store
+	logger    *zap.Logger
+}
+
+func NewService(
+	authSvc auth.Service,
+	tripSvc trips.Service,
+	tripStore trips.Store,
+	syncSvc trips.SyncService,
+	mailSvc email.Service,
+	store Store,
+	logger *zap.Logger,
+) Service {
+	return &service{authSvc, tripSvc, tripStore, syncSvc, mailSvc, store, logger}
+}
+
+func (svc *service) CreateTemplate(ctx context.Context, ownerID, title string, items []TemplateItem) (Template, error) {
+	tmpl := NewTemplate(ownerID, title, items)
+	return tmpl, svc.store.SaveTemplate(ctx, tmpl)
+}
+
+func (svc *service) CreateTemplateFromChecklist(
+	ctx context.Context,
+	ownerID,
+	tripID,
+	checklistID string,
+) (Template, error) {
+	trip, err := svc.tripFromContext(ctx, tripID)
+	if err != nil {
+		return Template{}, err
+	}
+	cl, ok := trip.Checklists[checklistID]
+	if !ok {
+		return Template{}, ErrChecklistNotFound
+	}
+	tmpl := NewTemplateFromChecklist(ownerID, *cl)
+	return tmpl, svc.store.SaveTemplate(ctx, tmpl)
+}
+
+func (svc *service) ReadTemplate(ctx context.Context, ID string) (Template, error) {
+	return svc.store.ReadTemplate(ctx, ID)
+}
+
+func (svc *service) ListTemplates(ctx context.Context, ownerID string) (TemplatesList, error) {
+	return svc.store.ListTemplates(ctx, ownerID)
+}
+
+func (svc *service) UpdateTemplate(ctx context.Context, ID, title string, items []TemplateItem) (Template, error) {
+	tmpl, err := svc.templateFromContext(ctx, ID)
+	if err != nil {
+		return Template{}, err
+	}
+	if items == nil {
+		items = []TemplateItem{}
+	}
+	tmpl.Title = title
+	tmpl.Items = items
+	tmpl.UpdatedAt = time.Now()
+	return tmpl, svc.store.SaveTemplate(ctx, tmpl)
+}
+
+func (svc *service) DeleteTemplate(ctx context.Context, ID string) error {
+	return svc.store.DeleteTemplate(ctx, ID)
+}
+
+func (svc *service) ApplyTemplate(ctx context.Context, ID, tripID, memberID string) (trips.Checklist, error) {
+	tmpl, err := svc.templateFromContext(ctx, ID)
+	if err != nil {
+		return trips.Checklist{}, err
+	}
+	cl, err := tmpl.ToChecklist()
+	if err != nil {
+		return trips.Checklist{}, err
+	}
+
+	return cl, trips.SyncUpdate(
+		ctx,
+		svc.syncSvc,
+		tripID,
+		memberID,
+		trips.SyncMsgTOBUpdateOpAddChecklist,
+		trips.MakeSyncMsgTOBUpdateOpAddChecklistOps(cl),
+	)
+}
+
+func (svc *service) templateFromContext(ctx context.Context, ID string) (Template, error) {
+	tmpl, err := TemplateInfoFromCtx(ctx)
+	if err == nil {
+		return tmpl, nil
+	}
+	return svc.store.ReadTemplate(ctx, ID)
+}
+
+func (svc *service) tripFromContext(ctx context.Context, ID string) (*trips.Trip, error) {
+	ti, err := trips.TripInfoFromCtx(ctx)
+	if err == nil && ti.Trip != nil {
+		return ti.Trip, nil
+	}
+	return svc.tripSvc.Read(ctx, ID)
+}
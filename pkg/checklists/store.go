@@ -0,0 +1,117 @@
+package checklists
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	bsonKeyID      = "id"
+	bsonKeyOwnerID = "ownerID"
+	bsonKeyKey     = "key"
+
+	CollChecklistTemplates = "checklist_templates"
+	CollChecklistReminders = "checklist_reminders"
+)
+
+var (
+	ErrTemplateNotFound     = errors.New("checklists.ErrTemplateNotFound")
+	ErrUnexpectedStoreError = errors.New("checklists.ErrUnexpectedStoreError")
+)
+
+type Store interface {
+	SaveTemplate(ctx context.Context, tmpl Template) error
+	ReadTemplate(ctx context.Context, ID string) (Template, error)
+	ListTemplates(ctx context.Context, ownerID string) (TemplatesList, error)
+	DeleteTemplate(ctx context.Context, ID string) error
+
+	// SaveReminder records the reminder with the given key, returning
+	// false when it was already recorded.
+	SaveReminder(ctx context.Context, key string) (bool, error)
+}
+
+type store struct {
+	db            *mongo.Database
+	templatesColl *mongo.Collection
+	remindersColl *mongo.Collection
+	logger        *zap.Logger
+}
+
+func NewStore(ctx context.Context, db *mongo.Database, logger *zap.Logger) Store {
+	templatesColl := db.Collection(CollChecklistTemplates)
+	templatesColl.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.M{bsonKeyID: 1}},
+		{Keys: bson.M{bsonKeyOwnerID: 1}},
+	})
+	remindersColl := db.Collection(CollChecklistReminders)
+	remindersColl.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.M{bsonKeyKey: 1}, Options: options.Index().SetUnique(true)},
+	})
+	return &store{db, templatesColl, remindersColl, logger.Named("checklists.store")}
+}
+
+func (s *store) SaveTemplate(ctx context.Context, tmpl Template) error {
+	saveFF := bson.M{bsonKeyID: tmpl.ID}
+	opts := options.Replace().SetUpsert(true)
+	_, err := s.templatesColl.ReplaceOne(ctx, saveFF, tmpl, opts)
+	if err != nil {
+		s.logger.Error("SaveTemplate", zap.Error(err))
+		return ErrUnexpectedStoreError
+	}
+	return nil
+}
+
+func (s *store) ReadTemplate(ctx context.Context, ID string) (Template, error) {
+	var tmpl Template
+	err := s.templatesColl.FindOne(ctx, bson.M{bsonKeyID: ID}).Decode(&tmpl)
+	if err == mongo.ErrNoDocuments {
+		return Template{}, ErrTemplateNotFound
+	}
+	if err != nil {
+		s.logger.Error("ReadTemplate", zap.String("id", ID), zap.Error(err))
+		return Template{}, ErrUnexpectedStoreError
+	}
+	return tmpl, nil
+}
+
+func (s *store) ListTemplates(ctx context.Context, ownerID string) (TemplatesList, error) {
+	list := TemplatesList{}
+	opts := options.Find().SetSort(bson.M{"title": 1})
+	cursor, err := s.templatesColl.Find(ctx, bson.M{bsonKeyOwnerID: ownerID}, opts)
+	if err != nil {
+		s.logger.Error("ListTemplates", zap.Error(err))
+		return list, ErrUnexpectedStoreError
+	}
+	err = cursor.All(ctx, &list)
+	return list, err
+}
+
+func (s *store) DeleteTemplate(ctx context.Context, ID string) error {
+	_, err := s.templatesColl.DeleteOne(ctx, bson.M{bsonKeyID: ID})
+	if err != nil {
+		s.logger.Error("DeleteTemplate", zap.String("id", ID), zap.Error(err))
+		return ErrUnexpectedStoreError
+	}
+	return nil
+}
+
+func (s *store) SaveReminder(ctx context.Context, key string) (bool, error) {
+	_, err := s.remindersColl.InsertOne(ctx, bson.M{
+		bsonKeyKey:  key,
+		"createdAt": time.Now(),
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		s.logger.Error("SaveReminder", zap.String("key", key), zap.Error(err))
+		return false, ErrUnexpectedStoreError
+	}
+	return true, nil
+}
@@ -0,0 +1,164 @@
+package checklists
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/travelreys/travelreys/pkg/common"
+	"github.com/travelreys/travelreys/pkg/reqctx"
+	"github.com/travelreys/travelreys/pkg/trips"
+)
+
+const (
+	URLPathVarID = "id"
+)
+
+func errToHttpCode(err error) int {
+	notFoundErrors := []error{
+		ErrTemplateNotFound,
+		ErrChecklistNotFound,
+		trips.ErrTripNotFound,
+	}
+	appErrors := []error{ErrUnexpectedStoreError, trips.ErrUpdateNotApplied, trips.ErrUpdateTimeout}
+
+	if common.ErrorContains(notFoundErrors, err) {
+		return http.StatusNotFound
+	}
+	if common.ErrorContains(appErrors, err) {
+		return http.StatusUnprocessableEntity
+	}
+	if errors.Is(err, ErrRBAC) || errors.Is(err, trips.ErrRBAC) {
+		return http.StatusUnauthorized
+	}
+	if errors.Is(err, common.ErrValidation) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if e, ok := response.(common.Errorer); ok && e.Error() != nil {
+		common.EncodeErrorFactory(errToHttpCode)(ctx, e.Error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Encoding", "gzip")
+
+	gw := gzip.NewWriter(w)
+	defer gw.Close()
+
+	return json.NewEncoder(gw).Encode(response)
+}
+
+func MakeHandler(svc Service) http.Handler {
+	r := mux.NewRouter()
+
+	opts := []kithttp.ServerOption{
+		kithttp.ServerBefore(reqctx.ContextWithClientInfo),
+		kithttp.ServerErrorEncoder(common.EncodeErrorFactory(errToHttpCode)),
+	}
+
+	createTemplateHandler := kithttp.NewServer(
+		NewCreateTemplateEndpoint(svc),
+		decodeCreateTemplateRequest,
+		encodeResponse, opts...,
+	)
+	listTemplatesHandler := kithttp.NewServer(
+		NewListTemplatesEndpoint(svc),
+		decodeListTemplatesRequest,
+		encodeResponse, opts...,
+	)
+	readTemplateHandler := kithttp.NewServer(
+		NewReadTemplateEndpoint(svc),
+		decodeReadTemplateRequest,
+		encodeResponse, opts...,
+	)
+	updateTemplateHandler := kithttp.NewServer(
+		NewUpdateTemplateEndpoint(svc),
+		decodeUpdateTemplateRequest,
+		encodeResponse, opts...,
+	)
+	deleteTemplateHandler := kithttp.NewServer(
+		NewDeleteTemplateEndpoint(svc),
+		decodeDeleteTemplateRequest,
+		encodeResponse, opts...,
+	)
+	applyTemplateHandler := kithttp.NewServer(
+		NewApplyTemplateEndpoint(svc),
+		decodeApplyTemplateRequest,
+		encodeResponse, opts...,
+	)
+
+	r.Handle("/api/v1/checklists/templates", createTemplateHandler).Methods(http.MethodPost)
+	r.Handle("/api/v1/checklists/templates", listTemplatesHandler).Methods(http.MethodGet)
+	r.Handle("/api/v1/checklists/templates/{id}", readTemplateHandler).Methods(http.MethodGet)
+	r.Handle("/api/v1/checklists/templates/{id}", updateTemplateHandler).Methods(http.MethodPut)
+	r.Handle("/api/v1/checklists/templates/{id}", deleteTemplateHandler).Methods(http.MethodDelete)
+	r.Handle("/api/v1/checklists/templates/{id}/apply", applyTemplateHandler).Methods(http.MethodPost)
+
+	return r
+}
+
+func decodeCreateTemplateRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	req := CreateTemplateRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, common.ErrInvalidJSONBody
+	}
+	return req, nil
+}
+
+func decodeListTemplatesRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return ListTemplatesRequest{OwnerID: r.URL.Query().Get("ownerID")}, nil
+}
+
+func decodeReadTemplateRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	ID, ok := vars[URLPathVarID]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	return ReadTemplateRequest{ID}, nil
+}
+
+func decodeUpdateTemplateRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	ID, ok := vars[URLPathVarID]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	req := UpdateTemplateRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, common.ErrInvalidJSONBody
+	}
+	req.ID = ID
+	return req, nil
+}
+
+func decodeDeleteTemplateRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	ID, ok := vars[URLPathVarID]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	return DeleteTemplateRequest{ID}, nil
+}
+
+func decodeApplyTemplateRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	ID, ok := vars[URLPathVarID]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	req := ApplyTemplateRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, common.ErrInvalidJSONBody
+	}
+	req.ID = ID
+	return req, nil
+}
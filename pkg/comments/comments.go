@@ -0,0 +1,164 @@
+package comments
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/travelreys/travelreys/pkg/common"
+	"github.com/travelreys/travelreys/pkg/trips"
+)
+
+const (
+	maxCommentBodyLength = 4000
+	maxReactionLength    = 32
+)
+
+var (
+	ErrInvalidEntityPath = errors.New("comments.ErrInvalidEntityPath")
+	ErrEntityNotFound    = errors.New("comments.ErrEntityNotFound")
+	ErrInvalidMention    = errors.New("comments.ErrInvalidMention")
+
+	// entityPathRegexp matches the JSON path of the trip entities
+	// which can be commented on.
+	entityPathRegexp = regexp.MustCompile(
+		`^/(itineraries/\d{4}-\d{2}-\d{2}/activities|lodgings|transits|links)/([^/]+)$`,
+	)
+)
+
+type Comment struct {
+	ID         string `json:"id" bson:"id"`
+	TripID     string `json:"tripID" bson:"tripID"`
+	EntityPath string `json:"entityPath" bson:"entityPath"`
+	AuthorID   string `json:"authorID" bson:"authorID"`
+	Body       string `json:"body" bson:"body"`
+
+	// Mentions are the IDs of the members mentioned in the comment
+	Mentions []string `json:"mentions" bson:"mentions"`
+
+	// Reactions maps a reaction (e.g an emoji) to the IDs of the members
+	Reactions map[string][]string `json:"reactions" bson:"reactions"`
+
+	CreatedAt time.Time     `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time     `json:"updatedAt" bson:"updatedAt"`
+	Labels    common.Labels `json:"labels" bson:"labels"`
+}
+
+func NewComment(tripID, entityPath, authorID, body string, mentions []string) Comment {
+	return Comment{
+		ID:         uuid.NewString(),
+		TripID:     tripID,
+		EntityPath: entityPath,
+		AuthorID:   authorID,
+		Body:       body,
+		Mentions:   dedupe(mentions),
+		Reactions:  map[string][]string{},
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+		Labels:     common.Labels{},
+	}
+}
+
+func (c Comment) IsEdited() bool {
+	return c.UpdatedAt.After(c.CreatedAt)
+}
+
+// AddReaction returns true if the reaction of the member was added.
+func (c *Comment) AddReaction(memberID, reaction string) bool {
+	if c.Reactions == nil {
+		c.Reactions = map[string][]string{}
+	}
+	if common.StringContains(c.Reactions[reaction], memberID) {
+		return false
+	}
+	c.Reactions[reaction] = append(c.Reactions[reaction], memberID)
+	return true
+}
+
+// RemoveReaction returns true if the reaction of the member was removed.
+func (c *Comment) RemoveReaction(memberID, reaction string) bool {
+	members := []string{}
+	for _, id := range c.Reactions[reaction] {
+		if id != memberID {
+			members = append(members, id)
+		}
+	}
+	if len(members) == len(c.Reactions[reaction]) {
+		return false
+	}
+	if len(members) == 0 {
+		delete(c.Reactions, reaction)
+	} else {
+		c.Reactions[reaction] = members
+	}
+	return true
+}
+
+type CommentsList []Comment
+
+// ValidateEntityPath checks that the path refers to an activity, lodging,
+// transit or link of the trip.
+func ValidateEntityPath(trip *trips.Trip, path string) error {
+	matches := entityPathRegexp.FindStringSubmatch(path)
+	if matches == nil {
+		return ErrInvalidEntityPath
+	}
+
+	id := matches[2]
+	exists := false
+	switch {
+	case strings.HasPrefix(matches[1], "itineraries"):
+		dtKey := strings.Split(matches[1], "/")[1]
+		if itin, ok := trip.Itineraries[dtKey]; ok {
+			_, exists = itin.Activities[id]
+		}
+	case matches[1] == "lodgings":
+		_, exists = trip.Lodgings[id]
+	case matches[1] == "transits":
+		_, exists = trip.Transits[id]
+	case matches[1] == "links":
+		_, exists = trip.Links[id]
+	}
+	if !exists {
+		return ErrEntityNotFound
+	}
+	return nil
+}
+
+// ValidateMentions checks that the mentioned users are members of the trip.
+func ValidateMentions(trip *trips.Trip, mentions []string) error {
+	memberIDs := trip.GetMemberIDs()
+	for _, id := range mentions {
+		if !common.StringContains(memberIDs, id) {
+			return ErrInvalidMention
+		}
+	}
+	return nil
+}
+
+// newMentions returns the mentions in curr which are not in prev.
+func newMentions(prev, curr []string) []string {
+	result := []string{}
+	for _, id := range curr {
+		if !common.StringContains(prev, id) {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+func dedupe(list []string) []string {
+	seen := map[string]bool{}
+	result := []string{}
+	for _, item := range list {
+		if item != "" && !seen[item] {
+			seen[item] = true
+			result = append(result, item)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,40 @@
+package comments
+
+import (
+	"context"
+	"errors"
+
+	"github.com/travelreys/travelreys/pkg/common"
+	"github.com/travelreys/travelreys/pkg/trips"
+)
+
+var (
+	ErrCommentInfoNotSet = errors.New("comments.ErrCommentInfoNotSet")
+)
+
+type CommentInfo struct {
+	Trip    *trips.Trip
+	Comment *Comment
+}
+
+func ContextWithCommentInfo(
+	ctx context.Context,
+	trip *trips.Trip,
+	comment *Comment,
+) context.Context {
+	return context.WithValue(
+		ctx,
+		common.ContextKeyCommentInfo,
+		CommentInfo{trip, comment},
+	)
+}
+
+func CommentInfoFromCtx(ctx context.Context) (CommentInfo, error) {
+	val := ctx.Value(common.ContextKeyCommentInfo)
+	if val == nil {
+		return CommentInfo{}, ErrCommentInfoNotSet
+	}
+
+	ci, _ := val.(CommentInfo)
+	return ci, nil
+}
@@ -0,0 +1,171 @@
+package comments
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/travelreys/travelreys/pkg/common"
+)
+
+type CreateCommentRequest struct {
+	TripID     string   `json:"tripID"`
+	AuthorID   string   `json:"authorID"`
+	EntityPath string   `json:"entityPath"`
+	Body       string   `json:"body"`
+	Mentions   []string `json:"mentions"`
+}
+
+type CreateCommentResponse struct {
+	Comment Comment `json:"comment"`
+	Err     error   `json:"error,omitempty"`
+}
+
+func (r CreateCommentResponse) Error() error {
+	return r.Err
+}
+
+func NewCreateCommentEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(CreateCommentRequest)
+		if !ok {
+			return CreateCommentResponse{
+				Err: common.ErrEndpointReqMismatch,
+			}, nil
+		}
+		comment, err := svc.Create(
+			ctx,
+			req.TripID,
+			req.AuthorID,
+			req.EntityPath,
+			req.Body,
+			req.Mentions,
+		)
+		return CreateCommentResponse{Comment: comment, Err: err}, nil
+	}
+}
+
+type ListCommentsRequest struct {
+	ListCommentsFilter
+}
+
+type ListCommentsResponse struct {
+	Comments CommentsList `json:"comments"`
+	Err      error        `json:"error,omitempty"`
+}
+
+func (r ListCommentsResponse) Error() error {
+	return r.Err
+}
+
+func NewListCommentsEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(ListCommentsRequest)
+		if !ok {
+			return ListCommentsResponse{
+				Err: common.ErrEndpointReqMismatch,
+			}, nil
+		}
+		comments, err := svc.List(ctx, req.ListCommentsFilter)
+		return ListCommentsResponse{Comments: comments, Err: err}, nil
+	}
+}
+
+type UpdateCommentRequest struct {
+	ID       string   `json:"id"`
+	Body     string   `json:"body"`
+	Mentions []string `json:"mentions"`
+}
+
+type UpdateCommentResponse struct {
+	Comment Comment `json:"comment"`
+	Err     error   `json:"error,omitempty"`
+}
+
+func (r UpdateCommentResponse) Error() error {
+	return r.Err
+}
+
+func NewUpdateCommentEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(UpdateCommentRequest)
+		if !ok {
+			return UpdateCommentResponse{
+				Err: common.ErrEndpointReqMismatch,
+			}, nil
+		}
+		comment, err := svc.Update(ctx, req.ID, req.Body, req.Mentions)
+		return UpdateCommentResponse{Comment: comment, Err: err}, nil
+	}
+}
+
+type DeleteCommentRequest struct {
+	ID string `json:"id"`
+}
+
+type DeleteCommentResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r DeleteCommentResponse) Error() error {
+	return r.Err
+}
+
+func NewDeleteCommentEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(DeleteCommentRequest)
+		if !ok {
+			return DeleteCommentResponse{
+				Err: common.ErrEndpointReqMismatch,
+			}, nil
+		}
+		err := svc.Delete(ctx, req.ID)
+		return DeleteCommentResponse{Err: err}, nil
+	}
+}
+
+type ReactionRequest struct {
+	ID       string `json:"id"`
+	MemberID string `json:"memberID"`
+	Reaction string `json:"reaction"`
+}
+
+type ReactionResponse struct {
+	Comment Comment `json:"comment"`
+	Err     error   `json:"error,omitempty"`
+}
+
+func (r ReactionResponse) Error() error {
+	return r.Err
+}
+
+type AddReactionRequest ReactionRequest
+type AddReactionResponse = ReactionResponse
+
+func NewAddReactionEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(AddReactionRequest)
+		if !ok {
+			return AddReactionResponse{
+				Err: common.ErrEndpointReqMismatch,
+			}, nil
+		}
+		comment, err := svc.AddReaction(ctx, req.ID, req.MemberID, req.Reaction)
+		return AddReactionResponse{Comment: comment, Err: err}, nil
+	}
+}
+
+type RemoveReactionRequest ReactionRequest
+type RemoveReactionResponse = ReactionResponse
+
+func NewRemoveReactionEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(RemoveReactionRequest)
+		if !ok {
+			return RemoveReactionResponse{
+				Err: common.ErrEndpointReqMismatch,
+			}, nil
+		}
+		comment, err := svc.RemoveReaction(ctx, req.ID, req.MemberID, req.Reaction)
+		return RemoveReactionResponse{Comment: comment, Err: err}, nil
+	}
+}
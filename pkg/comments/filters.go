@@ -0,0 +1,48 @@
+package comments
+
+import (
+	"errors"
+	"net/url"
+
+	"github.com/travelreys/travelreys/pkg/common"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var (
+	ErrInvalidFilter = errors.New("comments.ErrInvalidFilter")
+)
+
+type ListCommentsFilter struct {
+	TripID     *string
+	EntityPath *string
+}
+
+func MakeListCommentsFilterFromURLParams(params url.Values) ListCommentsFilter {
+	ff := ListCommentsFilter{}
+	if params.Get("tripID") != "" {
+		ff.TripID = common.StringPtr(params.Get("tripID"))
+	}
+	if params.Get("entityPath") != "" {
+		ff.EntityPath = common.StringPtr(params.Get("entityPath"))
+	}
+	return ff
+}
+
+// Validate checks that the filter is scoped to a trip.
+func (f ListCommentsFilter) Validate() error {
+	if f.TripID == nil || *f.TripID == "" {
+		return ErrInvalidFilter
+	}
+	return nil
+}
+
+func (f ListCommentsFilter) toBSON() bson.M {
+	bsonM := bson.M{}
+	if f.TripID != nil && *f.TripID != "" {
+		bsonM["tripID"] = *f.TripID
+	}
+	if f.EntityPath != nil && *f.EntityPath != "" {
+		bsonM["entityPath"] = *f.EntityPath
+	}
+	return bsonM
+}
@@ -0,0 +1,226 @@
+package comments
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/travelreys/travelreys/pkg/common"
+	"github.com/travelreys/travelreys/pkg/reqctx"
+	"github.com/travelreys/travelreys/pkg/trips"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrRBAC = errors.New("comments.ErrRBAC")
+)
+
+type validationMiddleware struct {
+	next   Service
+	logger *zap.Logger
+}
+
+func SvcWithValidationMw(svc Service, logger *zap.Logger) Service {
+	return &validationMiddleware{svc, logger.Named("comments.validationMiddleware")}
+}
+
+func isValidBody(body string) bool {
+	body = strings.TrimSpace(body)
+	return body != "" && utf8.RuneCountInString(body) <= maxCommentBodyLength
+}
+
+func isValidReaction(reaction string) bool {
+	return reaction != "" && utf8.RuneCountInString(reaction) <= maxReactionLength
+}
+
+func (mw *validationMiddleware) Create(
+	ctx context.Context,
+	tripID,
+	authorID,
+	entityPath,
+	body string,
+	mentions []string,
+) (Comment, error) {
+	if tripID == "" || authorID == "" || entityPath == "" || !isValidBody(body) {
+		mw.logger.Warn("Create")
+		return Comment{}, common.ErrValidation
+	}
+	return mw.next.Create(ctx, tripID, authorID, entityPath, strings.TrimSpace(body), mentions)
+}
+
+func (mw *validationMiddleware) Read(ctx context.Context, ID string) (Comment, error) {
+	if ID == "" {
+		mw.logger.Warn("Read")
+		return Comment{}, common.ErrValidation
+	}
+	return mw.next.Read(ctx, ID)
+}
+
+func (mw *validationMiddleware) List(ctx context.Context, ff ListCommentsFilter) (CommentsList, error) {
+	if err := ff.Validate(); err != nil {
+		mw.logger.Warn("List")
+		return nil, common.ErrValidation
+	}
+	return mw.next.List(ctx, ff)
+}
+
+func (mw *validationMiddleware) Update(ctx context.Context, ID, body string, mentions []string) (Comment, error) {
+	if ID == "" || !isValidBody(body) {
+		mw.logger.Warn("Update")
+		return Comment{}, common.ErrValidation
+	}
+	return mw.next.Update(ctx, ID, strings.TrimSpace(body), mentions)
+}
+
+func (mw *validationMiddleware) Delete(ctx context.Context, ID string) error {
+	if ID == "" {
+		mw.logger.Warn("Delete")
+		return common.ErrValidation
+	}
+	return mw.next.Delete(ctx, ID)
+}
+
+func (mw *validationMiddleware) AddReaction(ctx context.Context, ID, memberID, reaction string) (Comment, error) {
+	if ID == "" || memberID == "" || !isValidReaction(reaction) {
+		mw.logger.Warn("AddReaction")
+		return Comment{}, common.ErrValidation
+	}
+	return mw.next.AddReaction(ctx, ID, memberID, reaction)
+}
+
+func (mw *validationMiddleware) RemoveReaction(ctx context.Context, ID, memberID, reaction string) (Comment, error) {
+	if ID == "" || memberID == "" || !isValidReaction(reaction) {
+		mw.logger.Warn("RemoveReaction")
+		return Comment{}, common.ErrValidation
+	}
+	return mw.next.RemoveReaction(ctx, ID, memberID, reaction)
+}
+
+type rbacMiddleware struct {
+	next    Service
+	tripSvc trips.Service
+	logger  *zap.Logger
+}
+
+func SvcWithRBACMw(svc Service, tripSvc trips.Service, logger *zap.Logger) Service {
+	return &rbacMiddleware{svc, tripSvc, logger.Named("comments.rbacMiddleware")}
+}
+
+// readTripAsMember reads the trip if the user is one of its members.
+func (mw *rbacMiddleware) readTripAsMember(ctx context.Context, tripID string) (*trips.Trip, string, error) {
+	ci, err := reqctx.ClientInfoFromCtx(ctx)
+	if err != nil || ci.HasEmptyID() {
+		return nil, "", ErrRBAC
+	}
+	trip, err := mw.tripSvc.Read(ctx, tripID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !common.StringContains(trip.GetMemberIDs(), ci.UserID) {
+		return nil, "", ErrRBAC
+	}
+	return trip, ci.UserID, nil
+}
+
+// readCommentAsMember reads the comment if the user is a member of its trip.
+func (mw *rbacMiddleware) readCommentAsMember(ctx context.Context, ID string) (*trips.Trip, Comment, string, error) {
+	comment, err := mw.next.Read(ctx, ID)
+	if err != nil {
+		return nil, Comment{}, "", err
+	}
+	trip, userID, err := mw.readTripAsMember(ctx, comment.TripID)
+	if err != nil {
+		return nil, Comment{}, "", err
+	}
+	return trip, comment, userID, nil
+}
+
+func (mw *rbacMiddleware) Create(
+	ctx context.Context,
+	tripID,
+	authorID,
+	entityPath,
+	body string,
+	mentions []string,
+) (Comment, error) {
+	trip, userID, err := mw.readTripAsMember(ctx, tripID)
+	if err != nil {
+		return Comment{}, err
+	}
+	if authorID != userID {
+		return Comment{}, ErrRBAC
+	}
+	if err := ValidateEntityPath(trip, entityPath); err != nil {
+		return Comment{}, err
+	}
+	if err := ValidateMentions(trip, mentions); err != nil {
+		return Comment{}, err
+	}
+	return mw.next.Create(
+		ContextWithCommentInfo(ctx, trip, nil),
+		tripID, authorID, entityPath, body, mentions,
+	)
+}
+
+func (mw *rbacMiddleware) Read(ctx context.Context, ID string) (Comment, error) {
+	_, comment, _, err := mw.readCommentAsMember(ctx, ID)
+	return comment, err
+}
+
+func (mw *rbacMiddleware) List(ctx context.Context, ff ListCommentsFilter) (CommentsList, error) {
+	if ff.TripID == nil {
+		return nil, common.ErrValidation
+	}
+	if _, _, err := mw.readTripAsMember(ctx, *ff.TripID); err != nil {
+		return nil, err
+	}
+	return mw.next.List(ctx, ff)
+}
+
+func (mw *rbacMiddleware) Update(ctx context.Context, ID, body string, mentions []string) (Comment, error) {
+	trip, comment, userID, err := mw.readCommentAsMember(ctx, ID)
+	if err != nil {
+		return Comment{}, err
+	}
+	if comment.AuthorID != userID {
+		return Comment{}, ErrRBAC
+	}
+	if err := ValidateMentions(trip, mentions); err != nil {
+		return Comment{}, err
+	}
+	return mw.next.Update(ContextWithCommentInfo(ctx, trip, &comment), ID, body, mentions)
+}
+
+func (mw *rbacMiddleware) Delete(ctx context.Context, ID string) error {
+	trip, comment, userID, err := mw.readCommentAsMember(ctx, ID)
+	if err != nil {
+		return err
+	}
+	if comment.AuthorID != userID {
+		return ErrRBAC
+	}
+	return mw.next.Delete(ContextWithCommentInfo(ctx, trip, &comment), ID)
+}
+
+func (mw *rbacMiddleware) AddReaction(ctx context.Context, ID, memberID, reaction string) (Comment, error) {
+	trip, comment, userID, err := mw.readCommentAsMember(ctx, ID)
+	if err != nil {
+		return Comment{}, err
+	}
+	if memberID != userID {
+		return Comment{}, ErrRBAC
+	}
+	return mw.next.AddReaction(ContextWithCommentInfo(ctx, trip, &comment), ID, memberID, reaction)
+}
+
+func (mw *rbacMiddleware) RemoveReaction(ctx context.Context, ID, memberID, reaction string) (Comment, error) {
+	trip, comment, userID, err := mw.readCommentAsMember(ctx, ID)
+	if err != nil {
+		return Comment{}, err
+	}
+	if memberID != userID {
+		return Comment{}, ErrRBAC
+	}
+	return mw.next.RemoveReaction(ContextWithCommentInfo(ctx, trip, &comment), ID, memberID, reaction)
+}
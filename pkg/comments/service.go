@@ -0,0 +1,262 @@
+package comments
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"time"
+
+	"github.com/travelreys/travelreys/pkg/auth"
+	"github.com/travelreys/travelreys/pkg/email"
+	"github.com/travelreys/travelreys/pkg/notifications"
+	"github.com/travelreys/travelreys/pkg/trips"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultNotificationSender = "notifications@travelreys.com"
+
+	mentionTmplFilePath = "assets/commentMentionEmail.tmpl.html"
+	mentionTmplFileName = "commentMentionEmail.tmpl.html"
+)
+
+type Service interface {
+	Create(ctx context.Context, tripID, authorID, entityPath, body string, mentions []string) (Comment, error)
+	Read(ctx context.Context, ID string) (Comment, error)
+	List(ctx context.Context, ff ListCommentsFilter) (CommentsList, error)
+	Update(ctx context.Context, ID, body string, mentions []string) (Comment, error)
+	Delete(ctx context.Context, ID string) error
+
+	AddReaction(ctx context.Context, ID, memberID, reaction string) (Comment, error)
+	RemoveReaction(ctx context.Context, ID, memberID, reaction string) (Comment, error)
+}
+
+type service struct {
+	authSvc  auth.Service
+	syncSvc  trips.SyncService
+	mailSvc  email.Service
+	notifSvc notifications.Service
+	store    Store
+	logger   *zap.Logger
+}
+
+func NewService(
+	authSvc auth.Service,
+	syncSvc trips.SyncService,
+	mailSvc email.Service,
+	notifSvc notifications.Service,
+	store Store,
+	logger *zap.Logger,
+) Service {
+	return &service{authSvc, syncSvc, mailSvc, notifSvc, store, logger}
+}
+
+func (svc *service) Create(
+	ctx context.Context,
+	tripID,
+	authorID,
+	entityPath,
+	body string,
+	mentions []string,
+) (Comment, error) {
+	comment := NewComment(tripID, entityPath, authorID, body, mentions)
+	if err := svc.store.Save(ctx, comment); err != nil {
+		return Comment{}, err
+	}
+	svc.broadcast(ctx, comment, trips.SyncMsgBroadcastCommentActionAdd)
+	svc.notifyMentions(ctx, comment, comment.Mentions)
+	return comment, nil
+}
+
+func (svc *service) Read(ctx context.Context, ID string) (Comment, error) {
+	return svc.store.Read(ctx, ID)
+}
+
+func (svc *service) List(ctx context.Context, ff ListCommentsFilter) (CommentsList, error) {
+	return svc.store.List(ctx, ff)
+}
+
+func (svc *service) Update(ctx context.Context, ID, body string, mentions []string) (Comment, error) {
+	comment, err := svc.commentFromContext(ctx, ID)
+	if err != nil {
+		return Comment{}, err
+	}
+
+	prevMentions := comment.Mentions
+	comment.Body = body
+	comment.Mentions = dedupe(mentions)
+	comment.UpdatedAt = time.Now()
+	if err := svc.store.Save(ctx, comment); err != nil {
+		return Comment{}, err
+	}
+	svc.broadcast(ctx, comment, trips.SyncMsgBroadcastCommentActionUpdate)
+	svc.notifyMentions(ctx, comment, newMentions(prevMentions, comment.Mentions))
+	return comment, nil
+}
+
+func (svc *service) Delete(ctx context.Context, ID string) error {
+	comment, err := svc.commentFromContext(ctx, ID)
+	if err != nil {
+		return err
+	}
+	if err := svc.store.Delete(ctx, ID); err != nil {
+		return err
+	}
+	svc.broadcast(ctx, comment, trips.SyncMsgBroadcastCommentActionDelete)
+	return nil
+}
+
+func (svc *service) AddReaction(ctx context.Context, ID, memberID, reaction string) (Comment, error) {
+	comment, err := svc.commentFromContext(ctx, ID)
+	if err != nil {
+		return Comment{}, err
+	}
+	if !comment.AddReaction(memberID, reaction) {
+		return comment, nil
+	}
+	if err := svc.store.Save(ctx, comment); err != nil {
+		return Comment{}, err
+	}
+	svc.broadcast(ctx, comment, trips.SyncMsgBroadcastCommentActionUpdate)
+	return comment, nil
+}
+
+func (svc *service) RemoveReaction(ctx context.Context, ID, memberID, reaction string) (Comment, error) {
+	comment, err := svc.commentFromContext(ctx, ID)
+	if err != nil {
+		return Comment{}, err
+	}
+	if !comment.RemoveReaction(memberID, reaction) {
+		return comment, nil
+	}
+	if err := svc.store.Save(ctx, comment); err != nil {
+		return Comment{}, err
+	}
+	svc.broadcast(ctx, comment, trips.SyncMsgBroadcastCommentActionUpdate)
+	return comment, nil
+}
+
+func (svc *service) commentFromContext(ctx context.Context, ID string) (Comment, error) {
+	ci, err := CommentInfoFromCtx(ctx)
+	if err == nil && ci.Comment != nil {
+		return *ci.Comment, nil
+	}
+	return svc.store.Read(ctx, ID)
+}
+
+// broadcast delivers the change to the members connected to the trip.
+func (svc *service) broadcast(ctx context.Context, comment Comment, action string) {
+	msg := trips.MakeSyncMsgBroadcastTopicComment(
+		comment.TripID,
+		comment.AuthorID,
+		action,
+		comment,
+	)
+	if err := svc.syncSvc.Broadcast(ctx, &msg); err != nil {
+		svc.logger.Error("broadcast", zap.String("id", comment.ID), zap.Error(err))
+	}
+}
+
+// notifyMentions notifies and emails the mentioned members, except the author.
+func (svc *service) notifyMentions(ctx context.Context, comment Comment, mentions []string) {
+	userIDs := []string{}
+	for _, id := range mentions {
+		if id != comment.AuthorID {
+			userIDs = append(userIDs, id)
+		}
+	}
+	if len(userIDs) == 0 {
+		return
+	}
+
+	tripName := ""
+	if ci, err := CommentInfoFromCtx(ctx); err == nil && ci.Trip != nil {
+		tripName = ci.Trip.Name
+	}
+
+	list := notifications.NotificationsList{}
+	for _, id := range userIDs {
+		n := notifications.NewNotification(id, notifications.TypeCommentMention, comment.AuthorID)
+		n.TripID = comment.TripID
+		n.TripName = tripName
+		n.EntityPath = comment.EntityPath
+		n.Labels[notifications.LabelCommentID] = comment.ID
+		list = append(list, n)
+	}
+	if err := svc.notifSvc.Notify(ctx, list); err != nil {
+		svc.logger.Error("notifyMentions", zap.Error(err))
+	}
+
+	go func() {
+		ctx := context.Background()
+		users, err := svc.authSvc.List(ctx, auth.ListFilter{
+			IDs: append(userIDs, comment.AuthorID),
+		})
+		if err != nil {
+			svc.logger.Error("notifyMentions", zap.Error(err))
+			return
+		}
+		authorName := ""
+		for _, usr := range users {
+			if usr.ID == comment.AuthorID {
+				authorName = usr.Name
+			}
+		}
+		for _, usr := range users {
+			if usr.ID != comment.AuthorID {
+				svc.sendMentionEmail(ctx, comment, usr, authorName, tripName)
+			}
+		}
+	}()
+}
+
+func (svc *service) sendMentionEmail(
+	ctx context.Context,
+	comment Comment,
+	user auth.User,
+	authorName,
+	tripName string,
+) {
+	svc.logger.Info("sending mention email", zap.String("to", user.Email))
+	t, err := template.
+		New(mentionTmplFileName).
+		ParseFiles(mentionTmplFilePath)
+	if err != nil {
+		svc.logger.Error("sendMentionEmail", zap.Error(err))
+		return
+	}
+
+	var doc bytes.Buffer
+	data := struct {
+		TripID     string
+		TripName   string
+		AuthorName string
+		Body       string
+	}{
+		comment.TripID,
+		tripName,
+		authorName,
+		comment.Body,
+	}
+	if err := t.Execute(&doc, data); err != nil {
+		svc.logger.Error("sendMentionEmail", zap.Error(err))
+		return
+	}
+
+	mailBody, err := svc.mailSvc.InsertContentOnTemplate(doc.String())
+	if err != nil {
+		svc.logger.Error("sendMentionEmail", zap.Error(err))
+		return
+	}
+
+	subj := "You were mentioned in a comment"
+	if err := svc.mailSvc.SendMail(
+		ctx,
+		user.Email,
+		defaultNotificationSender,
+		subj,
+		mailBody,
+	); err != nil {
+		svc.logger.Error("sendMentionEmail", zap.Error(err))
+	}
+}
@@ -0,0 +1,98 @@
+package comments
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	bsonKeyID    = "id"
+	CollComments = "comments"
+)
+
+var (
+	ErrCommentNotFound      = errors.New("comments.ErrCommentNotFound")
+	ErrUnexpectedStoreError = errors.New("comments.ErrUnexpectedStoreError")
+)
+
+type Store interface {
+	Save(ctx context.Context, comment Comment) error
+	Read(ctx context.Context, ID string) (Comment, error)
+	List(ctx context.Context, ff ListCommentsFilter) (CommentsList, error)
+	Delete(ctx context.Context, ID string) error
+	DeleteByTripID(ctx context.Context, tripID string) error
+}
+
+type store struct {
+	db           *mongo.Database
+	commentsColl *mongo.Collection
+	logger       *zap.Logger
+}
+
+func NewStore(ctx context.Context, db *mongo.Database, logger *zap.Logger) Store {
+	commentsColl := db.Collection(CollComments)
+	commentsColl.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.M{bsonKeyID: 1}},
+		{Keys: bson.D{{Key: "tripID", Value: 1}, {Key: "entityPath", Value: 1}}},
+	})
+	return &store{db, commentsColl, logger.Named("comments.store")}
+}
+
+func (s *store) Save(ctx context.Context, comment Comment) error {
+	saveFF := bson.M{bsonKeyID: comment.ID}
+	opts := options.Replace().SetUpsert(true)
+	_, err := s.commentsColl.ReplaceOne(ctx, saveFF, comment, opts)
+	if err != nil {
+		s.logger.Error("Save", zap.Error(err))
+		return ErrUnexpectedStoreError
+	}
+	return nil
+}
+
+func (s *store) Read(ctx context.Context, ID string) (Comment, error) {
+	var comment Comment
+	err := s.commentsColl.FindOne(ctx, bson.M{bsonKeyID: ID}).Decode(&comment)
+	if err == mongo.ErrNoDocuments {
+		return Comment{}, ErrCommentNotFound
+	}
+	if err != nil {
+		s.logger.Error("Read", zap.String("id", ID), zap.Error(err))
+		return Comment{}, ErrUnexpectedStoreError
+	}
+	return comment, nil
+}
+
+func (s *store) List(ctx context.Context, ff ListCommentsFilter) (CommentsList, error) {
+	list := CommentsList{}
+	opts := options.Find().SetSort(bson.M{"createdAt": 1})
+	cursor, err := s.commentsColl.Find(ctx, ff.toBSON(), opts)
+	if err != nil {
+		s.logger.Error("List", zap.Error(err))
+		return list, ErrUnexpectedStoreError
+	}
+	err = cursor.All(ctx, &list)
+	return list, err
+}
+
+func (s *store) Delete(ctx context.Context, ID string) error {
+	_, err := s.commentsColl.DeleteOne(ctx, bson.M{bsonKeyID: ID})
+	if err != nil {
+		s.logger.Error("Delete", zap.String("id", ID), zap.Error(err))
+		return ErrUnexpectedStoreError
+	}
+	return nil
+}
+
+func (s *store) DeleteByTripID(ctx context.Context, tripID string) error {
+	_, err := s.commentsColl.DeleteMany(ctx, bson.M{"tripID": tripID})
+	if err != nil {
+		s.logger.Error("DeleteByTripID", zap.String("tripID", tripID), zap.Error(err))
+		return ErrUnexpectedStoreError
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package comments
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/travelreys/travelreys/pkg/common"
+	"github.com/travelreys/travelreys/pkg/reqctx"
+	"github.com/travelreys/travelreys/pkg/trips"
+)
+
+const (
+	URLPathVarID = "id"
+)
+
+func errToHttpCode(err error) int {
+	notFoundErrors := []error{
+		ErrCommentNotFound,
+		ErrEntityNotFound,
+		trips.ErrTripNotFound,
+	}
+	appErrors := []error{ErrUnexpectedStoreError}
+	validationErrors := []error{
+		common.ErrValidation,
+		ErrInvalidEntityPath,
+		ErrInvalidMention,
+	}
+
+	if common.ErrorContains(notFoundErrors, err) {
+		return http.StatusNotFound
+	}
+	if common.ErrorContains(appErrors, err) {
+		return http.StatusUnprocessableEntity
+	}
+	if common.ErrorContains(validationErrors, err) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, ErrRBAC) {
+		return http.StatusUnauthorized
+	}
+	return http.StatusInternalServerError
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if e, ok := response.(common.Errorer); ok && e.Error() != nil {
+		common.EncodeErrorFactory(errToHttpCode)(ctx, e.Error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Encoding", "gzip")
+
+	gw := gzip.NewWriter(w)
+	defer gw.Close()
+
+	return json.NewEncoder(gw).Encode(response)
+}
+
+func MakeHandler(svc Service) http.Handler {
+	r := mux.NewRouter()
+
+	opts := []kithttp.ServerOption{
+		kithttp.ServerBefore(reqctx.ContextWithClientInfo),
+		kithttp.ServerErrorEncoder(common.EncodeErrorFactory(errToHttpCode)),
+	}
+
+	createCommentHandler := kithttp.NewServer(
+		NewCreateCommentEndpoint(svc),
+		decodeCreateCommentRequest,
+		encodeResponse, opts...,
+	)
+	listCommentsHandler := kithttp.NewServer(
+		NewListCommentsEndpoint(svc),
+		decodeListCommentsRequest,
+		encodeResponse, opts...,
+	)
+	updateCommentHandler := kithttp.NewServer(
+		NewUpdateCommentEndpoint(svc),
+		decodeUpdateCommentRequest,
+		encodeResponse, opts...,
+	)
+	deleteCommentHandler := kithttp.NewServer(
+		NewDeleteCommentEndpoint(svc),
+		decodeDeleteCommentRequest,
+		encodeResponse, opts...,
+	)
+	addReactionHandler := kithttp.NewServer(
+		NewAddReactionEndpoint(svc),
+		decodeAddReactionRequest,
+		encodeResponse, opts...,
+	)
+	removeReactionHandler := kithttp.NewServer(
+		NewRemoveReactionEndpoint(svc),
+		decodeRemoveReactionRequest,
+		encodeResponse, opts...,
+	)
+
+	r.Handle("/api/v1/comments", createCommentHandler).Methods(http.MethodPost)
+	r.Handle("/api/v1/comments", listCommentsHandler).Methods(http.MethodGet)
+	r.Handle("/api/v1/comments/{id}", updateCommentHandler).Methods(http.MethodPut)
+	r.Handle("/api/v1/comments/{id}", deleteCommentHandler).Methods(http.MethodDelete)
+	r.Handle("/api/v1/comments/{id}/reactions", addReactionHandler).Methods(http.MethodPut)
+	r.Handle("/api/v1/comments/{id}/reactions", removeReactionHandler).Methods(http.MethodDelete)
+
+	return r
+}
+
+func decodeCreateCommentRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	req := CreateCommentRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, common.ErrInvalidJSONBody
+	}
+	return req, nil
+}
+
+func decodeListCommentsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	ff := MakeListCommentsFilterFromURLParams(r.URL.Query())
+	return ListCommentsRequest{ff}, nil
+}
+
+func decodeUpdateCommentRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	ID, ok := vars[URLPathVarID]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	req := UpdateCommentRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, common.ErrInvalidJSONBody
+	}
+	req.ID = ID
+	return req, nil
+}
+
+func decodeDeleteCommentRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	ID, ok := vars[URLPathVarID]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	return DeleteCommentRequest{ID}, nil
+}
+
+func decodeReactionRequest(r *http.Request) (ReactionRequest, error) {
+	vars := mux.Vars(r)
+	ID, ok := vars[URLPathVarID]
+	if !ok {
+		return ReactionRequest{}, common.ErrInvalidRequest
+	}
+	req := ReactionRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return ReactionRequest{}, common.ErrInvalidJSONBody
+	}
+	req.ID = ID
+	return req, nil
+}
+
+func decodeAddReactionRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	req, err := decodeReactionRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	return AddReactionRequest(req), nil
+}
+
+func decodeRemoveReactionRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	req, err := decodeReactionRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	return RemoveReactionRequest(req), nil
+}
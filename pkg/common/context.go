@@ -7,3 +7,6 @@ var ContextKeyTripInfo ContextKey = 2
 var ContextKeyFollowRequestInfo ContextKey = 3
 var ContextKeyTripInviteMetaInfo ContextKey = 4
 var ContextKeyTripInviteInfo ContextKey = 5
+var ContextKeyCommentInfo ContextKey = 6
+var ContextKeyChecklistTemplateInfo ContextKey = 7
+var ContextKeyTripTemplateInfo ContextKey = 8
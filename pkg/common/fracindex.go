@@ -0,0 +1,301 @@
+package common
+
+import (
+	"errors"
+	"strings"
+)
+
+// Fractional indexing, as implemented by the clients.
+// See: https://observablehq.com/@dgreensp/implementing-fractional-indexing
+
+const (
+	fracIndexDigits      = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	fracIndexIntegerZero = "a0"
+)
+
+var (
+	ErrInvalidFracIndex = errors.New("common.ErrInvalidFracIndex")
+
+	fracIndexSmallestInteger = "A" + strings.Repeat("0", 26)
+)
+
+// GenerateFracIndexBetween returns a key that sorts strictly between
+// a and b. An empty a or b means no lower or upper bound respectively.
+func GenerateFracIndexBetween(a, b string) (string, error) {
+	if a != "" {
+		if err := validateFracIndex(a); err != nil {
+			return "", err
+		}
+	}
+	if b != "" {
+		if err := validateFracIndex(b); err != nil {
+			return "", err
+		}
+	}
+	if a != "" && b != "" && a >= b {
+		return "", ErrInvalidFracIndex
+	}
+
+	if a == "" {
+		if b == "" {
+			return fracIndexIntegerZero, nil
+		}
+		ib, err := fracIndexIntegerPart(b)
+		if err != nil {
+			return "", err
+		}
+		fb := b[len(ib):]
+		if ib == fracIndexSmallestInteger {
+			mid, err := fracIndexMidpoint("", fb)
+			return ib + mid, err
+		}
+		if ib < b {
+			return ib, nil
+		}
+		res, ok := decrementFracIndexInteger(ib)
+		if !ok {
+			return "", ErrInvalidFracIndex
+		}
+		return res, nil
+	}
+
+	ia, err := fracIndexIntegerPart(a)
+	if err != nil {
+		return "", err
+	}
+	fa := a[len(ia):]
+
+	if b == "" {
+		if i, ok := incrementFracIndexInteger(ia); ok {
+			return i, nil
+		}
+		mid, err := fracIndexMidpoint(fa, "")
+		return ia + mid, err
+	}
+
+	ib, err := fracIndexIntegerPart(b)
+	if err != nil {
+		return "", err
+	}
+	fb := b[len(ib):]
+	if ia == ib {
+		mid, err := fracIndexMidpoint(fa, fb)
+		return ia + mid, err
+	}
+	i, ok := incrementFracIndexInteger(ia)
+	if !ok {
+		return "", ErrInvalidFracIndex
+	}
+	if i < b {
+		return i, nil
+	}
+	mid, err := fracIndexMidpoint(fa, "")
+	return ia + mid, err
+}
+
+// GenerateNFracIndexesBetween returns n sorted keys between a and b.
+func GenerateNFracIndexesBetween(a, b string, n int) ([]string, error) {
+	if n <= 0 {
+		return []string{}, nil
+	}
+	if n == 1 {
+		c, err := GenerateFracIndexBetween(a, b)
+		return []string{c}, err
+	}
+
+	if b == "" {
+		result := []string{}
+		c := a
+		for i := 0; i < n; i++ {
+			next, err := GenerateFracIndexBetween(c, b)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, next)
+			c = next
+		}
+		return result, nil
+	}
+
+	if a == "" {
+		result := make([]string, n)
+		c := b
+		for i := n - 1; i >= 0; i-- {
+			next, err := GenerateFracIndexBetween(a, c)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = next
+			c = next
+		}
+		return result, nil
+	}
+
+	mid := n / 2
+	c, err := GenerateFracIndexBetween(a, b)
+	if err != nil {
+		return nil, err
+	}
+	before, err := GenerateNFracIndexesBetween(a, c, mid)
+	if err != nil {
+		return nil, err
+	}
+	after, err := GenerateNFracIndexesBetween(c, b, n-mid-1)
+	if err != nil {
+		return nil, err
+	}
+	return append(append(before, c), after...), nil
+}
+
+// fracIndexMidpoint returns the fractional part between a and b,
+// where an empty b means no upper bound.
+func fracIndexMidpoint(a, b string) (string, error) {
+	zero := fracIndexDigits[0]
+	if b != "" && a >= b {
+		return "", ErrInvalidFracIndex
+	}
+	if (a != "" && a[len(a)-1] == zero) || (b != "" && b[len(b)-1] == zero) {
+		return "", ErrInvalidFracIndex
+	}
+
+	if b != "" {
+		n := 0
+		for {
+			ac := zero
+			if n < len(a) {
+				ac = a[n]
+			}
+			if n >= len(b) || ac != b[n] {
+				break
+			}
+			n++
+		}
+		if n > 0 {
+			rest := ""
+			if n < len(a) {
+				rest = a[n:]
+			}
+			mid, err := fracIndexMidpoint(rest, b[n:])
+			return b[:n] + mid, err
+		}
+	}
+
+	digitA := 0
+	if a != "" {
+		digitA = strings.IndexByte(fracIndexDigits, a[0])
+	}
+	digitB := len(fracIndexDigits)
+	if b != "" {
+		digitB = strings.IndexByte(fracIndexDigits, b[0])
+	}
+	if digitB-digitA > 1 {
+		midDigit := (digitA + digitB + 1) / 2
+		return string(fracIndexDigits[midDigit]), nil
+	}
+	if b != "" && len(b) > 1 {
+		return b[:1], nil
+	}
+	rest := ""
+	if a != "" {
+		rest = a[1:]
+	}
+	mid, err := fracIndexMidpoint(rest, "")
+	return string(fracIndexDigits[digitA]) + mid, err
+}
+
+func fracIndexIntegerLength(head byte) (int, error) {
+	if head >= 'a' && head <= 'z' {
+		return int(head-'a') + 2, nil
+	}
+	if head >= 'A' && head <= 'Z' {
+		return int('Z'-head) + 2, nil
+	}
+	return 0, ErrInvalidFracIndex
+}
+
+func fracIndexIntegerPart(key string) (string, error) {
+	l, err := fracIndexIntegerLength(key[0])
+	if err != nil {
+		return "", err
+	}
+	if l > len(key) {
+		return "", ErrInvalidFracIndex
+	}
+	return key[:l], nil
+}
+
+func validateFracIndex(key string) error {
+	if key == fracIndexSmallestInteger {
+		return ErrInvalidFracIndex
+	}
+	i, err := fracIndexIntegerPart(key)
+	if err != nil {
+		return err
+	}
+	f := key[len(i):]
+	if f != "" && f[len(f)-1] == fracIndexDigits[0] {
+		return ErrInvalidFracIndex
+	}
+	return nil
+}
+
+func incrementFracIndexInteger(x string) (string, bool) {
+	head, digs := x[0], []byte(x[1:])
+	carry := true
+	for i := len(digs) - 1; carry && i >= 0; i-- {
+		d := strings.IndexByte(fracIndexDigits, digs[i]) + 1
+		if d == len(fracIndexDigits) {
+			digs[i] = fracIndexDigits[0]
+		} else {
+			digs[i] = fracIndexDigits[d]
+			carry = false
+		}
+	}
+	if !carry {
+		return string(head) + string(digs), true
+	}
+	if head == 'Z' {
+		return "a" + string(fracIndexDigits[0]), true
+	}
+	if head == 'z' {
+		return "", false
+	}
+	h := head + 1
+	if h > 'a' {
+		digs = append(digs, fracIndexDigits[0])
+	} else {
+		digs = digs[:len(digs)-1]
+	}
+	return string(h) + string(digs), true
+}
+
+func decrementFracIndexInteger(x string) (string, bool) {
+	last := fracIndexDigits[len(fracIndexDigits)-1]
+	head, digs := x[0], []byte(x[1:])
+	borrow := true
+	for i := len(digs) - 1; borrow && i >= 0; i-- {
+		d := strings.IndexByte(fracIndexDigits, digs[i]) - 1
+		if d == -1 {
+			digs[i] = last
+		} else {
+			digs[i] = fracIndexDigits[d]
+			borrow = false
+		}
+	}
+	if !borrow {
+		return string(head) + string(digs), true
+	}
+	if head == 'a' {
+		return "Z" + string(last), true
+	}
+	if head == 'A' {
+		return "", false
+	}
+	h := head - 1
+	if h < 'Z' {
+		digs = append(digs, last)
+	} else {
+		digs = digs[:len(digs)-1]
+	}
+	return string(h) + string(digs), true
+}
@@ -0,0 +1,91 @@
+package finance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// https://github.com/fawazahmed0/currency-api
+	currencyAPIURL = "https://cdn.jsdelivr.net/gh/fawazahmed0/currency-api@1"
+)
+
+type currencyAPIProvider struct {
+	endpoint string
+	client   *http.Client
+}
+
+func NewDefaultCurrencyAPIProvider() FxProvider {
+	return NewCurrencyAPIProvider(currencyAPIURL)
+}
+
+func NewCurrencyAPIProvider(endpoint string) FxProvider {
+	return &currencyAPIProvider{endpoint, &http.Client{}}
+}
+
+func (prv currencyAPIProvider) Name() string {
+	return fxProviderCurrencyAPI
+}
+
+func (prv currencyAPIProvider) LatestRates(ctx context.Context, base string) (ExchangeRates, error) {
+	return prv.get(ctx, "latest", base)
+}
+
+func (prv currencyAPIProvider) HistoricalRates(ctx context.Context, base string, date time.Time) (ExchangeRates, error) {
+	return prv.get(ctx, date.Format(ExchangeRatesDateFormat), base)
+}
+
+/*
+	{
+	    "date": "2023-05-22",
+	    "sgd": {
+	        "eur": 0.68,
+	        "usd": 0.74
+	    }
+	}
+*/
+func (prv currencyAPIProvider) get(ctx context.Context, version, base string) (ExchangeRates, error) {
+	code := strings.ToLower(base)
+	request, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodGet,
+		fmt.Sprintf("%s/%s/currencies/%s.json", prv.endpoint, version, code),
+		nil,
+	)
+	if err != nil {
+		return ExchangeRates{}, err
+	}
+
+	resp, err := prv.client.Do(request)
+	if err != nil {
+		return ExchangeRates{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ExchangeRates{}, fxProviderStatusError{"currency-api", resp.StatusCode}
+	}
+
+	var body map[string]json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return ExchangeRates{}, err
+	}
+	var date string
+	var quotes map[string]float64
+	if err := json.Unmarshal(body["date"], &date); err != nil {
+		return ExchangeRates{}, err
+	}
+	if err := json.Unmarshal(body[code], &quotes); err != nil {
+		return ExchangeRates{}, err
+	}
+
+	rates := ExchangeRates{Date: date, Base: base, Rates: map[string]float64{}}
+	for currency, val := range quotes {
+		rates.Rates[strings.ToUpper(currency)] = val
+	}
+	rates.Rates[base] = 1
+	return rates, nil
+}
@@ -2,6 +2,7 @@ package finance
 
 import (
 	"context"
+	"time"
 
 	"github.com/go-kit/kit/endpoint"
 	"github.com/travelreys/travelreys/pkg/common"
@@ -9,6 +10,7 @@ import (
 
 type GetFxRatesRequest struct {
 	Base string
+	Date time.Time
 }
 
 type GetFxRatesResponse struct {
@@ -26,7 +28,38 @@ func NewGetFxRatesEndpoint(svc Service) endpoint.Endpoint {
 		if !ok {
 			return GetFxRatesResponse{Err: common.ErrEndpointReqMismatch}, nil
 		}
+		if !req.Date.IsZero() {
+			rates, err := svc.GetHistoricalFxRates(ctx, req.Base, req.Date)
+			return GetFxRatesResponse{Rates: rates, Err: err}, nil
+		}
 		rates, err := svc.GetFxRates(ctx, req.Base)
 		return GetFxRatesResponse{Rates: rates, Err: err}, nil
 	}
 }
+
+type ConvertRequest struct {
+	Amount float64
+	From   string
+	To     string
+	Date   time.Time
+}
+
+type ConvertResponse struct {
+	Amount float64 `json:"amount"`
+	Err    error   `json:"error,omitempty"`
+}
+
+func (r ConvertResponse) Error() error {
+	return r.Err
+}
+
+func NewConvertEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(ConvertRequest)
+		if !ok {
+			return ConvertResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		amount, err := svc.Convert(ctx, req.Amount, req.From, req.To, req.Date)
+		return ConvertResponse{Amount: amount, Err: err}, nil
+	}
+}
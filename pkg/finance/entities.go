@@ -23,22 +23,70 @@ import (
 	}
 */
 type ExchangeRateHostResponse struct {
+	Success   bool               `json:"success"`
 	Timestamp int                `json:"timestamp"`
 	Source    string             `json:"source"`
 	Quotes    map[string]float64 `json:"quotes"`
 }
 
+const (
+	ExchangeRatesDateFormat = "2006-01-02"
+)
+
 type ExchangeRates struct {
 	Date  string             `json:"date"`
 	Base  string             `json:"base"`
 	Rates map[string]float64 `json:"rates"`
+
+	// Stale is set when the rates are the last known rates, served
+	// because no provider is available.
+	Stale bool `json:"stale"`
+}
+
+// Convert converts an amount in the given currency into the base currency.
+func (r ExchangeRates) Convert(amount float64, currency string) (float64, bool) {
+	if currency == "" || currency == r.Base {
+		return amount, true
+	}
+	rate, ok := r.Rates[currency]
+	if !ok || rate == 0 {
+		return 0, false
+	}
+	return amount / rate, true
+}
+
+// ExchangeRatesByDate holds the rates of a base currency for several
+// dates, falling back to the latest rates for other dates.
+type ExchangeRatesByDate struct {
+	Base   string                   `json:"base"`
+	Latest ExchangeRates            `json:"latest"`
+	Dates  map[string]ExchangeRates `json:"dates"`
+}
+
+func NewExchangeRatesByDate(latest ExchangeRates) ExchangeRatesByDate {
+	return ExchangeRatesByDate{
+		Base:   latest.Base,
+		Latest: latest,
+		Dates:  map[string]ExchangeRates{},
+	}
+}
+
+// Convert converts an amount in the given currency into the base currency,
+// using the rates of the date.
+func (r ExchangeRatesByDate) Convert(amount float64, currency string, date time.Time) (float64, bool) {
+	if rates, ok := r.Dates[date.Format(ExchangeRatesDateFormat)]; ok && !date.IsZero() {
+		if converted, ok := rates.Convert(amount, currency); ok {
+			return converted, true
+		}
+	}
+	return r.Latest.Convert(amount, currency)
 }
 
 func NewExchangeRatesFromExchangeRateHostResponse(resp ExchangeRateHostResponse) ExchangeRates {
 	i, _ := strconv.ParseInt(fmt.Sprintf("%d", resp.Timestamp), 10, 64)
 	tm := time.Unix(i, 0)
 	exRate := ExchangeRates{
-		Date:  tm.Format("2006-01-02"),
+		Date:  tm.UTC().Format(ExchangeRatesDateFormat),
 		Base:  resp.Source,
 		Rates: map[string]float64{},
 	}
@@ -0,0 +1,79 @@
+package finance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+const (
+	// https://exchangerate.host/#/#docs
+	exchangeRateHostURL = "http://api.exchangerate.host"
+)
+
+type exchangeRateHostProvider struct {
+	endpoint string
+	apiKey   string
+	client   *http.Client
+}
+
+func NewDefaultExchangeRateHostProvider() FxProvider {
+	return NewExchangeRateHostProvider(exchangeRateHostURL, os.Getenv("TRAVELREYS_EXCHANGE_RATE_KEY"))
+}
+
+func NewExchangeRateHostProvider(endpoint, apiKey string) FxProvider {
+	return &exchangeRateHostProvider{endpoint, apiKey, &http.Client{}}
+}
+
+func (prv exchangeRateHostProvider) Name() string {
+	return fxProviderExchangeRateHost
+}
+
+func (prv exchangeRateHostProvider) LatestRates(ctx context.Context, base string) (ExchangeRates, error) {
+	return prv.get(ctx, "live", url.Values{"source": {base}})
+}
+
+func (prv exchangeRateHostProvider) HistoricalRates(ctx context.Context, base string, date time.Time) (ExchangeRates, error) {
+	dt := date.Format(ExchangeRatesDateFormat)
+	rates, err := prv.get(ctx, "historical", url.Values{"source": {base}, "date": {dt}})
+	if err != nil {
+		return ExchangeRates{}, err
+	}
+	rates.Date = dt
+	return rates, nil
+}
+
+func (prv exchangeRateHostProvider) get(ctx context.Context, path string, params url.Values) (ExchangeRates, error) {
+	params.Set("access_key", prv.apiKey)
+	request, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodGet,
+		fmt.Sprintf("%s/%s?%s", prv.endpoint, path, params.Encode()),
+		nil,
+	)
+	if err != nil {
+		return ExchangeRates{}, err
+	}
+
+	resp, err := prv.client.Do(request)
+	if err != nil {
+		return ExchangeRates{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ExchangeRates{}, fxProviderStatusError{"exchangerate.host", resp.StatusCode}
+	}
+
+	var exRateResp ExchangeRateHostResponse
+	if err := json.NewDecoder(resp.Body).Decode(&exRateResp); err != nil {
+		return ExchangeRates{}, err
+	}
+	if !exRateResp.Success || exRateResp.Source == "" {
+		return ExchangeRates{}, ErrRatesNotFound
+	}
+	return NewExchangeRatesFromExchangeRateHostResponse(exRateResp), nil
+}
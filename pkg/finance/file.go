@@ -0,0 +1,63 @@
+package finance
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// fileProvider serves rates from a static JSON file of ExchangeRates,
+// for tests and offline use. Rates for other bases are cross rates.
+type fileProvider struct {
+	rates ExchangeRates
+}
+
+func NewDefaultFileProvider() (FxProvider, error) {
+	return NewFileProvider(os.Getenv("TRAVELREYS_FX_RATES_FILE"))
+}
+
+func NewFileProvider(path string) (FxProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rates ExchangeRates
+	if err := json.Unmarshal(data, &rates); err != nil {
+		return nil, err
+	}
+	return NewStaticFxProvider(rates), nil
+}
+
+func NewStaticFxProvider(rates ExchangeRates) FxProvider {
+	if rates.Rates == nil {
+		rates.Rates = map[string]float64{}
+	}
+	rates.Rates[rates.Base] = 1
+	return &fileProvider{rates}
+}
+
+func (prv fileProvider) Name() string {
+	return fxProviderFile
+}
+
+func (prv fileProvider) LatestRates(ctx context.Context, base string) (ExchangeRates, error) {
+	baseRate, ok := prv.rates.Rates[base]
+	if !ok || baseRate == 0 {
+		return ExchangeRates{}, ErrRatesNotFound
+	}
+	rates := ExchangeRates{Date: prv.rates.Date, Base: base, Rates: map[string]float64{}}
+	for currency, rate := range prv.rates.Rates {
+		rates.Rates[currency] = rate / baseRate
+	}
+	return rates, nil
+}
+
+func (prv fileProvider) HistoricalRates(ctx context.Context, base string, date time.Time) (ExchangeRates, error) {
+	rates, err := prv.LatestRates(ctx, base)
+	if err != nil {
+		return ExchangeRates{}, err
+	}
+	rates.Date = date.Format(ExchangeRatesDateFormat)
+	return rates, nil
+}
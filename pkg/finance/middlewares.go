@@ -3,6 +3,7 @@ package finance
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/travelreys/travelreys/pkg/reqctx"
 	"go.uber.org/zap"
@@ -28,3 +29,35 @@ func (mw rbacMiddleware) GetFxRates(ctx context.Context, base string) (ExchangeR
 	}
 	return mw.next.GetFxRates(ctx, base)
 }
+
+func (mw rbacMiddleware) GetHistoricalFxRates(ctx context.Context, base string, date time.Time) (ExchangeRates, error) {
+	ci, err := reqctx.ClientInfoFromCtx(ctx)
+	if err != nil || ci.HasEmptyID() {
+		return ExchangeRates{}, ErrRBAC
+	}
+	return mw.next.GetHistoricalFxRates(ctx, base, date)
+}
+
+func (mw rbacMiddleware) GetFxRatesForDates(ctx context.Context, base string, dates []time.Time) (ExchangeRatesByDate, error) {
+	ci, err := reqctx.ClientInfoFromCtx(ctx)
+	if err != nil || ci.HasEmptyID() {
+		return ExchangeRatesByDate{}, ErrRBAC
+	}
+	return mw.next.GetFxRatesForDates(ctx, base, dates)
+}
+
+func (mw rbacMiddleware) GetCachedFxRatesForDates(ctx context.Context, base string, dates []time.Time) (ExchangeRatesByDate, error) {
+	ci, err := reqctx.ClientInfoFromCtx(ctx)
+	if err != nil || ci.HasEmptyID() {
+		return ExchangeRatesByDate{}, ErrRBAC
+	}
+	return mw.next.GetCachedFxRatesForDates(ctx, base, dates)
+}
+
+func (mw rbacMiddleware) Convert(ctx context.Context, amount float64, from, to string, date time.Time) (float64, error) {
+	ci, err := reqctx.ClientInfoFromCtx(ctx)
+	if err != nil || ci.HasEmptyID() {
+		return 0, ErrRBAC
+	}
+	return mw.next.Convert(ctx, amount, from, to, date)
+}
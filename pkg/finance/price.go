@@ -25,4 +25,31 @@ type Price struct {
 type PriceItem struct {
 	Price        `bson:"inline"`
 	SplitOptions PriceSplitOptions `json:"splitOptions" bson:"splitOptions"`
+
+	// PaidBy is the ID of the member who paid for the item
+	PaidBy string `json:"paidBy" bson:"paidBy"`
+
+	// Receipts are the IDs of the files justifying the item
+	Receipts []string `json:"receipts" bson:"receipts"`
+}
+
+// Shares returns the amount owed by each member for the item, in the
+// item's currency. Targets which are settled are skipped.
+func (item PriceItem) Shares() map[string]float64 {
+	shares := map[string]float64{}
+	switch item.SplitOptions.Method {
+	case PriceSplitMethodAbsolute:
+		for id, target := range item.SplitOptions.Targets {
+			if !target.Settled {
+				shares[id] += target.Value
+			}
+		}
+	case PriceSplitMethodPercentage:
+		for id, target := range item.SplitOptions.Targets {
+			if !target.Settled {
+				shares[id] += item.Amount * target.Value / 100
+			}
+		}
+	}
+	return shares
 }
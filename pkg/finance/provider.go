@@ -0,0 +1,199 @@
+package finance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	fxProviderExchangeRateHost = "exchangeratehost"
+	fxProviderCurrencyAPI      = "currencyapi"
+	fxProviderFile             = "file"
+
+	defaultFxProviderTimeout          = 5 * time.Second
+	defaultFxProviderFailureThreshold = 3
+	defaultFxProviderCooldown         = 5 * time.Minute
+)
+
+var (
+	defaultFxProviders = []string{fxProviderExchangeRateHost, fxProviderCurrencyAPI}
+
+	ErrFxProviderUnavailable = errors.New("finance.ErrFxProviderUnavailable")
+	ErrFxProviderCircuitOpen = errors.New("finance.ErrFxProviderCircuitOpen")
+)
+
+type FxProvider interface {
+	Name() string
+	LatestRates(ctx context.Context, base string) (ExchangeRates, error)
+	HistoricalRates(ctx context.Context, base string, date time.Time) (ExchangeRates, error)
+}
+
+// NewDefaultFxProvider returns a chain of the providers listed in
+// TRAVELREYS_FX_PROVIDERS, e.g "exchangeratehost,currencyapi,file".
+func NewDefaultFxProvider(logger *zap.Logger) (FxProvider, error) {
+	names := defaultFxProviders
+	if env := os.Getenv("TRAVELREYS_FX_PROVIDERS"); env != "" {
+		names = strings.Split(env, ",")
+	}
+
+	providers := []FxProvider{}
+	for _, name := range names {
+		switch strings.TrimSpace(name) {
+		case fxProviderExchangeRateHost:
+			providers = append(providers, NewDefaultExchangeRateHostProvider())
+		case fxProviderCurrencyAPI:
+			providers = append(providers, NewDefaultCurrencyAPIProvider())
+		case fxProviderFile:
+			prv, err := NewDefaultFileProvider()
+			if err != nil {
+				return nil, err
+			}
+			providers = append(providers, prv)
+		default:
+			return nil, fmt.Errorf("finance: unknown fx provider %q", name)
+		}
+	}
+	return NewFxProviderChain(providers, defaultFxProviderTimeout, logger), nil
+}
+
+// circuitBreaker stops calling a provider for a cooldown period
+// after consecutive failures.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+
+	threshold int
+	cooldown  time.Duration
+}
+
+func (cb *circuitBreaker) allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return time.Now().After(cb.openUntil)
+}
+
+func (cb *circuitBreaker) record(failed bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if !failed {
+		cb.failures = 0
+		return
+	}
+	cb.failures++
+	if cb.failures >= cb.threshold {
+		cb.openUntil = time.Now().Add(cb.cooldown)
+		cb.failures = 0
+	}
+}
+
+// fxProviderStatusError is returned by the providers
+// on unexpected HTTP responses.
+type fxProviderStatusError struct {
+	provider string
+	code     int
+}
+
+func (e fxProviderStatusError) Error() string {
+	return fmt.Sprintf("%s: status %d", e.provider, e.code)
+}
+
+// isFxProviderFailure tells if the error is caused by the provider being
+// unavailable rather than by the request, e.g an unsupported base currency.
+func isFxProviderFailure(err error) bool {
+	if err == nil || errors.Is(err, ErrRatesNotFound) {
+		return false
+	}
+	var statusErr fxProviderStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.code >= http.StatusInternalServerError ||
+			statusErr.code == http.StatusTooManyRequests
+	}
+	return true
+}
+
+type fxProviderChain struct {
+	providers []FxProvider
+	breakers  []*circuitBreaker
+	timeout   time.Duration
+	logger    *zap.Logger
+}
+
+// NewFxProviderChain returns a provider which tries each provider in
+// turn, with a timeout and a circuit breaker per provider.
+func NewFxProviderChain(providers []FxProvider, timeout time.Duration, logger *zap.Logger) FxProvider {
+	breakers := []*circuitBreaker{}
+	for range providers {
+		breakers = append(breakers, &circuitBreaker{
+			threshold: defaultFxProviderFailureThreshold,
+			cooldown:  defaultFxProviderCooldown,
+		})
+	}
+	return &fxProviderChain{providers, breakers, timeout, logger.Named("finance.fxProviderChain")}
+}
+
+func (chain *fxProviderChain) Name() string {
+	names := []string{}
+	for _, prv := range chain.providers {
+		names = append(names, prv.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+func (chain *fxProviderChain) LatestRates(ctx context.Context, base string) (ExchangeRates, error) {
+	return chain.try(ctx, func(ctx context.Context, prv FxProvider) (ExchangeRates, error) {
+		return prv.LatestRates(ctx, base)
+	})
+}
+
+func (chain *fxProviderChain) HistoricalRates(ctx context.Context, base string, date time.Time) (ExchangeRates, error) {
+	return chain.try(ctx, func(ctx context.Context, prv FxProvider) (ExchangeRates, error) {
+		return prv.HistoricalRates(ctx, base, date)
+	})
+}
+
+func (chain *fxProviderChain) try(
+	ctx context.Context,
+	fn func(context.Context, FxProvider) (ExchangeRates, error),
+) (ExchangeRates, error) {
+	notFound := false
+	for i, prv := range chain.providers {
+		cb := chain.breakers[i]
+		if !cb.allow() {
+			chain.logger.Debug("skipping", zap.String("provider", prv.Name()), zap.Error(ErrFxProviderCircuitOpen))
+			continue
+		}
+
+		prvCtx, cancel := context.WithTimeout(ctx, chain.timeout)
+		rates, err := fn(prvCtx, prv)
+		cancel()
+		if err == nil {
+			cb.record(false)
+			return rates, nil
+		}
+		if ctx.Err() != nil {
+			// The caller gave up, the provider is not at fault.
+			return ExchangeRates{}, ctx.Err()
+		}
+		if !isFxProviderFailure(err) {
+			cb.record(false)
+			notFound = true
+			chain.logger.Debug("rates not found", zap.String("provider", prv.Name()), zap.Error(err))
+			continue
+		}
+		cb.record(true)
+		chain.logger.Warn("provider failed", zap.String("provider", prv.Name()), zap.Error(err))
+	}
+	if notFound {
+		return ExchangeRates{}, ErrRatesNotFound
+	}
+	return ExchangeRates{}, ErrFxProviderUnavailable
+}
@@ -2,38 +2,38 @@ package finance
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"math/rand"
-	"net/http"
-	"os"
+	"errors"
 	"time"
 
 	"go.uber.org/zap"
 )
 
-// 1. https://cdn.jsdelivr.net/gh/fawazahmed0/currency-api@1/2023-05-22/currencies/sgd.json
-// 2. https://exchangerate.host/#/#docs
+const (
+	// missingFxRatesTTL is how long failed historical lookups
+	// are not retried.
+	missingFxRatesTTL = 15 * time.Minute
+)
 
 var (
-	FxApiEndpoints = []string{
-		"http://api.exchangerate.host",
-	}
-
-	FxApiKey = os.Getenv("TRAVELREYS_EXCHANGE_RATE_KEY")
+	ErrUnsupportedCurrency = errors.New("finance.ErrUnsupportedCurrency")
 )
 
 type Service interface {
 	GetFxRates(ctx context.Context, base string) (ExchangeRates, error)
+	GetHistoricalFxRates(ctx context.Context, base string, date time.Time) (ExchangeRates, error)
+	GetFxRatesForDates(ctx context.Context, base string, dates []time.Time) (ExchangeRatesByDate, error)
+	GetCachedFxRatesForDates(ctx context.Context, base string, dates []time.Time) (ExchangeRatesByDate, error)
+	Convert(ctx context.Context, amount float64, from, to string, date time.Time) (float64, error)
 }
 
 type service struct {
-	store  Store
-	logger *zap.Logger
+	store    Store
+	provider FxProvider
+	logger   *zap.Logger
 }
 
-func NewService(store Store, logger *zap.Logger) Service {
-	return &service{store, logger}
+func NewService(store Store, provider FxProvider, logger *zap.Logger) Service {
+	return &service{store, provider, logger}
 }
 
 func (svc service) GetFxRates(ctx context.Context, base string) (ExchangeRates, error) {
@@ -47,34 +47,128 @@ func (svc service) GetFxRates(ctx context.Context, base string) (ExchangeRates,
 		svc.logger.Error("GetFxRates", zap.Error(err))
 	}
 
-	client := http.Client{}
-	request, err := http.NewRequest(
-		"GET",
-		fmt.Sprintf("%s/live?access_key=%s&source=%s",
-			FxApiEndpoints[rand.Intn(len(FxApiEndpoints))],
-			FxApiKey,
-			base,
-		),
-		nil,
-	)
+	rates, err = svc.provider.LatestRates(ctx, base)
 	if err != nil {
 		svc.logger.Error("GetFxRates", zap.Error(err))
-		return ExchangeRates{}, err
+		lastKnown, lkErr := svc.store.ReadLastKnownFxRates(ctx, base)
+		if lkErr != nil {
+			return ExchangeRates{}, err
+		}
+		lastKnown.Stale = true
+		return lastKnown, nil
+	}
+	svc.store.SaveFxRates(ctx, rates, 60*time.Minute)
+	svc.store.SaveLastKnownFxRates(ctx, rates)
+	return rates, nil
+}
+
+// GetHistoricalFxRates returns the rates of a base currency at a past date,
+// backfilling the store from the provider. Rates for today and future
+// dates are the live rates.
+func (svc service) GetHistoricalFxRates(ctx context.Context, base string, date time.Time) (ExchangeRates, error) {
+	dt := date.Format(ExchangeRatesDateFormat)
+	if dt >= time.Now().UTC().Format(ExchangeRatesDateFormat) {
+		return svc.GetFxRates(ctx, base)
 	}
 
-	resp, err := client.Do(request)
+	rates, err := svc.store.ReadHistoricalFxRates(ctx, base, dt)
+	if err == nil {
+		return rates, nil
+	}
+	if err != ErrRatesNotFound {
+		svc.logger.Error("GetHistoricalFxRates", zap.Error(err))
+	}
+	if svc.store.IsMissingHistoricalFxRates(ctx, base, dt) {
+		return ExchangeRates{}, ErrRatesNotFound
+	}
+
+	rates, err = svc.provider.HistoricalRates(ctx, base, date)
 	if err != nil {
-		svc.logger.Error("GetFxRates", zap.Error(err))
+		svc.logger.Error("GetHistoricalFxRates", zap.Error(err))
+		svc.store.SaveMissingHistoricalFxRates(ctx, base, dt, missingFxRatesTTL)
 		return ExchangeRates{}, err
 	}
+	rates.Date = dt
+	svc.store.SaveHistoricalFxRates(ctx, rates)
+	return rates, nil
+}
+
+// GetFxRatesForDates returns the latest rates, together with the
+// historical rates of each date. Dates without rates fall back to
+// the latest rates.
+func (svc service) GetFxRatesForDates(ctx context.Context, base string, dates []time.Time) (ExchangeRatesByDate, error) {
+	latest, err := svc.GetFxRates(ctx, base)
+	if err != nil {
+		return ExchangeRatesByDate{}, err
+	}
 
-	var exRateResp ExchangeRateHostResponse
-	if err := json.NewDecoder(resp.Body).Decode(&exRateResp); err != nil {
-		svc.logger.Error("GetFxRates", zap.Error(err))
-		return ExchangeRates{}, err
+	result := NewExchangeRatesByDate(latest)
+	for _, date := range dates {
+		dt := date.Format(ExchangeRatesDateFormat)
+		if _, ok := result.Dates[dt]; ok || date.IsZero() {
+			continue
+		}
+		rates, err := svc.GetHistoricalFxRates(ctx, base, date)
+		if errors.Is(err, ErrFxProviderUnavailable) {
+			// The other dates would wait on the providers as well.
+			break
+		}
+		if err != nil {
+			continue
+		}
+		result.Dates[dt] = rates
 	}
+	return result, nil
+}
 
-	rates = NewExchangeRatesFromExchangeRateHostResponse(exRateResp)
-	svc.store.SaveFxRates(ctx, rates, 60*time.Minute)
-	return rates, nil
+// GetCachedFxRatesForDates is GetFxRatesForDates without calling the
+// providers, for callers which cannot wait on them. The latest rates
+// fall back to the last known rates.
+func (svc service) GetCachedFxRatesForDates(ctx context.Context, base string, dates []time.Time) (ExchangeRatesByDate, error) {
+	latest, err := svc.store.ReadLatestFxRates(ctx, base)
+	if err != nil {
+		latest, err = svc.store.ReadLastKnownFxRates(ctx, base)
+		if err != nil {
+			return ExchangeRatesByDate{}, err
+		}
+		latest.Stale = true
+	}
+
+	result := NewExchangeRatesByDate(latest)
+	for _, date := range dates {
+		dt := date.Format(ExchangeRatesDateFormat)
+		if _, ok := result.Dates[dt]; ok || date.IsZero() {
+			continue
+		}
+		rates, err := svc.store.ReadHistoricalFxRates(ctx, base, dt)
+		if err != nil {
+			continue
+		}
+		result.Dates[dt] = rates
+	}
+	return result, nil
+}
+
+// Convert converts an amount between currencies at the rates of the date.
+func (svc service) Convert(ctx context.Context, amount float64, from, to string, date time.Time) (float64, error) {
+	if from == to {
+		return amount, nil
+	}
+	var (
+		rates ExchangeRates
+		err   error
+	)
+	if date.IsZero() {
+		rates, err = svc.GetFxRates(ctx, to)
+	} else {
+		rates, err = svc.GetHistoricalFxRates(ctx, to, date)
+	}
+	if err != nil {
+		return 0, err
+	}
+	converted, ok := rates.Convert(amount, from)
+	if !ok {
+		return 0, ErrUnsupportedCurrency
+	}
+	return converted, nil
 }
@@ -12,7 +12,10 @@ import (
 )
 
 const (
-	ExchangeRatesPrefix = "finance:exchange-rates"
+	ExchangeRatesPrefix           = "finance:exchange-rates"
+	HistoricalExchangeRatesPrefix = "finance:exchange-rates:historical"
+	LastKnownExchangeRatesPrefix  = "finance:exchange-rates:last-known"
+	MissingExchangeRatesPrefix    = "finance:exchange-rates:missing"
 )
 
 var (
@@ -22,6 +25,12 @@ var (
 type Store interface {
 	ReadLatestFxRates(context.Context, string) (ExchangeRates, error)
 	SaveFxRates(context.Context, ExchangeRates, time.Duration) error
+	ReadHistoricalFxRates(ctx context.Context, base, date string) (ExchangeRates, error)
+	SaveHistoricalFxRates(context.Context, ExchangeRates) error
+	ReadLastKnownFxRates(context.Context, string) (ExchangeRates, error)
+	SaveLastKnownFxRates(context.Context, ExchangeRates) error
+	IsMissingHistoricalFxRates(ctx context.Context, base, date string) bool
+	SaveMissingHistoricalFxRates(ctx context.Context, base, date string, ttl time.Duration) error
 }
 
 type store struct {
@@ -57,3 +66,72 @@ func (s store) SaveFxRates(ctx context.Context, rates ExchangeRates, ttl time.Du
 	cmd := s.rdb.Set(ctx, key, string(data), ttl)
 	return cmd.Err()
 }
+
+func (s store) ReadHistoricalFxRates(ctx context.Context, base, date string) (ExchangeRates, error) {
+	key := fmt.Sprintf("%s:%s:%s", HistoricalExchangeRatesPrefix, base, date)
+	cmd := s.rdb.Get(ctx, key)
+	if errors.Is(cmd.Err(), redis.Nil) {
+		return ExchangeRates{}, ErrRatesNotFound
+	}
+
+	var rates ExchangeRates
+	if err := json.Unmarshal([]byte(cmd.Val()), &rates); err != nil {
+		s.rdb.Del(ctx, key)
+		return ExchangeRates{}, err
+	}
+	return rates, nil
+}
+
+// SaveHistoricalFxRates saves the rates of a past date, which never expire.
+func (s store) SaveHistoricalFxRates(ctx context.Context, rates ExchangeRates) error {
+	key := fmt.Sprintf("%s:%s:%s", HistoricalExchangeRatesPrefix, rates.Base, rates.Date)
+	data, err := json.Marshal(rates)
+	if err != nil {
+		return err
+	}
+	cmd := s.rdb.Set(ctx, key, string(data), 0)
+	return cmd.Err()
+}
+
+func (s store) ReadLastKnownFxRates(ctx context.Context, base string) (ExchangeRates, error) {
+	key := fmt.Sprintf("%s:%s", LastKnownExchangeRatesPrefix, base)
+	cmd := s.rdb.Get(ctx, key)
+	if errors.Is(cmd.Err(), redis.Nil) {
+		return ExchangeRates{}, ErrRatesNotFound
+	}
+
+	var rates ExchangeRates
+	if err := json.Unmarshal([]byte(cmd.Val()), &rates); err != nil {
+		s.rdb.Del(ctx, key)
+		return ExchangeRates{}, err
+	}
+	return rates, nil
+}
+
+// SaveLastKnownFxRates keeps the latest rates without expiry, to be
+// served when no provider is available.
+func (s store) SaveLastKnownFxRates(ctx context.Context, rates ExchangeRates) error {
+	key := fmt.Sprintf("%s:%s", LastKnownExchangeRatesPrefix, rates.Base)
+	data, err := json.Marshal(rates)
+	if err != nil {
+		return err
+	}
+	cmd := s.rdb.Set(ctx, key, string(data), 0)
+	return cmd.Err()
+}
+
+// IsMissingHistoricalFxRates returns true if the providers recently
+// failed to return the rates of the date.
+func (s store) IsMissingHistoricalFxRates(ctx context.Context, base, date string) bool {
+	key := fmt.Sprintf("%s:%s:%s", MissingExchangeRatesPrefix, base, date)
+	cmd := s.rdb.Exists(ctx, key)
+	return cmd.Err() == nil && cmd.Val() > 0
+}
+
+// SaveMissingHistoricalFxRates remembers for the ttl that the
+// providers failed to return the rates of the date.
+func (s store) SaveMissingHistoricalFxRates(ctx context.Context, base, date string, ttl time.Duration) error {
+	key := fmt.Sprintf("%s:%s:%s", MissingExchangeRatesPrefix, base, date)
+	cmd := s.rdb.Set(ctx, key, "1", ttl)
+	return cmd.Err()
+}
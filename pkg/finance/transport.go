@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	kithttp "github.com/go-kit/kit/transport/http"
 	"github.com/travelreys/travelreys/pkg/common"
@@ -14,8 +17,8 @@ import (
 )
 
 func errToHttpCode() func(err error) int {
-	notFoundErrors := []error{}
-	appErrors := []error{}
+	notFoundErrors := []error{ErrRatesNotFound}
+	appErrors := []error{ErrUnsupportedCurrency}
 
 	return func(err error) int {
 		if common.ErrorContains(notFoundErrors, err) {
@@ -24,6 +27,9 @@ func errToHttpCode() func(err error) int {
 		if common.ErrorContains(appErrors, err) {
 			return http.StatusUnprocessableEntity
 		}
+		if errors.Is(err, common.ErrInvalidRequest) {
+			return http.StatusBadRequest
+		}
 		if errors.Is(err, ErrRBAC) {
 			return http.StatusUnauthorized
 		}
@@ -49,11 +55,46 @@ func MakeHandler(svc Service) http.Handler {
 	}
 
 	getExchangeRateHandler := kithttp.NewServer(NewGetFxRatesEndpoint(svc), decodeGetExchangeRateRequest, encodeResponse, opts...)
+	convertHandler := kithttp.NewServer(NewConvertEndpoint(svc), decodeConvertRequest, encodeResponse, opts...)
 	r.Handle("/api/v1/finance/exchange-rates", getExchangeRateHandler).Methods(http.MethodGet)
+	r.Handle("/api/v1/finance/convert", convertHandler).Methods(http.MethodGet)
 	return r
 }
 
 func decodeGetExchangeRateRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	q := r.URL.Query().Get("base")
-	return GetFxRatesRequest{Base: q}, nil
+	date, err := decodeDate(r.URL.Query().Get("date"))
+	if err != nil {
+		return nil, err
+	}
+	return GetFxRatesRequest{Base: q, Date: date}, nil
+}
+
+func decodeConvertRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+	amount, err := strconv.ParseFloat(q.Get("amount"), 64)
+	if err != nil || q.Get("from") == "" || q.Get("to") == "" {
+		return nil, common.ErrInvalidRequest
+	}
+	date, err := decodeDate(q.Get("date"))
+	if err != nil {
+		return nil, err
+	}
+	return ConvertRequest{
+		Amount: amount,
+		From:   strings.ToUpper(q.Get("from")),
+		To:     strings.ToUpper(q.Get("to")),
+		Date:   date,
+	}, nil
+}
+
+func decodeDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	date, err := time.Parse(ExchangeRatesDateFormat, value)
+	if err != nil {
+		return time.Time{}, common.ErrInvalidRequest
+	}
+	return date, nil
 }
@@ -234,7 +234,7 @@ func (mw *rbacMiddleware) SendTripInvite(
 	if err != nil {
 		return err
 	}
-	if !common.StringContains(t.GetMemberIDs(), authorID) {
+	if !t.HasPermission(authorID, trips.PermissionManageMembers) {
 		return ErrRBAC
 	}
 
@@ -331,7 +331,7 @@ func (mw *rbacMiddleware) SendEmailTripInvite(
 	if err != nil {
 		return err
 	}
-	if !common.StringContains(t.GetMemberIDs(), authorID) {
+	if !t.HasPermission(authorID, trips.PermissionManageMembers) {
 		return ErrRBAC
 	}
 
@@ -10,6 +10,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/travelreys/travelreys/pkg/auth"
 	"github.com/travelreys/travelreys/pkg/email"
+	"github.com/travelreys/travelreys/pkg/notifications"
 	"github.com/travelreys/travelreys/pkg/trips"
 	"go.uber.org/zap"
 )
@@ -48,21 +49,23 @@ type Service interface {
 }
 
 type service struct {
-	authSvc auth.Service
-	syncSvc trips.SyncService
-	mailSvc email.Service
-	store   Store
-	logger  *zap.Logger
+	authSvc  auth.Service
+	syncSvc  trips.SyncService
+	mailSvc  email.Service
+	notifSvc notifications.Service
+	store    Store
+	logger   *zap.Logger
 }
 
 func NewService(
 	authSvc auth.Service,
 	syncSvc trips.SyncService,
 	mailSvc email.Service,
+	notifSvc notifications.Service,
 	store Store,
 	logger *zap.Logger,
 ) Service {
-	return &service{authSvc, syncSvc, mailSvc, store, logger}
+	return &service{authSvc, syncSvc, mailSvc, notifSvc, store, logger}
 }
 
 // App Invites
@@ -198,6 +201,16 @@ func (svc *service) SendTripInvite(
 
 	err = svc.store.SaveTripInvite(ctx, invite)
 	if err == nil {
+		n := notifications.NewTripNotification(
+			userID,
+			notifications.TypeTripInviteReceived,
+			authorID,
+			inviteMeta.Trip,
+		)
+		n.Labels[notifications.LabelInviteID] = invite.ID
+		if err := svc.notifSvc.Notify(ctx, notifications.NotificationsList{n}); err != nil {
+			svc.logger.Error("SendTripInvite", zap.Error(err))
+		}
 		go func() {
 			svc.sendTripInviteEmail(
 				context.Background(),
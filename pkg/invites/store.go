@@ -36,6 +36,9 @@ type Store interface {
 	ReadEmailTripInvite(ctx context.Context, ID string) (EmailTripInvite, error)
 	SaveEmailTripInvite(ctx context.Context, invite EmailTripInvite) error
 	DeleteEmailTripInvite(ctx context.Context, ID string) error
+
+	// DeleteByTripID deletes the trip and email invites to the trip.
+	DeleteByTripID(ctx context.Context, tripID string) error
 }
 
 type store struct {
@@ -230,3 +233,16 @@ func (s *store) DeleteEmailTripInvite(ctx context.Context, ID string) error {
 	}
 	return err
 }
+
+func (s *store) DeleteByTripID(ctx context.Context, tripID string) error {
+	ff := bson.M{"tripID": tripID}
+	if _, err := s.tripInviteColl.DeleteMany(ctx, ff); err != nil {
+		s.logger.Error("DeleteByTripID", zap.String("tripID", tripID), zap.Error(err))
+		return ErrUnexpectedStoreError
+	}
+	if _, err := s.emailTripInviteColl.DeleteMany(ctx, ff); err != nil {
+		s.logger.Error("DeleteByTripID", zap.String("tripID", tripID), zap.Error(err))
+		return ErrUnexpectedStoreError
+	}
+	return nil
+}
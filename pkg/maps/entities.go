@@ -3,6 +3,7 @@ package maps
 import (
 	"errors"
 	"math"
+	"strconv"
 	"strings"
 	"time"
 
@@ -28,11 +29,17 @@ const (
 
 const (
 	maxNumMapsPhotos = 7
+
+	earthRadiusMeters = 6371000
+
+	minutesPerDay  = 24 * 60
+	minutesPerWeek = 7 * minutesPerDay
 )
 
 var (
-	ErrRouteListEmpty     = errors.New("maps.ErrRouteListEmpty")
-	DirectionModesAllList = []string{
+	ErrRouteListEmpty          = errors.New("maps.ErrRouteListEmpty")
+	ErrInvalidOpeningHoursTime = errors.New("maps.ErrInvalidOpeningHoursTime")
+	DirectionModesAllList      = []string{
 		DirectionModeDriving,
 		DirectionModeTransit,
 		DirectionModeWalking,
@@ -44,6 +51,17 @@ type LatLng struct {
 	Lng float64 `json:"lng" bson:"lng"`
 }
 
+// HaversineDistance returns the great-circle distance in meters
+// between two points.
+func HaversineDistance(a, b LatLng) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(b.Lat - a.Lat)
+	dLng := toRad(b.Lng - a.Lng)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(a.Lat))*math.Cos(toRad(b.Lat))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(h))
+}
+
 type Place struct {
 	ID          string        `json:"id" bson:"id"`
 	Name        string        `json:"name" bson:"name"`
@@ -53,6 +71,8 @@ type Place struct {
 	Types       []string      `json:"types" bson:"types"`
 	Website     string        `json:"website" bson:"website"`
 	Labels      common.Labels `json:"labels" bson:"labels"`
+
+	OpeningHours OpeningHours `json:"openingHours" bson:"openingHours"`
 }
 
 func PlaceFromPlaceDetailsResult(result maps.PlaceDetailsResult) Place {
@@ -67,6 +87,7 @@ func PlaceFromPlaceDetailsResult(result maps.PlaceDetailsResult) Place {
 		Labels: common.Labels{
 			LabelPlaceID: result.PlaceID,
 		},
+		OpeningHours: OpeningHoursFromResult(result.OpeningHours, result.UTCOffset),
 	}
 	if len(result.Photos) > 0 {
 		minPhotos := math.Min(maxNumMapsPhotos, float64(len(result.Photos)))
@@ -97,6 +118,143 @@ func (p Place) PlaceID() string {
 	return p.Labels[LabelPlaceID]
 }
 
+// OpeningHoursTime is a day of week and a time of day in
+// 24-hour hhmm format, in the place's time zone.
+type OpeningHoursTime struct {
+	Day  time.Weekday `json:"day" bson:"day"`
+	Time string       `json:"time" bson:"time"`
+}
+
+func (t OpeningHoursTime) minutesOfWeek() (int, error) {
+	if len(t.Time) != 4 {
+		return 0, ErrInvalidOpeningHoursTime
+	}
+	hh, err := strconv.Atoi(t.Time[:2])
+	if err != nil {
+		return 0, err
+	}
+	mm, err := strconv.Atoi(t.Time[2:])
+	if err != nil {
+		return 0, err
+	}
+	return int(t.Day)*minutesPerDay + hh*60 + mm, nil
+}
+
+type OpeningHoursPeriod struct {
+	Open  OpeningHoursTime `json:"open" bson:"open"`
+	Close OpeningHoursTime `json:"close" bson:"close"`
+}
+
+type OpeningHours struct {
+	Periods []OpeningHoursPeriod `json:"periods" bson:"periods"`
+
+	// UTCOffset is the offset of the place's time zone from UTC,
+	// in minutes, if known.
+	UTCOffset *int `json:"utcOffset,omitempty" bson:"utcOffset,omitempty"`
+}
+
+func OpeningHoursFromResult(result *maps.OpeningHours, utcOffset *int) OpeningHours {
+	oh := OpeningHours{Periods: []OpeningHoursPeriod{}, UTCOffset: utcOffset}
+	if result == nil {
+		return oh
+	}
+	for _, p := range result.Periods {
+		oh.Periods = append(oh.Periods, OpeningHoursPeriod{
+			Open:  OpeningHoursTime{p.Open.Day, p.Open.Time},
+			Close: OpeningHoursTime{p.Close.Day, p.Close.Time},
+		})
+	}
+	return oh
+}
+
+func (oh OpeningHours) IsKnown() bool {
+	return len(oh.Periods) > 0
+}
+
+// IsOpenBetween reports whether the place is open for the whole of
+// [start, end], in the place's time zone if known or else in start's.
+// Places without opening hours are assumed to be open.
+func (oh OpeningHours) IsOpenBetween(start, end time.Time) bool {
+	if !oh.IsKnown() {
+		return true
+	}
+	loc := oh.Location(start.Location())
+	start, end = start.In(loc), end.In(loc)
+
+	startMins := int(start.Weekday())*minutesPerDay + start.Hour()*60 + start.Minute()
+	durMins := int(end.Sub(start).Minutes())
+	if durMins < 0 {
+		durMins = 0
+	}
+
+	for _, p := range oh.Periods {
+		// Open 24 hours is represented by an open period without close
+		if p.Close.Time == "" {
+			return true
+		}
+		openMins, err := p.Open.minutesOfWeek()
+		if err != nil {
+			continue
+		}
+		closeMins, err := p.Close.minutesOfWeek()
+		if err != nil {
+			continue
+		}
+		// Periods wrapping around the end of the week, e.g Sat 2200 - Sun 0200
+		if closeMins <= openMins {
+			closeMins += minutesPerWeek
+		}
+		for _, shift := range []int{0, minutesPerWeek} {
+			s := startMins + shift
+			if openMins <= s && s+durMins <= closeMins {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsOpenOn reports whether the place opens at some point of the
+// date's day. Places without opening hours are assumed to be open.
+func (oh OpeningHours) IsOpenOn(date time.Time) bool {
+	if !oh.IsKnown() {
+		return true
+	}
+
+	dayMins := int(date.Weekday()) * minutesPerDay
+	for _, p := range oh.Periods {
+		if p.Close.Time == "" {
+			return true
+		}
+		openMins, err := p.Open.minutesOfWeek()
+		if err != nil {
+			continue
+		}
+		closeMins, err := p.Close.minutesOfWeek()
+		if err != nil {
+			continue
+		}
+		if closeMins <= openMins {
+			closeMins += minutesPerWeek
+		}
+		for _, shift := range []int{0, minutesPerWeek} {
+			s := dayMins + shift
+			if openMins < s+minutesPerDay && s < closeMins {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Location returns the place's time zone if known, or else def.
+func (oh OpeningHours) Location(def *time.Location) *time.Location {
+	if oh.UTCOffset == nil {
+		return def
+	}
+	return time.FixedZone("", *oh.UTCOffset*60)
+}
+
 type PlaceAtmosphere struct {
 	maps.PlaceDetailsResult
 }
@@ -113,6 +271,19 @@ type Polyline struct {
 	Points string `json:"points" bson:"points"`
 }
 
+// Decode returns the list of lat,lng points encoded in the polyline.
+func (p Polyline) Decode() ([]LatLng, error) {
+	gLatLngs, err := maps.DecodePolyline(p.Points)
+	if err != nil {
+		return nil, err
+	}
+	latlngs := []LatLng{}
+	for _, ll := range gLatLngs {
+		latlngs = append(latlngs, LatLng(ll))
+	}
+	return latlngs, nil
+}
+
 type Route struct {
 	Polyline      Polyline      `json:"polyline" bson:"polyline"`
 	Distance      int           `json:"distance" bson:"distance"`
@@ -143,6 +314,11 @@ func RouteFromRouteResult(result maps.Route, mode string) Route {
 	}
 }
 
+// DurationMatrix holds the travel durations between places, where
+// m[i][j] is the duration from the i-th to the j-th place. Unreachable
+// pairs have a negative duration.
+type DurationMatrix [][]time.Duration
+
 type RouteList []Route
 type RouteListMap map[string]RouteList
 
@@ -60,3 +60,11 @@ func (mw rbacMiddleware) OptimizeRoute(ctx context.Context, originPlaceID, destP
 	}
 	return mw.next.OptimizeRoute(ctx, originPlaceID, destPlaceID, waypointsPlaceID)
 }
+
+func (mw rbacMiddleware) DistanceMatrix(ctx context.Context, placeIDs []string, mode string) (DurationMatrix, error) {
+	ci, err := reqctx.ClientInfoFromCtx(ctx)
+	if err != nil || ci.HasEmptyID() {
+		return DurationMatrix{}, ErrRBAC
+	}
+	return mw.next.DistanceMatrix(ctx, placeIDs, mode)
+}
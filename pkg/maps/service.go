@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/travelreys/travelreys/pkg/common"
 	"go.uber.org/zap"
 	"googlemaps.github.io/maps"
 )
@@ -15,6 +17,9 @@ import (
 const (
 	mapSerivceLogger      = "maps.service"
 	envGoogleMapsApiToken = "TRAVELREYS_GOOGLE_MAPS_APIKEY"
+
+	// Distance Matrix requests are limited to 100 elements
+	distanceMatrixChunkSize = 10
 )
 
 var (
@@ -28,6 +33,7 @@ type Service interface {
 	PlaceAtmosphere(ctx context.Context, placeID string, fields []string, sessiontoken, lang string) (PlaceAtmosphere, error)
 	Directions(ctx context.Context, originPlaceID, destPlaceID string, modes []string) (RouteList, error)
 	OptimizeRoute(ctx context.Context, originPlaceID, destPlaceID string, waypointsPlaceID []string) (RouteList, []int, error)
+	DistanceMatrix(ctx context.Context, placeIDs []string, mode string) (DurationMatrix, error)
 }
 
 type service struct {
@@ -120,6 +126,18 @@ func (svc *service) placeDetails(ctx context.Context, placeID string, fields []s
 }
 
 func (svc *service) PlaceDetails(ctx context.Context, placeID string, fields []string, sessiontoken, lang string) (Place, error) {
+	// The opening hours, in the place's time zone, are needed for the
+	// itineraries' schedule warnings. No fields already returns all of them.
+	if len(fields) > 0 {
+		for _, field := range []maps.PlaceDetailsFieldMask{
+			maps.PlaceDetailsFieldMaskOpeningHours,
+			maps.PlaceDetailsFieldMaskUTCOffset,
+		} {
+			if !common.StringContains(fields, string(field)) {
+				fields = append(fields, string(field))
+			}
+		}
+	}
 	result, err := svc.placeDetails(ctx, placeID, fields, sessiontoken, lang)
 	if err != nil {
 		return Place{}, err
@@ -203,3 +221,52 @@ func (svc *service) OptimizeRoute(ctx context.Context, originPlaceID, destPlaceI
 	}
 	return routes, groutes[0].WaypointOrder, err
 }
+
+func (svc *service) DistanceMatrix(ctx context.Context, placeIDs []string, mode string) (DurationMatrix, error) {
+	matrix := DurationMatrix{}
+	for range placeIDs {
+		matrix = append(matrix, make([]time.Duration, len(placeIDs)))
+	}
+
+	places := []string{}
+	for _, id := range placeIDs {
+		places = append(places, fmt.Sprintf("place_id:%s", id))
+	}
+
+	for oStart := 0; oStart < len(places); oStart += distanceMatrixChunkSize {
+		oEnd := oStart + distanceMatrixChunkSize
+		if oEnd > len(places) {
+			oEnd = len(places)
+		}
+		for dStart := 0; dStart < len(places); dStart += distanceMatrixChunkSize {
+			dEnd := dStart + distanceMatrixChunkSize
+			if dEnd > len(places) {
+				dEnd = len(places)
+			}
+			req := &maps.DistanceMatrixRequest{
+				Origins:      places[oStart:oEnd],
+				Destinations: places[dStart:dEnd],
+				Mode:         maps.Mode(mode),
+			}
+			resp, err := svc.c.DistanceMatrix(ctx, req)
+			if err != nil {
+				svc.logger.Error("DistanceMatrix",
+					zap.String("placeIDs", strings.Join(placeIDs, ",")),
+					zap.String("mode", mode),
+					zap.Error(err),
+				)
+				return nil, err
+			}
+			for i, row := range resp.Rows {
+				for j, el := range row.Elements {
+					if el == nil || el.Status != "OK" {
+						matrix[oStart+i][dStart+j] = -1
+						continue
+					}
+					matrix[oStart+i][dStart+j] = el.Duration
+				}
+			}
+		}
+	}
+	return matrix, nil
+}
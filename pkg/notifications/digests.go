@@ -0,0 +1,147 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/travelreys/travelreys/pkg/auth"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultDigestsInterval = time.Hour
+
+	digestPeriod = 24 * time.Hour
+
+	// maxDigestItems caps the notifications listed in a digest,
+	// the others are left for the next one.
+	maxDigestItems = 50
+
+	defaultDigestSender = "notifications@travelreys.com"
+
+	digestTmplFilePath = "assets/notificationsDigestEmail.tmpl.html"
+	digestTmplFileName = "notificationsDigestEmail.tmpl.html"
+)
+
+type digestItem struct {
+	TripID  string
+	Summary string
+}
+
+// RunDigests sends the daily digests due every interval
+// until the context is done.
+func RunDigests(ctx context.Context, svc Service, interval time.Duration, logger *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := svc.SendDigests(ctx, time.Now()); err != nil {
+			logger.Error("SendDigests", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendDigests emails the users with a daily digest their unread
+// notifications, at most once a day. Each user is claimed before
+// sending, so that only one replica sends their digest; a failed
+// digest is not retried and its notifications go in the next one.
+func (svc *service) SendDigests(ctx context.Context, now time.Time) error {
+	before := now.Add(-digestPeriod)
+	prefsList, err := svc.store.ListDigestPreferences(ctx, before)
+	if err != nil {
+		return err
+	}
+	for _, prefs := range prefsList {
+		claimed, err := svc.store.ClaimDigest(ctx, prefs.UserID, before, now)
+		if err != nil || !claimed {
+			continue
+		}
+		if err := svc.sendDigest(ctx, prefs); err != nil {
+			svc.logger.Error("SendDigests", zap.String("userID", prefs.UserID), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+func (svc *service) sendDigest(ctx context.Context, prefs Preferences) error {
+	list, err := svc.store.List(ctx, ListFilter{
+		UserID:         prefs.UserID,
+		OnlyUnread:     true,
+		OnlyUndigested: true,
+		Limit:          maxDigestItems,
+	})
+	if err != nil || len(list) == 0 {
+		return err
+	}
+
+	users, err := svc.authSvc.List(ctx, auth.ListFilter{
+		IDs: append(list.GetActorIDs(), prefs.UserID),
+	})
+	if err != nil {
+		return err
+	}
+	var user auth.User
+	names := map[string]string{}
+	for _, usr := range users {
+		names[usr.ID] = usr.Name
+		if usr.ID == prefs.UserID {
+			user = usr
+		}
+	}
+	if user.Email == "" {
+		return nil
+	}
+
+	items := []digestItem{}
+	IDs := []string{}
+	for _, n := range list {
+		items = append(items, digestItem{n.TripID, n.Summary(names[n.ActorID])})
+		IDs = append(IDs, n.ID)
+	}
+	if err := svc.sendDigestEmail(ctx, user, items); err != nil {
+		return err
+	}
+	return svc.store.MarkDigested(ctx, prefs.UserID, IDs)
+}
+
+func (svc *service) sendDigestEmail(ctx context.Context, user auth.User, items []digestItem) error {
+	svc.logger.Info("sending notifications digest email", zap.String("to", user.Email))
+	t, err := template.
+		New(digestTmplFileName).
+		ParseFiles(digestTmplFilePath)
+	if err != nil {
+		return err
+	}
+
+	var doc bytes.Buffer
+	data := struct {
+		Items []digestItem
+	}{items}
+	if err := t.Execute(&doc, data); err != nil {
+		return err
+	}
+
+	mailBody, err := svc.mailSvc.InsertContentOnTemplate(doc.String())
+	if err != nil {
+		return err
+	}
+
+	subj := fmt.Sprintf("You have %d new notifications", len(items))
+	if len(items) == 1 {
+		subj = "You have a new notification"
+	}
+	return svc.mailSvc.SendMail(
+		ctx,
+		user.Email,
+		defaultDigestSender,
+		subj,
+		mailBody,
+	)
+}
@@ -0,0 +1,146 @@
+package notifications
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/travelreys/travelreys/pkg/common"
+)
+
+type ListRequest struct {
+	Filter ListFilter `json:"filter"`
+}
+
+type ListResponse struct {
+	Notifications NotificationsList `json:"notifications"`
+	NextCursor    string            `json:"nextCursor,omitempty"`
+	Err           error             `json:"error,omitempty"`
+}
+
+func (r ListResponse) Error() error {
+	return r.Err
+}
+
+func NewListEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(ListRequest)
+		if !ok {
+			return ListResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		list, next, err := svc.List(ctx, req.Filter)
+		return ListResponse{Notifications: list, NextCursor: next, Err: err}, nil
+	}
+}
+
+type CountUnreadRequest struct {
+	UserID string `json:"userID"`
+}
+
+type CountUnreadResponse struct {
+	Count int64 `json:"count"`
+	Err   error `json:"error,omitempty"`
+}
+
+func (r CountUnreadResponse) Error() error {
+	return r.Err
+}
+
+func NewCountUnreadEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(CountUnreadRequest)
+		if !ok {
+			return CountUnreadResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		count, err := svc.CountUnread(ctx, req.UserID)
+		return CountUnreadResponse{Count: count, Err: err}, nil
+	}
+}
+
+type MarkReadRequest struct {
+	UserID string   `json:"userID"`
+	IDs    []string `json:"ids"`
+}
+
+type MarkReadResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r MarkReadResponse) Error() error {
+	return r.Err
+}
+
+func NewMarkReadEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(MarkReadRequest)
+		if !ok {
+			return MarkReadResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		err := svc.MarkRead(ctx, req.UserID, req.IDs)
+		return MarkReadResponse{Err: err}, nil
+	}
+}
+
+type MarkAllReadRequest struct {
+	UserID string `json:"userID"`
+}
+
+type MarkAllReadResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r MarkAllReadResponse) Error() error {
+	return r.Err
+}
+
+func NewMarkAllReadEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(MarkAllReadRequest)
+		if !ok {
+			return MarkAllReadResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		err := svc.MarkAllRead(ctx, req.UserID)
+		return MarkAllReadResponse{Err: err}, nil
+	}
+}
+
+type ReadPreferencesRequest struct {
+	UserID string `json:"userID"`
+}
+
+type ReadPreferencesResponse struct {
+	Preferences Preferences `json:"preferences"`
+	Err         error       `json:"error,omitempty"`
+}
+
+func (r ReadPreferencesResponse) Error() error {
+	return r.Err
+}
+
+func NewReadPreferencesEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(ReadPreferencesRequest)
+		if !ok {
+			return ReadPreferencesResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		prefs, err := svc.ReadPreferences(ctx, req.UserID)
+		return ReadPreferencesResponse{Preferences: prefs, Err: err}, nil
+	}
+}
+
+type UpdatePreferencesRequest struct {
+	UserID      string `json:"userID"`
+	DailyDigest bool   `json:"dailyDigest"`
+}
+
+type UpdatePreferencesResponse = ReadPreferencesResponse
+
+func NewUpdatePreferencesEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(UpdatePreferencesRequest)
+		if !ok {
+			return UpdatePreferencesResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		prefs, err := svc.UpdatePreferences(ctx, req.UserID, req.DailyDigest)
+		return UpdatePreferencesResponse{Preferences: prefs, Err: err}, nil
+	}
+}
@@ -0,0 +1,108 @@
+package notifications
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+)
+
+var (
+	ErrInvalidFilter = errors.New("notifications.ErrInvalidFilter")
+)
+
+type ListFilter struct {
+	UserID string
+
+	OnlyUnread bool
+
+	// OnlyUndigested keeps the notifications not sent in a digest yet.
+	OnlyUndigested bool
+
+	// Limit pages the notifications, latest first. The next pages
+	// start at the cursor returned with the previous one.
+	Limit  int64
+	Cursor string
+}
+
+// listCursor is the position of the last notification of a page,
+// ties are broken by the notification's ID.
+type listCursor struct {
+	CreatedAt time.Time `json:"v"`
+	ID        string    `json:"id"`
+}
+
+func decodeListCursor(cursor string) (listCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return listCursor{}, ErrInvalidFilter
+	}
+	var c listCursor
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == "" {
+		return listCursor{}, ErrInvalidFilter
+	}
+	return c, nil
+}
+
+func (c listCursor) encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func (f ListFilter) Validate() error {
+	if f.UserID == "" {
+		return ErrInvalidFilter
+	}
+	if f.Limit < 0 || f.Limit > MaxListLimit {
+		return ErrInvalidFilter
+	}
+	if f.Cursor != "" {
+		if _, err := decodeListCursor(f.Cursor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NextCursor returns the cursor of the page after the notifications,
+// or an empty string if they are the last page.
+func (f ListFilter) NextCursor(list NotificationsList) string {
+	if f.Limit == 0 || int64(len(list)) < f.Limit {
+		return ""
+	}
+	last := list[len(list)-1]
+	return listCursor{CreatedAt: last.CreatedAt, ID: last.ID}.encode()
+}
+
+func (f ListFilter) toBSON() bson.M {
+	bsonAnd := bson.A{bson.M{bsonKeyUserID: f.UserID}}
+	if f.OnlyUnread {
+		bsonAnd = append(bsonAnd, bson.M{bsonKeyRead: false})
+	}
+	if f.OnlyUndigested {
+		bsonAnd = append(bsonAnd, bson.M{bsonKeyDigested: false})
+	}
+	if f.Cursor != "" {
+		if c, err := decodeListCursor(f.Cursor); err == nil {
+			bsonAnd = append(bsonAnd, bson.M{"$or": bson.A{
+				bson.M{bsonKeyCreatedAt: bson.M{"$lt": c.CreatedAt}},
+				bson.M{bsonKeyCreatedAt: c.CreatedAt, bsonKeyID: bson.M{"$lt": c.ID}},
+			}})
+		}
+	}
+	return bson.M{"$and": bsonAnd}
+}
+
+func (f ListFilter) toSortBSON() bson.D {
+	return bson.D{
+		{Key: bsonKeyCreatedAt, Value: -1},
+		{Key: bsonKeyID, Value: -1},
+	}
+}
@@ -0,0 +1,186 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/travelreys/travelreys/pkg/common"
+	"github.com/travelreys/travelreys/pkg/reqctx"
+	"github.com/travelreys/travelreys/pkg/trips"
+	"go.uber.org/zap"
+)
+
+const (
+	maxMarkReadIDs = 100
+)
+
+var (
+	ErrRBAC = errors.New("notifications.ErrRBAC")
+)
+
+type validationMiddleware struct {
+	next   Service
+	logger *zap.Logger
+}
+
+func SvcWithValidationMw(svc Service, logger *zap.Logger) Service {
+	return &validationMiddleware{svc, logger.Named("notifications.validationMiddleware")}
+}
+
+func (mw *validationMiddleware) Notify(ctx context.Context, list NotificationsList) error {
+	for _, n := range list {
+		if n.ID == "" || n.UserID == "" || n.Type == "" {
+			mw.logger.Warn("Notify")
+			return common.ErrValidation
+		}
+	}
+	return mw.next.Notify(ctx, list)
+}
+
+func (mw *validationMiddleware) NotifyTripUpdate(ctx context.Context, msg trips.SyncMsgTOB) error {
+	if msg.TripID == "" {
+		mw.logger.Warn("NotifyTripUpdate")
+		return common.ErrValidation
+	}
+	return mw.next.NotifyTripUpdate(ctx, msg)
+}
+
+func (mw *validationMiddleware) List(ctx context.Context, ff ListFilter) (NotificationsList, string, error) {
+	if err := ff.Validate(); err != nil {
+		mw.logger.Warn("List")
+		return nil, "", common.ErrValidation
+	}
+	return mw.next.List(ctx, ff)
+}
+
+func (mw *validationMiddleware) CountUnread(ctx context.Context, userID string) (int64, error) {
+	if userID == "" {
+		mw.logger.Warn("CountUnread")
+		return 0, common.ErrValidation
+	}
+	return mw.next.CountUnread(ctx, userID)
+}
+
+func (mw *validationMiddleware) MarkRead(ctx context.Context, userID string, IDs []string) error {
+	if userID == "" || len(IDs) > maxMarkReadIDs {
+		mw.logger.Warn("MarkRead")
+		return common.ErrValidation
+	}
+	return mw.next.MarkRead(ctx, userID, IDs)
+}
+
+func (mw *validationMiddleware) MarkAllRead(ctx context.Context, userID string) error {
+	if userID == "" {
+		mw.logger.Warn("MarkAllRead")
+		return common.ErrValidation
+	}
+	return mw.next.MarkAllRead(ctx, userID)
+}
+
+func (mw *validationMiddleware) Subscribe(ctx context.Context, userID string) (<-chan Notification, chan<- bool, error) {
+	if userID == "" {
+		mw.logger.Warn("Subscribe")
+		return nil, nil, common.ErrValidation
+	}
+	return mw.next.Subscribe(ctx, userID)
+}
+
+func (mw *validationMiddleware) ReadPreferences(ctx context.Context, userID string) (Preferences, error) {
+	if userID == "" {
+		mw.logger.Warn("ReadPreferences")
+		return Preferences{}, common.ErrValidation
+	}
+	return mw.next.ReadPreferences(ctx, userID)
+}
+
+func (mw *validationMiddleware) UpdatePreferences(ctx context.Context, userID string, dailyDigest bool) (Preferences, error) {
+	if userID == "" {
+		mw.logger.Warn("UpdatePreferences")
+		return Preferences{}, common.ErrValidation
+	}
+	return mw.next.UpdatePreferences(ctx, userID, dailyDigest)
+}
+
+func (mw *validationMiddleware) SendDigests(ctx context.Context, now time.Time) error {
+	return mw.next.SendDigests(ctx, now)
+}
+
+type rbacMiddleware struct {
+	next   Service
+	logger *zap.Logger
+}
+
+func SvcWithRBACMw(svc Service, logger *zap.Logger) Service {
+	return &rbacMiddleware{svc, logger.Named("notifications.rbacMiddleware")}
+}
+
+// checkIsUser checks that the user makes the request for themselves.
+func (mw *rbacMiddleware) checkIsUser(ctx context.Context, userID string) error {
+	ci, err := reqctx.ClientInfoFromCtx(ctx)
+	if err != nil || ci.HasEmptyID() || ci.UserID != userID {
+		return ErrRBAC
+	}
+	return nil
+}
+
+func (mw *rbacMiddleware) Notify(ctx context.Context, list NotificationsList) error {
+	return ErrRBAC
+}
+
+func (mw *rbacMiddleware) NotifyTripUpdate(ctx context.Context, msg trips.SyncMsgTOB) error {
+	return ErrRBAC
+}
+
+func (mw *rbacMiddleware) List(ctx context.Context, ff ListFilter) (NotificationsList, string, error) {
+	if err := mw.checkIsUser(ctx, ff.UserID); err != nil {
+		return nil, "", err
+	}
+	return mw.next.List(ctx, ff)
+}
+
+func (mw *rbacMiddleware) CountUnread(ctx context.Context, userID string) (int64, error) {
+	if err := mw.checkIsUser(ctx, userID); err != nil {
+		return 0, err
+	}
+	return mw.next.CountUnread(ctx, userID)
+}
+
+func (mw *rbacMiddleware) MarkRead(ctx context.Context, userID string, IDs []string) error {
+	if err := mw.checkIsUser(ctx, userID); err != nil {
+		return err
+	}
+	return mw.next.MarkRead(ctx, userID, IDs)
+}
+
+func (mw *rbacMiddleware) MarkAllRead(ctx context.Context, userID string) error {
+	if err := mw.checkIsUser(ctx, userID); err != nil {
+		return err
+	}
+	return mw.next.MarkAllRead(ctx, userID)
+}
+
+func (mw *rbacMiddleware) Subscribe(ctx context.Context, userID string) (<-chan Notification, chan<- bool, error) {
+	if err := mw.checkIsUser(ctx, userID); err != nil {
+		return nil, nil, err
+	}
+	return mw.next.Subscribe(ctx, userID)
+}
+
+func (mw *rbacMiddleware) ReadPreferences(ctx context.Context, userID string) (Preferences, error) {
+	if err := mw.checkIsUser(ctx, userID); err != nil {
+		return Preferences{}, err
+	}
+	return mw.next.ReadPreferences(ctx, userID)
+}
+
+func (mw *rbacMiddleware) UpdatePreferences(ctx context.Context, userID string, dailyDigest bool) (Preferences, error) {
+	if err := mw.checkIsUser(ctx, userID); err != nil {
+		return Preferences{}, err
+	}
+	return mw.next.UpdatePreferences(ctx, userID, dailyDigest)
+}
+
+func (mw *rbacMiddleware) SendDigests(ctx context.Context, now time.Time) error {
+	return ErrRBAC
+}
@@ -0,0 +1,230 @@
+package notifications
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/travelreys/travelreys/pkg/common"
+	"github.com/travelreys/travelreys/pkg/trips"
+)
+
+const (
+	TypeMemberJoined       = "memberJoined"
+	TypeActivityAdded      = "activityAdded"
+	TypeActivityRemoved    = "activityRemoved"
+	TypeTripDatesChanged   = "tripDatesChanged"
+	TypeCommentMention     = "commentMention"
+	TypeTripInviteReceived = "tripInviteReceived"
+	TypeFollowRequest      = "followRequest"
+
+	// Details of the notifications
+	LabelTitle           = "title"
+	LabelCount           = "count"
+	LabelMemberID        = "memberID"
+	LabelCommentID       = "commentID"
+	LabelInviteID        = "inviteID"
+	LabelFollowRequestID = "followRequestID"
+	LabelStartDate       = "startDate"
+	LabelEndDate         = "endDate"
+
+	labelDateFormat = "2006-01-02"
+)
+
+// Notification tells a user about a change they were not around
+// for, e.g made by the other members of a trip while offline.
+type Notification struct {
+	ID      string `json:"id" bson:"id"`
+	UserID  string `json:"userID" bson:"userID"`
+	Type    string `json:"type" bson:"type"`
+	ActorID string `json:"actorID" bson:"actorID"`
+
+	TripID   string `json:"tripID" bson:"tripID"`
+	TripName string `json:"tripName" bson:"tripName"`
+
+	// EntityPath is the JSON path of the trip entity, if any.
+	EntityPath string `json:"entityPath" bson:"entityPath"`
+
+	Read      bool          `json:"read" bson:"read"`
+	CreatedAt time.Time     `json:"createdAt" bson:"createdAt"`
+	Labels    common.Labels `json:"labels" bson:"labels"`
+
+	// Digested is set once the notification was sent in an email digest.
+	Digested bool `json:"-" bson:"digested"`
+}
+
+func NewNotification(userID, nType, actorID string) Notification {
+	return Notification{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		Type:      nType,
+		ActorID:   actorID,
+		CreatedAt: time.Now(),
+		Labels:    common.Labels{},
+	}
+}
+
+func NewTripNotification(userID, nType, actorID string, trip *trips.Trip) Notification {
+	n := NewNotification(userID, nType, actorID)
+	n.TripID = trip.ID
+	n.TripName = trip.Name
+	return n
+}
+
+// Summary describes the notification in a sentence, for the email digests.
+func (n Notification) Summary(actorName string) string {
+	if actorName == "" {
+		actorName = "Someone"
+	}
+	switch n.Type {
+	case TypeMemberJoined:
+		return fmt.Sprintf("A new member joined %s", n.TripName)
+	case TypeActivityAdded:
+		if count, _ := strconv.Atoi(n.Labels[LabelCount]); count > 1 {
+			return fmt.Sprintf("%s added %d activities to %s", actorName, count, n.TripName)
+		}
+		if title := n.Labels[LabelTitle]; title != "" {
+			return fmt.Sprintf("%s added %s to %s", actorName, title, n.TripName)
+		}
+		return fmt.Sprintf("%s added an activity to %s", actorName, n.TripName)
+	case TypeActivityRemoved:
+		if count, _ := strconv.Atoi(n.Labels[LabelCount]); count > 1 {
+			return fmt.Sprintf("%s removed %d activities from %s", actorName, count, n.TripName)
+		}
+		return fmt.Sprintf("%s removed an activity from %s", actorName, n.TripName)
+	case TypeTripDatesChanged:
+		return fmt.Sprintf("%s changed the dates of %s", actorName, n.TripName)
+	case TypeCommentMention:
+		return fmt.Sprintf("%s mentioned you in a comment on %s", actorName, n.TripName)
+	case TypeTripInviteReceived:
+		return fmt.Sprintf("%s invited you to %s", actorName, n.TripName)
+	case TypeFollowRequest:
+		return fmt.Sprintf("%s wants to follow you", actorName)
+	}
+	return ""
+}
+
+type NotificationsList []Notification
+
+// GetActorIDs returns the IDs of the users who caused the notifications.
+func (l NotificationsList) GetActorIDs() []string {
+	IDs := []string{}
+	for _, n := range l {
+		if n.ActorID != "" && !common.StringContains(IDs, n.ActorID) {
+			IDs = append(IDs, n.ActorID)
+		}
+	}
+	return IDs
+}
+
+// MakeTripUpdateNotifications returns the notifications of the members
+// of the trip about the update applied by the coordinator. The member
+// who made the update is not notified.
+func MakeTripUpdateNotifications(trip *trips.Trip, msg trips.SyncMsgTOB) NotificationsList {
+	if msg.Topic != trips.SyncMsgTOBTopicUpdate || msg.Update == nil || msg.Update.Err != "" {
+		return NotificationsList{}
+	}
+
+	templates := NotificationsList{}
+	switch msg.Update.Op {
+	case trips.SyncMsgTOBUpdateOpUpdateTripMembers:
+		for _, op := range msg.Update.Ops {
+			// /members/<memberID>
+			tkns := strings.Split(op.Path, "/")
+			if op.Op != "add" || len(tkns) != 3 || tkns[1] != "members" {
+				continue
+			}
+			n := NewTripNotification("", TypeMemberJoined, msg.MemberID, trip)
+			n.Labels[LabelMemberID] = tkns[2]
+			templates = append(templates, n)
+		}
+	case trips.SyncMsgTOBUpdateOpUpdateTripDates:
+		n := NewTripNotification("", TypeTripDatesChanged, msg.MemberID, trip)
+		n.Labels[LabelStartDate] = trip.StartDate.Format(labelDateFormat)
+		n.Labels[LabelEndDate] = trip.EndDate.Format(labelDateFormat)
+		templates = append(templates, n)
+	case trips.SyncMsgTOBUpdateOpReorderActivityToAnotherDay:
+		// Activities moved around the itinerary are neither new nor removed.
+	default:
+		templates = append(templates, makeActivitiesNotifications(trip, msg)...)
+	}
+
+	result := NotificationsList{}
+	for _, memberID := range trip.GetMemberIDs() {
+		if memberID == msg.MemberID {
+			continue
+		}
+		for _, n := range templates {
+			if n.Labels[LabelMemberID] == memberID {
+				continue
+			}
+			n.ID = uuid.NewString()
+			n.UserID = memberID
+			n.Labels = copyLabels(n.Labels)
+			result = append(result, n)
+		}
+	}
+	return result
+}
+
+// makeActivitiesNotifications returns a notification for the activities
+// added, and one for those removed, by the ops.
+func makeActivitiesNotifications(trip *trips.Trip, msg trips.SyncMsgTOB) NotificationsList {
+	added, removed := []string{}, []string{}
+	for _, op := range msg.Update.Ops {
+		// /itineraries/<dtKey>/activities/<activityID>
+		tkns := strings.Split(op.Path, "/")
+		if len(tkns) != 5 || tkns[1] != "itineraries" || tkns[3] != "activities" {
+			continue
+		}
+		switch {
+		case op.Op == "add", op.Op == "move" && strings.HasPrefix(op.From, trips.JSONPathIdeasRoot):
+			added = append(added, op.Path)
+		case op.Op == "remove":
+			removed = append(removed, op.Path)
+		}
+	}
+
+	list := NotificationsList{}
+	if len(added) > 0 {
+		n := NewTripNotification("", TypeActivityAdded, msg.MemberID, trip)
+		n.EntityPath = added[0]
+		n.Labels[LabelCount] = strconv.Itoa(len(added))
+		tkns := strings.Split(added[0], "/")
+		if itin, ok := trip.Itineraries[tkns[2]]; ok {
+			if act, ok := itin.Activities[tkns[4]]; ok {
+				n.Labels[LabelTitle] = act.Title
+			}
+		}
+		list = append(list, n)
+	}
+	if len(removed) > 0 {
+		n := NewTripNotification("", TypeActivityRemoved, msg.MemberID, trip)
+		n.Labels[LabelCount] = strconv.Itoa(len(removed))
+		list = append(list, n)
+	}
+	return list
+}
+
+func copyLabels(labels common.Labels) common.Labels {
+	result := common.Labels{}
+	for key, val := range labels {
+		result[key] = val
+	}
+	return result
+}
+
+// Preferences are the notification settings of a user.
+type Preferences struct {
+	UserID string `json:"userID" bson:"userID"`
+
+	// DailyDigest emails the unread notifications once a day.
+	DailyDigest  bool      `json:"dailyDigest" bson:"dailyDigest"`
+	LastDigestAt time.Time `json:"lastDigestAt" bson:"lastDigestAt"`
+}
+
+func NewPreferences(userID string) Preferences {
+	return Preferences{UserID: userID}
+}
@@ -0,0 +1,72 @@
+package notifications
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+	"github.com/travelreys/travelreys/pkg/common"
+	"go.uber.org/zap"
+)
+
+// SubjUserNotifications is the NATS.io subj delivering the
+// notifications of the user to their connected clients.
+func SubjUserNotifications(userID string) string {
+	return fmt.Sprintf("notifications.users.%s", userID)
+}
+
+// PushStore delivers the notifications to the API servers
+// holding the connections of their users.
+type PushStore interface {
+	Pub(userID string, n Notification) error
+	Sub(userID string) (<-chan Notification, chan<- bool, error)
+}
+
+type pushStore struct {
+	nc     *nats.Conn
+	logger *zap.Logger
+}
+
+func NewPushStore(nc *nats.Conn, logger *zap.Logger) PushStore {
+	return &pushStore{nc, logger.Named("notifications.pushStore")}
+}
+
+func (s *pushStore) Pub(userID string, n Notification) error {
+	data, err := json.Marshal(n)
+	if err != nil {
+		s.logger.Error("Pub", zap.Error(err))
+		return err
+	}
+	return s.nc.Publish(SubjUserNotifications(userID), data)
+}
+
+func (s *pushStore) Sub(userID string) (<-chan Notification, chan<- bool, error) {
+	natsCh := make(chan *nats.Msg, common.DefaultChSize)
+	msgCh := make(chan Notification, common.DefaultChSize)
+	done := make(chan bool)
+
+	sub, err := s.nc.ChanSubscribe(SubjUserNotifications(userID), natsCh)
+	if err != nil {
+		s.logger.Error("Sub", zap.Error(err))
+		return nil, nil, err
+	}
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				sub.Unsubscribe()
+				close(msgCh)
+				return
+			case natsMsg := <-natsCh:
+				var n Notification
+				if err := json.Unmarshal(natsMsg.Data, &n); err != nil {
+					s.logger.Error("Sub", zap.Error(err))
+					continue
+				}
+				msgCh <- n
+			}
+		}
+	}()
+	return msgCh, done, nil
+}
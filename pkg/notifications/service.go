@@ -0,0 +1,150 @@
+package notifications
+
+import (
+	"context"
+	"time"
+
+	"github.com/travelreys/travelreys/pkg/auth"
+	"github.com/travelreys/travelreys/pkg/email"
+	"github.com/travelreys/travelreys/pkg/trips"
+	"go.uber.org/zap"
+)
+
+const (
+	// GroupNotifications is the queue group of the API servers
+	// consuming the trip updates.
+	GroupNotifications = "notifications"
+)
+
+type Service interface {
+	// Notify saves the notifications and pushes them
+	// to the connected clients of their users.
+	Notify(ctx context.Context, list NotificationsList) error
+	NotifyTripUpdate(ctx context.Context, msg trips.SyncMsgTOB) error
+
+	List(ctx context.Context, ff ListFilter) (NotificationsList, string, error)
+	CountUnread(ctx context.Context, userID string) (int64, error)
+	MarkRead(ctx context.Context, userID string, IDs []string) error
+	MarkAllRead(ctx context.Context, userID string) error
+	Subscribe(ctx context.Context, userID string) (<-chan Notification, chan<- bool, error)
+
+	ReadPreferences(ctx context.Context, userID string) (Preferences, error)
+	UpdatePreferences(ctx context.Context, userID string, dailyDigest bool) (Preferences, error)
+	SendDigests(ctx context.Context, now time.Time) error
+}
+
+type service struct {
+	store     Store
+	pushStore PushStore
+	authSvc   auth.Service
+	tripSvc   trips.Service
+	mailSvc   email.Service
+	logger    *zap.Logger
+}
+
+func NewService(
+	store Store,
+	pushStore PushStore,
+	authSvc auth.Service,
+	tripSvc trips.Service,
+	mailSvc email.Service,
+	logger *zap.Logger,
+) Service {
+	return &service{store, pushStore, authSvc, tripSvc, mailSvc, logger}
+}
+
+func (svc *service) Notify(ctx context.Context, list NotificationsList) error {
+	if err := svc.store.SaveMany(ctx, list); err != nil {
+		return err
+	}
+	for _, n := range list {
+		if err := svc.pushStore.Pub(n.UserID, n); err != nil {
+			svc.logger.Error("Notify", zap.String("userID", n.UserID), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// NotifyTripUpdate notifies the members of the trip
+// about the update applied by its coordinator.
+func (svc *service) NotifyTripUpdate(ctx context.Context, msg trips.SyncMsgTOB) error {
+	if msg.Topic != trips.SyncMsgTOBTopicUpdate || msg.Update == nil || msg.Update.Err != "" {
+		return nil
+	}
+	trip, err := svc.tripSvc.Read(ctx, msg.TripID)
+	if err != nil {
+		return err
+	}
+	return svc.Notify(ctx, MakeTripUpdateNotifications(trip, msg))
+}
+
+// RunTripUpdates notifies the members of the trips about the updates
+// applied by the coordinators, until the context is done. The API
+// servers share the updates through the queue group.
+func RunTripUpdates(ctx context.Context, svc Service, msgStore trips.SyncMsgStore, logger *zap.Logger) {
+	msgCh, doneCh, err := msgStore.SubTOBRespQueue(trips.SubjAllTrips, GroupNotifications)
+	if err != nil {
+		logger.Error("RunTripUpdates", zap.Error(err))
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			doneCh <- true
+			return
+		case msg, ok := <-msgCh:
+			if !ok {
+				return
+			}
+			if err := svc.NotifyTripUpdate(ctx, msg); err != nil {
+				logger.Error("NotifyTripUpdate", zap.String("tripID", msg.TripID), zap.Error(err))
+			}
+		}
+	}
+}
+
+func (svc *service) List(ctx context.Context, ff ListFilter) (NotificationsList, string, error) {
+	if ff.Limit == 0 {
+		ff.Limit = DefaultListLimit
+	}
+	list, err := svc.store.List(ctx, ff)
+	if err != nil {
+		return nil, "", err
+	}
+	return list, ff.NextCursor(list), nil
+}
+
+func (svc *service) CountUnread(ctx context.Context, userID string) (int64, error) {
+	return svc.store.CountUnread(ctx, userID)
+}
+
+func (svc *service) MarkRead(ctx context.Context, userID string, IDs []string) error {
+	if len(IDs) == 0 {
+		return nil
+	}
+	return svc.store.MarkRead(ctx, userID, IDs)
+}
+
+func (svc *service) MarkAllRead(ctx context.Context, userID string) error {
+	return svc.store.MarkRead(ctx, userID, nil)
+}
+
+func (svc *service) Subscribe(ctx context.Context, userID string) (<-chan Notification, chan<- bool, error) {
+	return svc.pushStore.Sub(userID)
+}
+
+func (svc *service) ReadPreferences(ctx context.Context, userID string) (Preferences, error) {
+	return svc.store.ReadPreferences(ctx, userID)
+}
+
+func (svc *service) UpdatePreferences(ctx context.Context, userID string, dailyDigest bool) (Preferences, error) {
+	prefs, err := svc.store.ReadPreferences(ctx, userID)
+	if err != nil {
+		return Preferences{}, err
+	}
+	prefs.DailyDigest = dailyDigest
+	if err := svc.store.SavePreferences(ctx, prefs); err != nil {
+		return Preferences{}, err
+	}
+	return prefs, nil
+}
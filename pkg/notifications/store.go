@@ -0,0 +1,217 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	bsonKeyID           = "id"
+	bsonKeyUserID       = "userID"
+	bsonKeyTripID       = "tripID"
+	bsonKeyRead         = "read"
+	bsonKeyDigested     = "digested"
+	bsonKeyCreatedAt    = "createdAt"
+	bsonKeyDailyDigest  = "dailyDigest"
+	bsonKeyLastDigestAt = "lastDigestAt"
+
+	CollNotifications           = "notifications"
+	CollNotificationPreferences = "notification_preferences"
+
+	// Notifications are deleted by mongo once they are this old.
+	notificationsRetention = 90 * 24 * time.Hour
+)
+
+var (
+	ErrUnexpectedStoreError = errors.New("notifications.ErrUnexpectedStoreError")
+)
+
+type Store interface {
+	SaveMany(ctx context.Context, list NotificationsList) error
+	List(ctx context.Context, ff ListFilter) (NotificationsList, error)
+	CountUnread(ctx context.Context, userID string) (int64, error)
+
+	// MarkRead marks the notifications of the user as read,
+	// or all of them if no IDs are given.
+	MarkRead(ctx context.Context, userID string, IDs []string) error
+	MarkDigested(ctx context.Context, userID string, IDs []string) error
+	DeleteByTripID(ctx context.Context, tripID string) error
+
+	ReadPreferences(ctx context.Context, userID string) (Preferences, error)
+	SavePreferences(ctx context.Context, prefs Preferences) error
+
+	// ListDigestPreferences returns the preferences of the users
+	// with a daily digest, last sent before the time.
+	ListDigestPreferences(ctx context.Context, before time.Time) ([]Preferences, error)
+	// ClaimDigest sets the user's last digest to now if it was last sent
+	// before the time, returning false when another replica claimed it.
+	ClaimDigest(ctx context.Context, userID string, before, now time.Time) (bool, error)
+}
+
+type store struct {
+	db        *mongo.Database
+	coll      *mongo.Collection
+	prefsColl *mongo.Collection
+	logger    *zap.Logger
+}
+
+func NewStore(ctx context.Context, db *mongo.Database, logger *zap.Logger) Store {
+	coll := db.Collection(CollNotifications)
+	coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.M{bsonKeyID: 1}},
+		{Keys: bson.D{
+			{Key: bsonKeyUserID, Value: 1},
+			{Key: bsonKeyCreatedAt, Value: -1},
+			{Key: bsonKeyID, Value: -1},
+		}},
+		{Keys: bson.M{bsonKeyTripID: 1}},
+		{
+			Keys:    bson.M{bsonKeyCreatedAt: 1},
+			Options: options.Index().SetExpireAfterSeconds(int32(notificationsRetention.Seconds())),
+		},
+	})
+	prefsColl := db.Collection(CollNotificationPreferences)
+	prefsColl.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.M{bsonKeyUserID: 1}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: bsonKeyDailyDigest, Value: 1}, {Key: bsonKeyLastDigestAt, Value: 1}}},
+	})
+	return &store{db, coll, prefsColl, logger.Named("notifications.store")}
+}
+
+func (s *store) SaveMany(ctx context.Context, list NotificationsList) error {
+	if len(list) == 0 {
+		return nil
+	}
+	docs := []interface{}{}
+	for _, n := range list {
+		docs = append(docs, n)
+	}
+	if _, err := s.coll.InsertMany(ctx, docs); err != nil {
+		s.logger.Error("SaveMany", zap.Error(err))
+		return ErrUnexpectedStoreError
+	}
+	return nil
+}
+
+func (s *store) List(ctx context.Context, ff ListFilter) (NotificationsList, error) {
+	list := NotificationsList{}
+	opts := options.Find().SetSort(ff.toSortBSON())
+	if ff.Limit > 0 {
+		opts.SetLimit(ff.Limit)
+	}
+	cursor, err := s.coll.Find(ctx, ff.toBSON(), opts)
+	if err != nil {
+		s.logger.Error("List", zap.Error(err))
+		return list, ErrUnexpectedStoreError
+	}
+	if err := cursor.All(ctx, &list); err != nil {
+		s.logger.Error("List", zap.Error(err))
+		return list, ErrUnexpectedStoreError
+	}
+	return list, nil
+}
+
+func (s *store) CountUnread(ctx context.Context, userID string) (int64, error) {
+	count, err := s.coll.CountDocuments(ctx, bson.M{bsonKeyUserID: userID, bsonKeyRead: false})
+	if err != nil {
+		s.logger.Error("CountUnread", zap.String("userID", userID), zap.Error(err))
+		return 0, ErrUnexpectedStoreError
+	}
+	return count, nil
+}
+
+func (s *store) MarkRead(ctx context.Context, userID string, IDs []string) error {
+	ff := bson.M{bsonKeyUserID: userID, bsonKeyRead: false}
+	if len(IDs) > 0 {
+		ff[bsonKeyID] = bson.M{"$in": IDs}
+	}
+	_, err := s.coll.UpdateMany(ctx, ff, bson.M{"$set": bson.M{bsonKeyRead: true}})
+	if err != nil {
+		s.logger.Error("MarkRead", zap.String("userID", userID), zap.Error(err))
+		return ErrUnexpectedStoreError
+	}
+	return nil
+}
+
+func (s *store) MarkDigested(ctx context.Context, userID string, IDs []string) error {
+	ff := bson.M{bsonKeyUserID: userID, bsonKeyID: bson.M{"$in": IDs}}
+	_, err := s.coll.UpdateMany(ctx, ff, bson.M{"$set": bson.M{bsonKeyDigested: true}})
+	if err != nil {
+		s.logger.Error("MarkDigested", zap.String("userID", userID), zap.Error(err))
+		return ErrUnexpectedStoreError
+	}
+	return nil
+}
+
+func (s *store) DeleteByTripID(ctx context.Context, tripID string) error {
+	_, err := s.coll.DeleteMany(ctx, bson.M{bsonKeyTripID: tripID})
+	if err != nil {
+		s.logger.Error("DeleteByTripID", zap.String("tripID", tripID), zap.Error(err))
+		return ErrUnexpectedStoreError
+	}
+	return nil
+}
+
+func (s *store) ReadPreferences(ctx context.Context, userID string) (Preferences, error) {
+	var prefs Preferences
+	err := s.prefsColl.FindOne(ctx, bson.M{bsonKeyUserID: userID}).Decode(&prefs)
+	if err == mongo.ErrNoDocuments {
+		return NewPreferences(userID), nil
+	}
+	if err != nil {
+		s.logger.Error("ReadPreferences", zap.String("userID", userID), zap.Error(err))
+		return Preferences{}, ErrUnexpectedStoreError
+	}
+	return prefs, nil
+}
+
+func (s *store) SavePreferences(ctx context.Context, prefs Preferences) error {
+	saveFF := bson.M{bsonKeyUserID: prefs.UserID}
+	opts := options.Replace().SetUpsert(true)
+	_, err := s.prefsColl.ReplaceOne(ctx, saveFF, prefs, opts)
+	if err != nil {
+		s.logger.Error("SavePreferences", zap.Error(err))
+		return ErrUnexpectedStoreError
+	}
+	return nil
+}
+
+func (s *store) ListDigestPreferences(ctx context.Context, before time.Time) ([]Preferences, error) {
+	list := []Preferences{}
+	ff := bson.M{bsonKeyDailyDigest: true, bsonKeyLastDigestAt: bson.M{"$lt": before}}
+	cursor, err := s.prefsColl.Find(ctx, ff)
+	if err != nil {
+		s.logger.Error("ListDigestPreferences", zap.Error(err))
+		return list, ErrUnexpectedStoreError
+	}
+	if err := cursor.All(ctx, &list); err != nil {
+		s.logger.Error("ListDigestPreferences", zap.Error(err))
+		return list, ErrUnexpectedStoreError
+	}
+	return list, nil
+}
+
+func (s *store) ClaimDigest(ctx context.Context, userID string, before, now time.Time) (bool, error) {
+	ff := bson.M{
+		bsonKeyUserID:       userID,
+		bsonKeyDailyDigest:  true,
+		bsonKeyLastDigestAt: bson.M{"$lt": before},
+	}
+	err := s.prefsColl.FindOneAndUpdate(
+		ctx, ff, bson.M{"$set": bson.M{bsonKeyLastDigestAt: now}},
+	).Err()
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	if err != nil {
+		s.logger.Error("ClaimDigest", zap.String("userID", userID), zap.Error(err))
+		return false, ErrUnexpectedStoreError
+	}
+	return true, nil
+}
@@ -0,0 +1,178 @@
+package notifications
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/travelreys/travelreys/pkg/common"
+	"github.com/travelreys/travelreys/pkg/reqctx"
+)
+
+func errToHttpCode(err error) int {
+	appErrors := []error{ErrUnexpectedStoreError}
+	validationErrors := []error{common.ErrValidation, ErrInvalidFilter}
+
+	if common.ErrorContains(appErrors, err) {
+		return http.StatusUnprocessableEntity
+	}
+	if common.ErrorContains(validationErrors, err) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, ErrRBAC) {
+		return http.StatusUnauthorized
+	}
+	return http.StatusInternalServerError
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if e, ok := response.(common.Errorer); ok && e.Error() != nil {
+		common.EncodeErrorFactory(errToHttpCode)(ctx, e.Error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Encoding", "gzip")
+
+	gw := gzip.NewWriter(w)
+	defer gw.Close()
+
+	return json.NewEncoder(gw).Encode(response)
+}
+
+func MakeHandler(svc Service) http.Handler {
+	r := mux.NewRouter()
+
+	opts := []kithttp.ServerOption{
+		kithttp.ServerBefore(reqctx.ContextWithClientInfo),
+		kithttp.ServerErrorEncoder(common.EncodeErrorFactory(errToHttpCode)),
+	}
+
+	listHandler := kithttp.NewServer(
+		NewListEndpoint(svc),
+		decodeListRequest,
+		encodeResponse, opts...,
+	)
+	countUnreadHandler := kithttp.NewServer(
+		NewCountUnreadEndpoint(svc),
+		decodeCountUnreadRequest,
+		encodeResponse, opts...,
+	)
+	markReadHandler := kithttp.NewServer(
+		NewMarkReadEndpoint(svc),
+		decodeMarkReadRequest,
+		encodeResponse, opts...,
+	)
+	markAllReadHandler := kithttp.NewServer(
+		NewMarkAllReadEndpoint(svc),
+		decodeMarkAllReadRequest,
+		encodeResponse, opts...,
+	)
+	readPreferencesHandler := kithttp.NewServer(
+		NewReadPreferencesEndpoint(svc),
+		decodeReadPreferencesRequest,
+		encodeResponse, opts...,
+	)
+	updatePreferencesHandler := kithttp.NewServer(
+		NewUpdatePreferencesEndpoint(svc),
+		decodeUpdatePreferencesRequest,
+		encodeResponse, opts...,
+	)
+
+	r.Handle("/api/v1/notifications", listHandler).Methods(http.MethodGet)
+	r.Handle("/api/v1/notifications/unread/count", countUnreadHandler).Methods(http.MethodGet)
+	r.Handle("/api/v1/notifications/read", markReadHandler).Methods(http.MethodPut)
+	r.Handle("/api/v1/notifications/read/all", markAllReadHandler).Methods(http.MethodPut)
+	r.Handle("/api/v1/notifications/preferences", readPreferencesHandler).Methods(http.MethodGet)
+	r.Handle("/api/v1/notifications/preferences", updatePreferencesHandler).Methods(http.MethodPut)
+
+	return r
+}
+
+// userIDFromCtx returns the ID of the requesting user,
+// the notifications are only ever read by their user.
+func userIDFromCtx(ctx context.Context) (string, error) {
+	ci, err := reqctx.ClientInfoFromCtx(ctx)
+	if err != nil {
+		return "", err
+	}
+	if ci.HasEmptyID() {
+		return "", ErrRBAC
+	}
+	return ci.UserID, nil
+}
+
+func decodeListRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	userID, err := userIDFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	q := r.URL.Query()
+	ff := ListFilter{
+		UserID:     userID,
+		OnlyUnread: q.Get("unread") == "true",
+		Cursor:     q.Get("cursor"),
+	}
+	if limit := q.Get("limit"); limit != "" {
+		ff.Limit, err = strconv.ParseInt(limit, 10, 64)
+		if err != nil {
+			return nil, common.ErrInvalidRequest
+		}
+	}
+	return ListRequest{Filter: ff}, nil
+}
+
+func decodeCountUnreadRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	userID, err := userIDFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return CountUnreadRequest{UserID: userID}, nil
+}
+
+func decodeMarkReadRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	userID, err := userIDFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req := MarkReadRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, common.ErrInvalidJSONBody
+	}
+	req.UserID = userID
+	return req, nil
+}
+
+func decodeMarkAllReadRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	userID, err := userIDFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return MarkAllReadRequest{UserID: userID}, nil
+}
+
+func decodeReadPreferencesRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	userID, err := userIDFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ReadPreferencesRequest{UserID: userID}, nil
+}
+
+func decodeUpdatePreferencesRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	userID, err := userIDFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req := UpdatePreferencesRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, common.ErrInvalidJSONBody
+	}
+	req.UserID = userID
+	return req, nil
+}
@@ -0,0 +1,102 @@
+package notifications
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/travelreys/travelreys/pkg/reqctx"
+	"go.uber.org/zap"
+)
+
+// https://github.com/gorilla/websocket/tree/master/examples/chat
+
+const (
+	// Time allowed to write a message to the peer.
+	writeWait = 10 * time.Second
+
+	// Time allowed to read the next pong message from the peer.
+	pongWait = 60 * time.Second
+
+	// Send pings to peer with this period. Must be less than pongWait.
+	pingPeriod = (pongWait * 9) / 10
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+// WebsocketServer pushes the new notifications of the
+// signed in user to their connected clients.
+type WebsocketServer struct {
+	svc    Service
+	logger *zap.Logger
+}
+
+func NewWebsocketServer(svc Service, logger *zap.Logger) *WebsocketServer {
+	return &WebsocketServer{svc: svc, logger: logger.Named("notifications.websocketServer")}
+}
+
+func (srv *WebsocketServer) HandleFunc(w http.ResponseWriter, r *http.Request) {
+	ctx := reqctx.ContextWithClientInfo(r.Context(), r)
+	userID, err := userIDFromCtx(ctx)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	msgCh, doneCh, err := srv.svc.Subscribe(ctx, userID)
+	if err != nil {
+		w.WriteHeader(errToHttpCode(err))
+		return
+	}
+	defer func() { doneCh <- true }()
+
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		srv.logger.Error("upgrader.Upgrade", zap.Error(err))
+		return
+	}
+	defer ws.Close()
+
+	// The clients only send control messages, reading them
+	// handles the pongs and notices when the connection closes.
+	closed := make(chan bool)
+	go func() {
+		defer close(closed)
+		ws.SetReadDeadline(time.Now().Add(pongWait))
+		ws.SetPongHandler(func(string) error {
+			return ws.SetReadDeadline(time.Now().Add(pongWait))
+		})
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	pingTicker := time.NewTicker(pingPeriod)
+	defer pingTicker.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case n, ok := <-msgCh:
+			if !ok {
+				return
+			}
+			ws.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := ws.WriteJSON(n); err != nil {
+				return
+			}
+		case <-pingTicker.C:
+			ws.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := ws.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
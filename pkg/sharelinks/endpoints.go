@@ -0,0 +1,161 @@
+package sharelinks
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/travelreys/travelreys/pkg/common"
+	"github.com/travelreys/travelreys/pkg/trips"
+)
+
+type CreateRequest struct {
+	TripID    string     `json:"tripID"`
+	CreatorID string     `json:"creatorID"`
+	Scope     string     `json:"scope"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+	Password  string     `json:"password"`
+}
+
+type CreateResponse struct {
+	ShareLink ShareLink `json:"shareLink"`
+	Err       error     `json:"error,omitempty"`
+}
+
+func (r CreateResponse) Error() error {
+	return r.Err
+}
+
+func NewCreateEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(CreateRequest)
+		if !ok {
+			return CreateResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		link, err := svc.Create(ctx, req.TripID, req.CreatorID, req.Scope, req.ExpiresAt, req.Password)
+		return CreateResponse{ShareLink: link, Err: err}, nil
+	}
+}
+
+type ReadRequest struct {
+	ID string `json:"id"`
+}
+
+type ReadResponse struct {
+	ShareLink ShareLink `json:"shareLink"`
+	Err       error     `json:"error,omitempty"`
+}
+
+func (r ReadResponse) Error() error {
+	return r.Err
+}
+
+func NewReadEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(ReadRequest)
+		if !ok {
+			return ReadResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		link, err := svc.Read(ctx, req.ID)
+		return ReadResponse{ShareLink: link, Err: err}, nil
+	}
+}
+
+type ListRequest struct {
+	TripID string `json:"tripID"`
+}
+
+type ListResponse struct {
+	ShareLinks ShareLinksList `json:"shareLinks"`
+	Err        error          `json:"error,omitempty"`
+}
+
+func (r ListResponse) Error() error {
+	return r.Err
+}
+
+func NewListEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(ListRequest)
+		if !ok {
+			return ListResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		links, err := svc.List(ctx, req.TripID)
+		return ListResponse{ShareLinks: links, Err: err}, nil
+	}
+}
+
+type RevokeRequest struct {
+	ID string `json:"id"`
+}
+
+type RevokeResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r RevokeResponse) Error() error {
+	return r.Err
+}
+
+func NewRevokeEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(RevokeRequest)
+		if !ok {
+			return RevokeResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		err := svc.Revoke(ctx, req.ID)
+		return RevokeResponse{Err: err}, nil
+	}
+}
+
+type ReadSharedTripRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type ReadSharedTripResponse struct {
+	Trip  *trips.Trip `json:"trip"`
+	Scope string      `json:"scope"`
+	Err   error       `json:"error,omitempty"`
+}
+
+func (r ReadSharedTripResponse) Error() error {
+	return r.Err
+}
+
+func NewReadSharedTripEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(ReadSharedTripRequest)
+		if !ok {
+			return ReadSharedTripResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		trip, link, err := svc.ReadSharedTrip(ctx, req.Token, req.Password)
+		return ReadSharedTripResponse{Trip: trip, Scope: link.Scope, Err: err}, nil
+	}
+}
+
+type JoinRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+	UserID   string `json:"userID"`
+}
+
+type JoinResponse struct {
+	TripID string `json:"tripID"`
+	Err    error  `json:"error,omitempty"`
+}
+
+func (r JoinResponse) Error() error {
+	return r.Err
+}
+
+func NewJoinEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(JoinRequest)
+		if !ok {
+			return JoinResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		tripID, err := svc.Join(ctx, req.Token, req.Password, req.UserID)
+		return JoinResponse{TripID: tripID, Err: err}, nil
+	}
+}
@@ -0,0 +1,166 @@
+package sharelinks
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/travelreys/travelreys/pkg/common"
+	"github.com/travelreys/travelreys/pkg/reqctx"
+	"github.com/travelreys/travelreys/pkg/trips"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrRBAC = errors.New("sharelinks.ErrRBAC")
+)
+
+type validationMiddleware struct {
+	next   Service
+	logger *zap.Logger
+}
+
+func SvcWithValidationMw(svc Service, logger *zap.Logger) Service {
+	return &validationMiddleware{svc, logger.Named("sharelinks.validationMiddleware")}
+}
+
+func (mw *validationMiddleware) Create(
+	ctx context.Context,
+	tripID,
+	creatorID,
+	scope string,
+	expiresAt *time.Time,
+	password string,
+) (ShareLink, error) {
+	if tripID == "" ||
+		creatorID == "" ||
+		!common.StringContains(ScopesList, scope) ||
+		(expiresAt != nil && expiresAt.Before(time.Now())) ||
+		len(password) > maxPasswordLength {
+		mw.logger.Warn("Create")
+		return ShareLink{}, common.ErrValidation
+	}
+	return mw.next.Create(ctx, tripID, creatorID, scope, expiresAt, password)
+}
+
+func (mw *validationMiddleware) Read(ctx context.Context, ID string) (ShareLink, error) {
+	if ID == "" {
+		mw.logger.Warn("Read")
+		return ShareLink{}, common.ErrValidation
+	}
+	return mw.next.Read(ctx, ID)
+}
+
+func (mw *validationMiddleware) List(ctx context.Context, tripID string) (ShareLinksList, error) {
+	if tripID == "" {
+		mw.logger.Warn("List")
+		return nil, common.ErrValidation
+	}
+	return mw.next.List(ctx, tripID)
+}
+
+func (mw *validationMiddleware) Revoke(ctx context.Context, ID string) error {
+	if ID == "" {
+		mw.logger.Warn("Revoke")
+		return common.ErrValidation
+	}
+	return mw.next.Revoke(ctx, ID)
+}
+
+func (mw *validationMiddleware) ReadSharedTrip(ctx context.Context, token, password string) (*trips.Trip, ShareLink, error) {
+	if token == "" {
+		mw.logger.Warn("ReadSharedTrip")
+		return nil, ShareLink{}, common.ErrValidation
+	}
+	return mw.next.ReadSharedTrip(ctx, token, password)
+}
+
+func (mw *validationMiddleware) Join(ctx context.Context, token, password, userID string) (string, error) {
+	if token == "" || userID == "" {
+		mw.logger.Warn("Join")
+		return "", common.ErrValidation
+	}
+	return mw.next.Join(ctx, token, password, userID)
+}
+
+type rbacMiddleware struct {
+	next    Service
+	tripSvc trips.Service
+	logger  *zap.Logger
+}
+
+func SvcWithRBACMw(svc Service, tripSvc trips.Service, logger *zap.Logger) Service {
+	return &rbacMiddleware{svc, tripSvc, logger.Named("sharelinks.rbacMiddleware")}
+}
+
+// checkCanManageSharing checks that the user's role in the trip lets them manage its sharing.
+func (mw *rbacMiddleware) checkCanManageSharing(ctx context.Context, tripID string) (reqctx.ClientInfo, error) {
+	ci, err := reqctx.ClientInfoFromCtx(ctx)
+	if err != nil || ci.HasEmptyID() {
+		return ci, ErrRBAC
+	}
+	trip, err := mw.tripSvc.Read(ctx, tripID)
+	if err != nil {
+		return ci, err
+	}
+	if !trip.HasPermission(ci.UserID, trips.PermissionManageSharing) {
+		return ci, ErrRBAC
+	}
+	return ci, nil
+}
+
+func (mw *rbacMiddleware) Create(
+	ctx context.Context,
+	tripID,
+	creatorID,
+	scope string,
+	expiresAt *time.Time,
+	password string,
+) (ShareLink, error) {
+	ci, err := mw.checkCanManageSharing(ctx, tripID)
+	if err != nil {
+		return ShareLink{}, err
+	}
+	if ci.UserID != creatorID {
+		return ShareLink{}, ErrRBAC
+	}
+	return mw.next.Create(ctx, tripID, creatorID, scope, expiresAt, password)
+}
+
+func (mw *rbacMiddleware) Read(ctx context.Context, ID string) (ShareLink, error) {
+	link, err := mw.next.Read(ctx, ID)
+	if err != nil {
+		return ShareLink{}, err
+	}
+	if _, err := mw.checkCanManageSharing(ctx, link.TripID); err != nil {
+		return ShareLink{}, err
+	}
+	return link, nil
+}
+
+func (mw *rbacMiddleware) List(ctx context.Context, tripID string) (ShareLinksList, error) {
+	if _, err := mw.checkCanManageSharing(ctx, tripID); err != nil {
+		return nil, err
+	}
+	return mw.next.List(ctx, tripID)
+}
+
+func (mw *rbacMiddleware) Revoke(ctx context.Context, ID string) error {
+	if _, err := mw.Read(ctx, ID); err != nil {
+		return err
+	}
+	return mw.next.Revoke(ctx, ID)
+}
+
+// ReadSharedTrip is open to anyone with the token.
+func (mw *rbacMiddleware) ReadSharedTrip(ctx context.Context, token, password string) (*trips.Trip, ShareLink, error) {
+	return mw.next.ReadSharedTrip(ctx, token, password)
+}
+
+func (mw *rbacMiddleware) Join(ctx context.Context, token, password, userID string) (string, error) {
+	ci, err := reqctx.ClientInfoFromCtx(ctx)
+	if err != nil || ci.HasEmptyID() || ci.UserID != userID {
+		return "", ErrRBAC
+	}
+	return mw.next.Join(ctx, token, password, userID)
+}
@@ -0,0 +1,127 @@
+package sharelinks
+
+import (
+	"context"
+	"time"
+
+	"github.com/travelreys/travelreys/pkg/trips"
+	"go.uber.org/zap"
+)
+
+type Service interface {
+	Create(ctx context.Context, tripID, creatorID, scope string, expiresAt *time.Time, password string) (ShareLink, error)
+	Read(ctx context.Context, ID string) (ShareLink, error)
+	List(ctx context.Context, tripID string) (ShareLinksList, error)
+	Revoke(ctx context.Context, ID string) error
+
+	// ReadSharedTrip returns the trip redacted for the scope of the link.
+	ReadSharedTrip(ctx context.Context, token, password string) (*trips.Trip, ShareLink, error)
+
+	// Join adds the user to the trip as a collaborator, if the link allows it.
+	Join(ctx context.Context, token, password, userID string) (string, error)
+}
+
+type service struct {
+	tripSvc trips.Service
+	syncSvc trips.SyncService
+	store   Store
+	logger  *zap.Logger
+}
+
+func NewService(
+	tripSvc trips.Service,
+	syncSvc trips.SyncService,
+	store Store,
+	logger *zap.Logger,
+) Service {
+	return &service{tripSvc, syncSvc, store, logger}
+}
+
+func (svc *service) Create(
+	ctx context.Context,
+	tripID,
+	creatorID,
+	scope string,
+	expiresAt *time.Time,
+	password string,
+) (ShareLink, error) {
+	link, err := NewShareLink(tripID, creatorID, scope, expiresAt, password)
+	if err != nil {
+		svc.logger.Error("Create", zap.Error(err))
+		return ShareLink{}, err
+	}
+	return link, svc.store.Save(ctx, link)
+}
+
+func (svc *service) Read(ctx context.Context, ID string) (ShareLink, error) {
+	return svc.store.Read(ctx, ID)
+}
+
+func (svc *service) List(ctx context.Context, tripID string) (ShareLinksList, error) {
+	return svc.store.List(ctx, tripID)
+}
+
+func (svc *service) Revoke(ctx context.Context, ID string) error {
+	return svc.store.Delete(ctx, ID)
+}
+
+func (svc *service) ReadSharedTrip(ctx context.Context, token, password string) (*trips.Trip, ShareLink, error) {
+	link, trip, err := svc.readLinkAndTrip(ctx, token, password)
+	if err != nil {
+		return nil, ShareLink{}, err
+	}
+	return MakeSharedTrip(trip, link.Scope), link, nil
+}
+
+func (svc *service) Join(ctx context.Context, token, password, userID string) (string, error) {
+	link, trip, err := svc.readLinkAndTrip(ctx, token, password)
+	if err != nil {
+		return "", err
+	}
+	if link.Scope != ScopeCollaborate {
+		return "", ErrRBAC
+	}
+	if trip.MemberRole(userID) != "" {
+		return trip.ID, nil
+	}
+	// The member is added on behalf of the creator of the link,
+	// who may have lost the permission since.
+	if !trip.HasPermission(link.CreatorID, trips.PermissionManageMembers) {
+		return "", ErrRBAC
+	}
+
+	err = trips.SyncUpdate(
+		ctx,
+		svc.syncSvc,
+		trip.ID,
+		link.CreatorID,
+		trips.SyncMsgTOBUpdateOpUpdateTripMembers,
+		trips.MakeSyncMsgTOBUpdateOpUpdateTripMembersOps(
+			trips.NewMember(userID, trips.MemberRoleCollaborator),
+		),
+	)
+	if err != nil {
+		return "", err
+	}
+	return trip.ID, nil
+}
+
+// readLinkAndTrip reads the link with the token, checks its expiry and
+// password, and reads the trip it shares.
+func (svc *service) readLinkAndTrip(ctx context.Context, token, password string) (ShareLink, *trips.Trip, error) {
+	link, err := svc.store.ReadByToken(ctx, token)
+	if err != nil {
+		return ShareLink{}, nil, err
+	}
+	if err := link.Check(password, time.Now()); err != nil {
+		return ShareLink{}, nil, err
+	}
+	trip, err := svc.tripSvc.Read(ctx, link.TripID)
+	if err != nil {
+		return ShareLink{}, nil, err
+	}
+	if trip.Deleted {
+		return ShareLink{}, nil, trips.ErrTripNotFound
+	}
+	return link, trip, nil
+}
@@ -0,0 +1,176 @@
+package sharelinks
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/travelreys/travelreys/pkg/common"
+	"github.com/travelreys/travelreys/pkg/finance"
+	"github.com/travelreys/travelreys/pkg/trips"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// ScopeView shows the trip without its budget and expenses.
+	ScopeView = "view"
+	// ScopeViewWithBudget shows the trip together with its budget.
+	ScopeViewWithBudget = "viewWithBudget"
+	// ScopeCollaborate lets signed in users join the trip as collaborators.
+	ScopeCollaborate = "collaborate"
+
+	tokenNumBytes = 24
+
+	// bcrypt ignores the bytes of a password beyond 72.
+	maxPasswordLength = 72
+)
+
+var (
+	ErrShareLinkExpired = errors.New("sharelinks.ErrShareLinkExpired")
+	ErrInvalidPassword  = errors.New("sharelinks.ErrInvalidPassword")
+
+	ScopesList = []string{ScopeView, ScopeViewWithBudget, ScopeCollaborate}
+)
+
+// ShareLink gives access to a trip to anyone with its token.
+type ShareLink struct {
+	ID        string     `json:"id" bson:"id"`
+	TripID    string     `json:"tripID" bson:"tripID"`
+	CreatorID string     `json:"creatorID" bson:"creatorID"`
+	Token     string     `json:"token" bson:"token"`
+	Scope     string     `json:"scope" bson:"scope"`
+	ExpiresAt *time.Time `json:"expiresAt" bson:"expiresAt"`
+
+	PasswordHash string `json:"-" bson:"passwordHash"`
+	HasPassword  bool   `json:"hasPassword" bson:"hasPassword"`
+
+	CreatedAt time.Time     `json:"createdAt" bson:"createdAt"`
+	Labels    common.Labels `json:"labels" bson:"labels"`
+}
+
+type ShareLinksList []ShareLink
+
+func NewShareLink(
+	tripID,
+	creatorID,
+	scope string,
+	expiresAt *time.Time,
+	password string,
+) (ShareLink, error) {
+	token, err := generateToken()
+	if err != nil {
+		return ShareLink{}, err
+	}
+	link := ShareLink{
+		ID:        uuid.NewString(),
+		TripID:    tripID,
+		CreatorID: creatorID,
+		Token:     token,
+		Scope:     scope,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+		Labels:    common.Labels{},
+	}
+	if password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return ShareLink{}, err
+		}
+		link.PasswordHash = string(hash)
+		link.HasPassword = true
+	}
+	return link, nil
+}
+
+func generateToken() (string, error) {
+	b := make([]byte, tokenNumBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func (l ShareLink) IsExpired(now time.Time) bool {
+	return l.ExpiresAt != nil && now.After(*l.ExpiresAt)
+}
+
+// Check verifies that the link is still valid and that
+// the password matches, if the link has one.
+func (l ShareLink) Check(password string, now time.Time) error {
+	if l.IsExpired(now) {
+		return ErrShareLinkExpired
+	}
+	if !l.HasPassword {
+		return nil
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(l.PasswordHash), []byte(password)); err != nil {
+		return ErrInvalidPassword
+	}
+	return nil
+}
+
+// MakeSharedTrip redacts the trip for the scope of the link. Booking
+// references, attachments, checklists and the members' details are
+// never shared, the budget and prices only with ScopeViewWithBudget.
+func MakeSharedTrip(trip *trips.Trip, scope string) *trips.Trip {
+	shared := *trip
+	withBudget := scope != ScopeView
+
+	shared.Members = trips.MembersMap{}
+	for ID, mem := range trip.Members {
+		shared.Members[ID] = &trips.Member{ID: ID, Role: mem.Role, Labels: common.Labels{}}
+	}
+	shared.Creator = trips.Member{ID: trip.Creator.ID, Role: trip.Creator.Role, Labels: common.Labels{}}
+	shared.MembersID = map[string]string{}
+	shared.Files = trips.FilesMap{}
+	shared.Checklists = trips.ChecklistsMap{}
+	shared.Labels = common.Labels{}
+	if sharing, ok := trip.Labels[trips.LabelSharingAccess]; ok {
+		shared.Labels[trips.LabelSharingAccess] = sharing
+	}
+	if !withBudget {
+		shared.Budget = trips.NewBudget()
+		shared.Settlements = trips.SettlementsMap{}
+	}
+
+	shared.Lodgings = trips.LodgingsMap{}
+	for ID, l := range trip.Lodgings {
+		lodging := *l
+		lodging.ConfirmationID = ""
+		if !withBudget {
+			lodging.PriceItem = finance.PriceItem{}
+		}
+		shared.Lodgings[ID] = &lodging
+	}
+	shared.Transits = trips.TransitsMap{}
+	for ID, t := range trip.Transits {
+		transit := *t
+		transit.ConfirmationID = ""
+		if !withBudget {
+			transit.PriceItem = finance.PriceItem{}
+		}
+		shared.Transits[ID] = &transit
+	}
+	shared.Itineraries = trips.ItineraryMap{}
+	for dtKey, itin := range trip.Itineraries {
+		newItin := *itin
+		newItin.Activities = redactActivities(itin.Activities, withBudget)
+		shared.Itineraries[dtKey] = &newItin
+	}
+	shared.Ideas = redactActivities(trip.Ideas, withBudget)
+	return &shared
+}
+
+func redactActivities(activities trips.ActivityMap, withBudget bool) trips.ActivityMap {
+	result := trips.ActivityMap{}
+	for ID, act := range activities {
+		newAct := *act
+		if !withBudget {
+			newAct.PriceItem = finance.PriceItem{}
+		}
+		result[ID] = &newAct
+	}
+	return result
+}
@@ -0,0 +1,111 @@
+package sharelinks
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	bsonKeyID     = "id"
+	bsonKeyToken  = "token"
+	bsonKeyTripID = "tripID"
+
+	CollShareLinks = "share_links"
+)
+
+var (
+	ErrShareLinkNotFound    = errors.New("sharelinks.ErrShareLinkNotFound")
+	ErrUnexpectedStoreError = errors.New("sharelinks.ErrUnexpectedStoreError")
+)
+
+type Store interface {
+	Save(ctx context.Context, link ShareLink) error
+	Read(ctx context.Context, ID string) (ShareLink, error)
+	ReadByToken(ctx context.Context, token string) (ShareLink, error)
+	List(ctx context.Context, tripID string) (ShareLinksList, error)
+	Delete(ctx context.Context, ID string) error
+	DeleteByTripID(ctx context.Context, tripID string) error
+}
+
+type store struct {
+	db     *mongo.Database
+	coll   *mongo.Collection
+	logger *zap.Logger
+}
+
+func NewStore(ctx context.Context, db *mongo.Database, logger *zap.Logger) Store {
+	coll := db.Collection(CollShareLinks)
+	coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.M{bsonKeyID: 1}},
+		{Keys: bson.M{bsonKeyToken: 1}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.M{bsonKeyTripID: 1}},
+	})
+	return &store{db, coll, logger.Named("sharelinks.store")}
+}
+
+func (s *store) Save(ctx context.Context, link ShareLink) error {
+	saveFF := bson.M{bsonKeyID: link.ID}
+	opts := options.Replace().SetUpsert(true)
+	_, err := s.coll.ReplaceOne(ctx, saveFF, link, opts)
+	if err != nil {
+		s.logger.Error("Save", zap.Error(err))
+		return ErrUnexpectedStoreError
+	}
+	return nil
+}
+
+func (s *store) Read(ctx context.Context, ID string) (ShareLink, error) {
+	return s.readOne(ctx, bson.M{bsonKeyID: ID})
+}
+
+func (s *store) ReadByToken(ctx context.Context, token string) (ShareLink, error) {
+	return s.readOne(ctx, bson.M{bsonKeyToken: token})
+}
+
+func (s *store) readOne(ctx context.Context, ff bson.M) (ShareLink, error) {
+	var link ShareLink
+	err := s.coll.FindOne(ctx, ff).Decode(&link)
+	if err == mongo.ErrNoDocuments {
+		return ShareLink{}, ErrShareLinkNotFound
+	}
+	if err != nil {
+		s.logger.Error("readOne", zap.Error(err))
+		return ShareLink{}, ErrUnexpectedStoreError
+	}
+	return link, nil
+}
+
+func (s *store) List(ctx context.Context, tripID string) (ShareLinksList, error) {
+	list := ShareLinksList{}
+	opts := options.Find().SetSort(bson.M{"createdAt": -1})
+	cursor, err := s.coll.Find(ctx, bson.M{bsonKeyTripID: tripID}, opts)
+	if err != nil {
+		s.logger.Error("List", zap.Error(err))
+		return list, ErrUnexpectedStoreError
+	}
+	err = cursor.All(ctx, &list)
+	return list, err
+}
+
+func (s *store) Delete(ctx context.Context, ID string) error {
+	_, err := s.coll.DeleteOne(ctx, bson.M{bsonKeyID: ID})
+	if err != nil {
+		s.logger.Error("Delete", zap.String("id", ID), zap.Error(err))
+		return ErrUnexpectedStoreError
+	}
+	return nil
+}
+
+func (s *store) DeleteByTripID(ctx context.Context, tripID string) error {
+	_, err := s.coll.DeleteMany(ctx, bson.M{bsonKeyTripID: tripID})
+	if err != nil {
+		s.logger.Error("DeleteByTripID", zap.String("tripID", tripID), zap.Error(err))
+		return ErrUnexpectedStoreError
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package sharelinks
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/travelreys/travelreys/pkg/common"
+	"github.com/travelreys/travelreys/pkg/reqctx"
+	"github.com/travelreys/travelreys/pkg/trips"
+)
+
+const (
+	URLPathVarID    = "id"
+	URLPathVarToken = "token"
+)
+
+func errToHttpCode(err error) int {
+	notFoundErrors := []error{
+		ErrShareLinkNotFound,
+		trips.ErrTripNotFound,
+	}
+	appErrors := []error{ErrUnexpectedStoreError, trips.ErrUpdateNotApplied, trips.ErrUpdateTimeout}
+	unauthorizedErrors := []error{ErrRBAC, ErrInvalidPassword, trips.ErrRBAC}
+
+	if common.ErrorContains(notFoundErrors, err) {
+		return http.StatusNotFound
+	}
+	if errors.Is(err, ErrShareLinkExpired) {
+		return http.StatusGone
+	}
+	if common.ErrorContains(appErrors, err) {
+		return http.StatusUnprocessableEntity
+	}
+	if common.ErrorContains(unauthorizedErrors, err) {
+		return http.StatusUnauthorized
+	}
+	if errors.Is(err, common.ErrValidation) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if e, ok := response.(common.Errorer); ok && e.Error() != nil {
+		common.EncodeErrorFactory(errToHttpCode)(ctx, e.Error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Encoding", "gzip")
+
+	gw := gzip.NewWriter(w)
+	defer gw.Close()
+
+	return json.NewEncoder(gw).Encode(response)
+}
+
+func MakeHandler(svc Service) http.Handler {
+	r := mux.NewRouter()
+
+	opts := []kithttp.ServerOption{
+		kithttp.ServerBefore(reqctx.ContextWithClientInfo),
+		kithttp.ServerErrorEncoder(common.EncodeErrorFactory(errToHttpCode)),
+	}
+
+	createHandler := kithttp.NewServer(
+		NewCreateEndpoint(svc),
+		decodeCreateRequest,
+		encodeResponse, opts...,
+	)
+	listHandler := kithttp.NewServer(
+		NewListEndpoint(svc),
+		decodeListRequest,
+		encodeResponse, opts...,
+	)
+	readHandler := kithttp.NewServer(
+		NewReadEndpoint(svc),
+		decodeReadRequest,
+		encodeResponse, opts...,
+	)
+	revokeHandler := kithttp.NewServer(
+		NewRevokeEndpoint(svc),
+		decodeRevokeRequest,
+		encodeResponse, opts...,
+	)
+	readSharedTripHandler := kithttp.NewServer(
+		NewReadSharedTripEndpoint(svc),
+		decodeReadSharedTripRequest,
+		encodeResponse, opts...,
+	)
+	joinHandler := kithttp.NewServer(
+		NewJoinEndpoint(svc),
+		decodeJoinRequest,
+		encodeResponse, opts...,
+	)
+
+	r.Handle("/api/v1/sharelinks", createHandler).Methods(http.MethodPost)
+	r.Handle("/api/v1/sharelinks", listHandler).Methods(http.MethodGet)
+	r.Handle("/api/v1/sharelinks/tokens/{token}/trip", readSharedTripHandler).Methods(http.MethodPost)
+	r.Handle("/api/v1/sharelinks/tokens/{token}/join", joinHandler).Methods(http.MethodPost)
+	r.Handle("/api/v1/sharelinks/{id}", readHandler).Methods(http.MethodGet)
+	r.Handle("/api/v1/sharelinks/{id}", revokeHandler).Methods(http.MethodDelete)
+
+	return r
+}
+
+func decodeCreateRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	req := CreateRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, common.ErrInvalidJSONBody
+	}
+	return req, nil
+}
+
+func decodeListRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return ListRequest{TripID: r.URL.Query().Get("tripID")}, nil
+}
+
+func decodeReadRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	ID, ok := vars[URLPathVarID]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	return ReadRequest{ID}, nil
+}
+
+func decodeRevokeRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	ID, ok := vars[URLPathVarID]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	return RevokeRequest{ID}, nil
+}
+
+// The password is sent in the body, rather than in the URL,
+// so that it does not end up in the logs.
+func decodeReadSharedTripRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	token, ok := vars[URLPathVarToken]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	req := ReadSharedTripRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, common.ErrInvalidJSONBody
+	}
+	req.Token = token
+	return req, nil
+}
+
+func decodeJoinRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	token, ok := vars[URLPathVarToken]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	ci, err := reqctx.ClientInfoFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ci.UserID == "" {
+		return nil, ErrRBAC
+	}
+	req := JoinRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, common.ErrInvalidJSONBody
+	}
+	req.Token = token
+	req.UserID = ci.UserID
+	return req, nil
+}
@@ -27,3 +27,20 @@ func FollowRequestInfoFromCtx(ctx context.Context) (FollowRequestInfo, error) {
 	fi, _ := val.(FollowRequestInfo)
 	return fi, nil
 }
+
+type TripTemplateInfo struct {
+	Tmpl TripTemplate
+}
+
+func ContextWithTripTemplateInfo(ctx context.Context, tmpl TripTemplate) context.Context {
+	return context.WithValue(ctx, common.ContextKeyTripTemplateInfo, TripTemplateInfo{Tmpl: tmpl})
+}
+
+func TripTemplateInfoFromCtx(ctx context.Context) (TripTemplateInfo, error) {
+	val := ctx.Value(common.ContextKeyTripTemplateInfo)
+	if val == nil {
+		return TripTemplateInfo{}, ErrNoInfoSet
+	}
+	ti, _ := val.(TripTemplateInfo)
+	return ti, nil
+}
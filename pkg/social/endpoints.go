@@ -313,3 +313,132 @@ func NewDuplicateTripEndpoint(svc Service) endpoint.Endpoint {
 		return DuplicateResponse{ID: id, Err: err}, nil
 	}
 }
+
+type CreateTripTemplateRequest struct {
+	CreatorID   string `json:"creatorID"`
+	TripID      string `json:"tripID"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Visibility  string `json:"visibility"`
+}
+
+type TripTemplateResponse struct {
+	Template TripTemplate `json:"template"`
+	Err      error        `json:"error,omitempty"`
+}
+
+func (r TripTemplateResponse) Error() error {
+	return r.Err
+}
+
+func NewCreateTripTemplateEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(CreateTripTemplateRequest)
+		if !ok {
+			return TripTemplateResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		tmpl, err := svc.CreateTripTemplate(
+			ctx, req.CreatorID, req.TripID, req.Name, req.Description, req.Visibility,
+		)
+		return TripTemplateResponse{Template: tmpl, Err: err}, nil
+	}
+}
+
+type ReadTripTemplateRequest struct {
+	ID string `json:"id"`
+}
+
+func NewReadTripTemplateEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(ReadTripTemplateRequest)
+		if !ok {
+			return TripTemplateResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		tmpl, err := svc.ReadTripTemplate(ctx, req.ID)
+		return TripTemplateResponse{Template: tmpl, Err: err}, nil
+	}
+}
+
+type ListTripTemplatesRequest struct {
+	ListTripTemplatesFilter
+}
+
+type ListTripTemplatesResponse struct {
+	Templates TripTemplatesList `json:"templates"`
+	Err       error             `json:"error,omitempty"`
+}
+
+func (r ListTripTemplatesResponse) Error() error {
+	return r.Err
+}
+
+func NewListTripTemplatesEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(ListTripTemplatesRequest)
+		if !ok {
+			return ListTripTemplatesResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		templates, err := svc.ListTripTemplates(ctx, req.ListTripTemplatesFilter)
+		return ListTripTemplatesResponse{Templates: templates, Err: err}, nil
+	}
+}
+
+type UpdateTripTemplateRequest struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Visibility  string `json:"visibility"`
+}
+
+func NewUpdateTripTemplateEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(UpdateTripTemplateRequest)
+		if !ok {
+			return TripTemplateResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		tmpl, err := svc.UpdateTripTemplate(ctx, req.ID, req.Name, req.Description, req.Visibility)
+		return TripTemplateResponse{Template: tmpl, Err: err}, nil
+	}
+}
+
+type DeleteTripTemplateRequest struct {
+	ID string `json:"id"`
+}
+
+type DeleteTripTemplateResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r DeleteTripTemplateResponse) Error() error {
+	return r.Err
+}
+
+func NewDeleteTripTemplateEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(DeleteTripTemplateRequest)
+		if !ok {
+			return DeleteTripTemplateResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		err := svc.DeleteTripTemplate(ctx, req.ID)
+		return DeleteTripTemplateResponse{Err: err}, nil
+	}
+}
+
+type InstantiateTripTemplateRequest struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	StartDate time.Time `json:"startDate"`
+}
+
+type InstantiateTripTemplateResponse = DuplicateResponse
+
+func NewInstantiateTripTemplateEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(InstantiateTripTemplateRequest)
+		if !ok {
+			return InstantiateTripTemplateResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		id, err := svc.InstantiateTripTemplate(ctx, "", req.ID, req.Name, req.StartDate)
+		return InstantiateTripTemplateResponse{ID: id, Err: err}, nil
+	}
+}
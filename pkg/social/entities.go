@@ -103,31 +103,50 @@ func (l FollowingsList) GetInitiatorIDs() []string {
 	return ids
 }
 
+// MakeTripPublicInfo strips the trip down to what the public may see.
+// Times are removed and the itineraries are keyed by day number, the
+// rest is redacted according to the trip's privacy policy.
 func MakeTripPublicInfo(trip *trips.Trip) *trips.Trip {
+	policy := trip.Privacy
+
 	newTrip := trips.NewTrip(trip.Creator, trip.Name)
 	newTrip.ID = trip.ID
-	newTrip.CoverImage = trip.CoverImage
-	newTrip.Lodgings = trip.Lodgings
-	for key, lod := range newTrip.Lodgings {
-		lod.CheckinTime = time.Time{}
-		lod.CheckoutTime = time.Time{}
-		newTrip.Lodgings[key] = lod
+	newTrip.Privacy = policy
+	if policy.IsCoverImageVisible(*trip.CoverImage) {
+		newTrip.CoverImage = trip.CoverImage
+	}
+	if !policy.HideLodgings {
+		for key, l := range trip.Lodgings {
+			lod := *l
+			lod.CheckinTime = time.Time{}
+			lod.CheckoutTime = time.Time{}
+			lod.ConfirmationID = ""
+			lod.Place = policy.RedactPlace(lod.Place)
+			if policy.HideNotes {
+				lod.Notes = ""
+			}
+			newTrip.Lodgings[key] = &lod
+		}
 	}
-	newTrip.MediaItems = trip.MediaItems
+	newTrip.MediaItems = policy.FilterMediaItems(trip.MediaItems)
 
-	newTrip.Itineraries = trips.ItineraryMap{}
 	sortedItinKey := trips.GetSortedItineraryKeys(trip)
 	for idx, key := range sortedItinKey {
-		itin := trip.Itineraries[key]
+		itin := *trip.Itineraries[key]
 		itin.Date = time.Time{}
 		newActivities := trips.ActivityMap{}
-		for aKey, act := range trip.Itineraries[key].Activities {
+		for aKey, a := range trip.Itineraries[key].Activities {
+			act := *a
 			act.StartTime = time.Time{}
 			act.EndTime = time.Time{}
-			newActivities[aKey] = act
+			act.Place = policy.RedactPlace(act.Place)
+			if policy.HideNotes {
+				act.Notes = ""
+			}
+			newActivities[aKey] = &act
 		}
 		itin.Activities = newActivities
-		newTrip.Itineraries[fmt.Sprintf("%d", idx)] = itin
+		newTrip.Itineraries[fmt.Sprintf("%d", idx)] = &itin
 	}
 	if _, ok := trip.Labels[trips.LabelSharingAccess]; ok {
 		newTrip.Labels[trips.LabelSharingAccess] = trip.Labels[trips.LabelSharingAccess]
@@ -144,6 +163,9 @@ func MakeTripPublicInfoWithUserProfiles(
 	if _, ok := profiles[trip.Creator.ID]; ok {
 		newTrip.Members[trip.Creator.ID] = &trips.Member{}
 	}
+	if trip.Privacy.HideMembers {
+		return newTrip
+	}
 	for key := range trip.Members {
 		if _, ok := profiles[key]; ok {
 			newTrip.Members[key] = &trips.Member{}
@@ -278,3 +278,114 @@ func (mw rbacMiddleware) DuplicateTrip(
 
 	return "", ErrTripSharingNotEnabled
 }
+
+// canViewTripTemplate allows the creator, anyone for a public template
+// and the creator's followers for a template published to them.
+func (mw rbacMiddleware) canViewTripTemplate(ctx context.Context, userID string, tmpl TripTemplate) (bool, error) {
+	if tmpl.Visibility == TripTemplateVisibilityPublic || tmpl.CreatorID == userID {
+		return true, nil
+	}
+	if tmpl.Visibility != TripTemplateVisibilityFollowers {
+		return false, nil
+	}
+	return mw.next.IsFollowing(ctx, userID, tmpl.CreatorID)
+}
+
+func (mw rbacMiddleware) readViewableTripTemplate(ctx context.Context, ID string) (TripTemplate, string, error) {
+	ci, err := reqctx.ClientInfoFromCtx(ctx)
+	if err != nil || ci.HasEmptyID() {
+		return TripTemplate{}, "", ErrRBAC
+	}
+	tmpl, err := mw.next.ReadTripTemplate(ctx, ID)
+	if err != nil {
+		return TripTemplate{}, "", err
+	}
+	ok, err := mw.canViewTripTemplate(ctx, ci.UserID, tmpl)
+	if err != nil {
+		return TripTemplate{}, "", err
+	}
+	if !ok {
+		return TripTemplate{}, "", ErrRBAC
+	}
+	return tmpl, ci.UserID, nil
+}
+
+func (mw rbacMiddleware) CreateTripTemplate(
+	ctx context.Context,
+	creatorID,
+	tripID,
+	name,
+	description,
+	visibility string,
+) (TripTemplate, error) {
+	ci, err := reqctx.ClientInfoFromCtx(ctx)
+	if err != nil || ci.HasEmptyID() || ci.UserID != creatorID {
+		return TripTemplate{}, ErrRBAC
+	}
+	trip, err := mw.tripSvc.Read(ctx, tripID)
+	if err != nil {
+		return TripTemplate{}, err
+	}
+	if !trip.IsSharingEnabled() && !common.StringContains(trip.GetMemberIDs(), ci.UserID) {
+		return TripTemplate{}, ErrTripSharingNotEnabled
+	}
+	return mw.next.CreateTripTemplate(
+		trips.ContextWithTripInfo(ctx, trip),
+		creatorID, tripID, name, description, visibility,
+	)
+}
+
+func (mw rbacMiddleware) ReadTripTemplate(ctx context.Context, ID string) (TripTemplate, error) {
+	tmpl, _, err := mw.readViewableTripTemplate(ctx, ID)
+	return tmpl, err
+}
+
+func (mw rbacMiddleware) ListTripTemplates(ctx context.Context, ff ListTripTemplatesFilter) (TripTemplatesList, error) {
+	ci, err := reqctx.ClientInfoFromCtx(ctx)
+	if err != nil || ci.HasEmptyID() {
+		return nil, ErrRBAC
+	}
+	followings, err := mw.next.ListFollowing(ctx, ci.UserID)
+	if err != nil {
+		return nil, err
+	}
+	ff.ViewerID = common.StringPtr(ci.UserID)
+	ff.FollowingIDs = followings.GetTargetIDs()
+	return mw.next.ListTripTemplates(ctx, ff)
+}
+
+func (mw rbacMiddleware) UpdateTripTemplate(ctx context.Context, ID, name, description, visibility string) (TripTemplate, error) {
+	tmpl, userID, err := mw.readViewableTripTemplate(ctx, ID)
+	if err != nil {
+		return TripTemplate{}, err
+	}
+	if tmpl.CreatorID != userID {
+		return TripTemplate{}, ErrRBAC
+	}
+	return mw.next.UpdateTripTemplate(ContextWithTripTemplateInfo(ctx, tmpl), ID, name, description, visibility)
+}
+
+func (mw rbacMiddleware) DeleteTripTemplate(ctx context.Context, ID string) error {
+	tmpl, userID, err := mw.readViewableTripTemplate(ctx, ID)
+	if err != nil {
+		return err
+	}
+	if tmpl.CreatorID != userID {
+		return ErrRBAC
+	}
+	return mw.next.DeleteTripTemplate(ctx, ID)
+}
+
+func (mw rbacMiddleware) InstantiateTripTemplate(
+	ctx context.Context,
+	initiatorID,
+	ID,
+	name string,
+	startDate time.Time,
+) (string, error) {
+	tmpl, userID, err := mw.readViewableTripTemplate(ctx, ID)
+	if err != nil {
+		return "", err
+	}
+	return mw.next.InstantiateTripTemplate(ContextWithTripTemplateInfo(ctx, tmpl), userID, ID, name, startDate)
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/travelreys/travelreys/pkg/common"
 	"github.com/travelreys/travelreys/pkg/email"
 	"github.com/travelreys/travelreys/pkg/images"
+	"github.com/travelreys/travelreys/pkg/notifications"
 	"github.com/travelreys/travelreys/pkg/trips"
 	"go.uber.org/zap"
 )
@@ -50,13 +51,21 @@ type Service interface {
 	ListFollowingTrips(ctx context.Context, initiatorID string) (trips.TripsList, UserProfileMap, error)
 
 	DuplicateTrip(ctx context.Context, initiatorID, referrerID, tripID, name string, startDate time.Time) (string, error)
+
+	CreateTripTemplate(ctx context.Context, creatorID, tripID, name, description, visibility string) (TripTemplate, error)
+	ReadTripTemplate(ctx context.Context, ID string) (TripTemplate, error)
+	ListTripTemplates(ctx context.Context, ff ListTripTemplatesFilter) (TripTemplatesList, error)
+	UpdateTripTemplate(ctx context.Context, ID, name, description, visibility string) (TripTemplate, error)
+	DeleteTripTemplate(ctx context.Context, ID string) error
+	InstantiateTripTemplate(ctx context.Context, initiatorID, ID, name string, startDate time.Time) (string, error)
 }
 
 type service struct {
-	store   Store
-	authSvc auth.Service
-	tripSvc trips.Service
-	mailSvc email.Service
+	store    Store
+	authSvc  auth.Service
+	tripSvc  trips.Service
+	mailSvc  email.Service
+	notifSvc notifications.Service
 
 	logger *zap.Logger
 }
@@ -66,9 +75,10 @@ func NewService(
 	authSvc auth.Service,
 	tripSvc trips.Service,
 	mailSvc email.Service,
+	notifSvc notifications.Service,
 	logger *zap.Logger,
 ) Service {
-	return &service{store, authSvc, tripSvc, mailSvc, logger}
+	return &service{store, authSvc, tripSvc, mailSvc, notifSvc, logger}
 }
 
 func (svc *service) tripFromContext(ctx context.Context, ID string) (*trips.Trip, error) {
@@ -152,6 +162,11 @@ func (svc service) SendFollowRequest(ctx context.Context, initiatorID, targetID
 	if err := svc.store.UpsertFollowRequest(ctx, req); err != nil {
 		return err
 	}
+	n := notifications.NewNotification(targetID, notifications.TypeFollowRequest, initiatorID)
+	n.Labels[notifications.LabelFollowRequestID] = req.ID
+	if err := svc.notifSvc.Notify(ctx, notifications.NotificationsList{n}); err != nil {
+		svc.logger.Error("SendFollowRequest", zap.Error(err))
+	}
 	go svc.sendFollowRequestEmail(
 		context.Background(), initiator, target, req,
 	)
@@ -432,6 +447,116 @@ func (svc *service) DuplicateTrip(
 	return newTrip.ID, svc.tripSvc.Save(ctx, newTrip)
 }
 
+func (svc *service) tripTemplateFromContext(ctx context.Context, ID string) (TripTemplate, error) {
+	ti, err := TripTemplateInfoFromCtx(ctx)
+	if err == nil {
+		return ti.Tmpl, nil
+	}
+	return svc.store.ReadTripTemplate(ctx, ID)
+}
+
+func (svc *service) CreateTripTemplate(
+	ctx context.Context,
+	creatorID,
+	tripID,
+	name,
+	description,
+	visibility string,
+) (TripTemplate, error) {
+	if !IsValidTripTemplateVisibility(visibility) {
+		return TripTemplate{}, ErrInvalidTripTemplateVisibility
+	}
+	trip, err := svc.tripFromContext(ctx, tripID)
+	if err != nil {
+		return TripTemplate{}, err
+	}
+	if trip.StartDate.IsZero() || trip.EndDate.IsZero() {
+		return TripTemplate{}, common.ErrValidation
+	}
+	if name == "" {
+		name = trip.Name
+	}
+	if !common.StringContains(trip.GetMemberIDs(), creatorID) {
+		trip = redactTripForTemplate(trip)
+	}
+	tmpl := NewTripTemplate(creatorID, name, description, visibility, trip)
+	return tmpl, svc.store.SaveTripTemplate(ctx, tmpl)
+}
+
+func (svc *service) ReadTripTemplate(ctx context.Context, ID string) (TripTemplate, error) {
+	tmpl, err := svc.tripTemplateFromContext(ctx, ID)
+	if err != nil {
+		return TripTemplate{}, err
+	}
+	if profile, err := svc.GetProfile(ctx, tmpl.CreatorID); err == nil {
+		tmpl.CreatorProfile = profile
+	}
+	return tmpl, nil
+}
+
+func (svc *service) ListTripTemplates(ctx context.Context, ff ListTripTemplatesFilter) (TripTemplatesList, error) {
+	list, err := svc.store.ListTripTemplates(ctx, ff)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return list, nil
+	}
+
+	users, err := svc.authSvc.List(ctx, auth.ListFilter{IDs: list.GetCreatorIDs()})
+	if err != nil {
+		return nil, err
+	}
+	profiles := UserProfileMap{}
+	for _, usr := range users {
+		profiles[usr.ID] = UserProfileFromUser(usr)
+	}
+	for i := 0; i < len(list); i++ {
+		list[i].CreatorProfile = profiles[list[i].CreatorID]
+	}
+	return list, nil
+}
+
+func (svc *service) UpdateTripTemplate(ctx context.Context, ID, name, description, visibility string) (TripTemplate, error) {
+	if !IsValidTripTemplateVisibility(visibility) || name == "" {
+		return TripTemplate{}, common.ErrValidation
+	}
+	tmpl, err := svc.tripTemplateFromContext(ctx, ID)
+	if err != nil {
+		return TripTemplate{}, err
+	}
+	tmpl.Name = name
+	tmpl.Description = description
+	tmpl.Visibility = visibility
+	tmpl.UpdatedAt = time.Now()
+	return tmpl, svc.store.SaveTripTemplate(ctx, tmpl)
+}
+
+func (svc *service) DeleteTripTemplate(ctx context.Context, ID string) error {
+	return svc.store.DeleteTripTemplate(ctx, ID)
+}
+
+func (svc *service) InstantiateTripTemplate(
+	ctx context.Context,
+	initiatorID,
+	ID,
+	name string,
+	startDate time.Time,
+) (string, error) {
+	if startDate.IsZero() {
+		return "", common.ErrValidation
+	}
+	tmpl, err := svc.tripTemplateFromContext(ctx, ID)
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		name = tmpl.Name
+	}
+	trip := tmpl.ToTrip(initiatorID, name, startDate)
+	return trip.ID, svc.tripSvc.Save(ctx, trip)
+}
+
 func (svc service) sendFollowRequestEmail(
 	ctx context.Context,
 	initiator,
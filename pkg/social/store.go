@@ -20,13 +20,15 @@ const (
 	bsonKeyInitiatorID   = "initiatorID"
 	bsonKeyTargetID      = "targetID"
 
-	friendsColl     = "friends"
-	friendsReqColl  = "friend_requests"
-	storeLoggerName = "social.store"
+	friendsColl       = "friends"
+	friendsReqColl    = "friend_requests"
+	tripTemplatesColl = "trip_templates"
+	storeLoggerName   = "social.store"
 )
 
 var (
 	ErrFollowingNotFound    = errors.New("social.ErrFollowingNotFound")
+	ErrTripTemplateNotFound = errors.New("social.ErrTripTemplateNotFound")
 	ErrUnexpectedStoreError = errors.New("social.ErrUnexpectedStoreError")
 )
 
@@ -57,12 +59,18 @@ type Store interface {
 	ListFollowers(ctx context.Context, userID string) (FollowingsList, error)
 	ListFollowing(ctx context.Context, userID string) (FollowingsList, error)
 	DeleteFollowing(ctx context.Context, bindingKey string) error
+
+	SaveTripTemplate(ctx context.Context, tmpl TripTemplate) error
+	ReadTripTemplate(ctx context.Context, id string) (TripTemplate, error)
+	ListTripTemplates(ctx context.Context, ff ListTripTemplatesFilter) (TripTemplatesList, error)
+	DeleteTripTemplate(ctx context.Context, id string) error
 }
 
 type store struct {
 	db             *mongo.Database
 	friendsColl    *mongo.Collection
 	friendReqsColl *mongo.Collection
+	templatesColl  *mongo.Collection
 
 	logger *zap.Logger
 }
@@ -86,7 +94,15 @@ func NewStore(ctx context.Context, db *mongo.Database, logger *zap.Logger) Store
 		{Keys: bson.M{bsonKeyBindingKey: 1}},
 	})
 
-	return &store{db, friendsColl, friendReqColl, logger}
+	templatesColl := db.Collection(tripTemplatesColl)
+	templatesColl.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.M{bsonKeyID: 1}},
+		{Keys: bson.M{"creatorID": 1}},
+		{Keys: bson.M{"countries": 1}},
+		{Keys: bson.M{"cities": 1}},
+	})
+
+	return &store{db, friendsColl, friendReqColl, templatesColl, logger}
 }
 
 func (store *store) UpsertFollowRequest(ctx context.Context, freq FollowRequest) error {
@@ -204,3 +220,50 @@ func (store *store) DeleteFollowing(ctx context.Context, bindingKey string) erro
 	}
 	return nil
 }
+
+func (store *store) SaveTripTemplate(ctx context.Context, tmpl TripTemplate) error {
+	opts := options.Replace().SetUpsert(true)
+	_, err := store.templatesColl.ReplaceOne(ctx, bson.M{bsonKeyID: tmpl.ID}, tmpl, opts)
+	if err != nil {
+		store.logger.Error("SaveTripTemplate", zap.Error(err))
+		return ErrUnexpectedStoreError
+	}
+	return nil
+}
+
+func (store *store) ReadTripTemplate(ctx context.Context, id string) (TripTemplate, error) {
+	var tmpl TripTemplate
+	err := store.templatesColl.FindOne(ctx, bson.M{bsonKeyID: id}).Decode(&tmpl)
+	if err == mongo.ErrNoDocuments {
+		return TripTemplate{}, ErrTripTemplateNotFound
+	}
+	if err != nil {
+		store.logger.Error("ReadTripTemplate", zap.String("id", id), zap.Error(err))
+		return TripTemplate{}, ErrUnexpectedStoreError
+	}
+	return tmpl, nil
+}
+
+func (store *store) ListTripTemplates(ctx context.Context, ff ListTripTemplatesFilter) (TripTemplatesList, error) {
+	list := TripTemplatesList{}
+	opts := options.Find().SetSort(bson.M{"createdAt": -1})
+	cursor, err := store.templatesColl.Find(ctx, ff.toBSON(), opts)
+	if err != nil {
+		store.logger.Error("ListTripTemplates", zap.String("ff", common.FmtString(ff)), zap.Error(err))
+		return list, ErrUnexpectedStoreError
+	}
+	if err := cursor.All(ctx, &list); err != nil {
+		store.logger.Error("ListTripTemplates", zap.String("ff", common.FmtString(ff)), zap.Error(err))
+		return list, ErrUnexpectedStoreError
+	}
+	return list, nil
+}
+
+func (store *store) DeleteTripTemplate(ctx context.Context, id string) error {
+	_, err := store.templatesColl.DeleteOne(ctx, bson.M{bsonKeyID: id})
+	if err != nil {
+		store.logger.Error("DeleteTripTemplate", zap.String("id", id), zap.Error(err))
+		return ErrUnexpectedStoreError
+	}
+	return nil
+}
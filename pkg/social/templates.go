@@ -0,0 +1,392 @@
+package social
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/travelreys/travelreys/pkg/common"
+	"github.com/travelreys/travelreys/pkg/finance"
+	"github.com/travelreys/travelreys/pkg/maps"
+	"github.com/travelreys/travelreys/pkg/trips"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	TripTemplateVisibilityPublic    = "public"
+	TripTemplateVisibilityFollowers = "followers"
+
+	maxTripTemplateNameLength = 200
+)
+
+var (
+	ErrInvalidTripTemplateVisibility = errors.New("social.ErrInvalidTripTemplateVisibility")
+)
+
+func IsValidTripTemplateVisibility(visibility string) bool {
+	return visibility == TripTemplateVisibilityPublic ||
+		visibility == TripTemplateVisibilityFollowers
+}
+
+// TripTemplateActivity is an activity of a template day. Its times are
+// offsets in minutes from the start of the day.
+type TripTemplateActivity struct {
+	ID              string            `json:"id" bson:"id"`
+	Title           string            `json:"title" bson:"title"`
+	Place           maps.Place        `json:"place" bson:"place"`
+	Notes           string            `json:"notes" bson:"notes"`
+	PriceItem       finance.PriceItem `json:"price" bson:"price"`
+	StartOffsetMins *int              `json:"startOffsetMins,omitempty" bson:"startOffsetMins,omitempty"`
+	EndOffsetMins   *int              `json:"endOffsetMins,omitempty" bson:"endOffsetMins,omitempty"`
+	FracIndex       string            `json:"fIndex" bson:"fIndex"`
+}
+
+// TripTemplateDay is the itinerary of the Day-th day of the trip,
+// counting from 0.
+type TripTemplateDay struct {
+	Day         int                    `json:"day" bson:"day"`
+	Description string                 `json:"desc" bson:"desc"`
+	Activities  []TripTemplateActivity `json:"activities" bson:"activities"`
+	Routes      trips.RouteMap         `json:"routes" bson:"routes"`
+	Labels      common.Labels          `json:"labels" bson:"labels"`
+}
+
+// TripTemplateLodging is a lodging of the template, from the night of
+// CheckinDay to the morning of CheckoutDay.
+type TripTemplateLodging struct {
+	CheckinDay  int               `json:"checkinDay" bson:"checkinDay"`
+	CheckoutDay int               `json:"checkoutDay" bson:"checkoutDay"`
+	Place       maps.Place        `json:"place" bson:"place"`
+	Notes       string            `json:"notes" bson:"notes"`
+	PriceItem   finance.PriceItem `json:"price" bson:"price"`
+}
+
+// TripTemplate is a trip plan with day numbers instead of dates, which
+// users instantiate into their own trips.
+type TripTemplate struct {
+	ID           string `json:"id" bson:"id"`
+	CreatorID    string `json:"creatorID" bson:"creatorID"`
+	SourceTripID string `json:"sourceTripID" bson:"sourceTripID"`
+
+	Name        string            `json:"name" bson:"name"`
+	Description string            `json:"description" bson:"description"`
+	CoverImage  *trips.CoverImage `json:"coverImage" bson:"coverImage"`
+	NumDays     int               `json:"numDays" bson:"numDays"`
+
+	Lodgings []TripTemplateLodging `json:"lodgings" bson:"lodgings"`
+	Days     []TripTemplateDay     `json:"days" bson:"days"`
+
+	// Countries and Cities are the destinations of the template,
+	// from the labels of its places.
+	Countries []string `json:"countries" bson:"countries"`
+	Cities    []string `json:"cities" bson:"cities"`
+
+	Visibility string `json:"visibility" bson:"visibility"`
+
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+
+	CreatorProfile UserProfile `json:"creatorProfile" bson:"-"`
+}
+
+type TripTemplatesList []TripTemplate
+
+func (l TripTemplatesList) GetCreatorIDs() []string {
+	ids := []string{}
+	for _, tmpl := range l {
+		ids = append(ids, tmpl.CreatorID)
+	}
+	return ids
+}
+
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
+
+func offsetMins(dayStart, t time.Time) *int {
+	if t.IsZero() {
+		return nil
+	}
+	mins := int(t.Sub(dayStart).Minutes())
+	return &mins
+}
+
+// NewTripTemplate makes a template of the trip's lodgings and itineraries,
+// replacing their dates with day numbers from the trip's start date.
+func NewTripTemplate(creatorID, name, description, visibility string, trip *trips.Trip) TripTemplate {
+	tmpl := TripTemplate{
+		ID:           uuid.NewString(),
+		CreatorID:    creatorID,
+		SourceTripID: trip.ID,
+		Name:         name,
+		Description:  description,
+		CoverImage:   trip.CoverImage,
+		NumDays:      daysBetween(trip.StartDate, trip.EndDate) + 1,
+		Lodgings:     []TripTemplateLodging{},
+		Days:         []TripTemplateDay{},
+		Visibility:   visibility,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+
+	// Only the prices are kept, the payers, splits and receipts
+	// belong to the members of the source trip.
+	places := []maps.Place{}
+	for _, lodging := range trip.Lodgings {
+		tmpl.Lodgings = append(tmpl.Lodgings, TripTemplateLodging{
+			CheckinDay:  daysBetween(trip.StartDate, lodging.CheckinTime),
+			CheckoutDay: daysBetween(trip.StartDate, lodging.CheckoutTime),
+			Place:       lodging.Place,
+			Notes:       lodging.Notes,
+			PriceItem:   finance.PriceItem{Price: lodging.PriceItem.Price},
+		})
+		places = append(places, lodging.Place)
+	}
+	sort.SliceStable(tmpl.Lodgings, func(i, j int) bool {
+		return tmpl.Lodgings[i].CheckinDay < tmpl.Lodgings[j].CheckinDay
+	})
+
+	for _, key := range trips.GetSortedItineraryKeys(trip) {
+		itin := trip.Itineraries[key]
+		day := TripTemplateDay{
+			Day:         daysBetween(trip.StartDate, itin.Date),
+			Description: itin.Description,
+			Activities:  []TripTemplateActivity{},
+			Routes:      itin.Routes,
+			Labels:      common.Labels{trips.LabelUiColor: itin.Labels[trips.LabelUiColor]},
+		}
+		for _, act := range itin.SortActivities() {
+			day.Activities = append(day.Activities, TripTemplateActivity{
+				ID:              act.ID,
+				Title:           act.Title,
+				Place:           act.Place,
+				Notes:           act.Notes,
+				PriceItem:       finance.PriceItem{Price: act.PriceItem.Price},
+				StartOffsetMins: offsetMins(itin.Date, act.StartTime),
+				EndOffsetMins:   offsetMins(itin.Date, act.EndTime),
+				FracIndex:       act.Labels[trips.LabelFractionalIndex],
+			})
+			places = append(places, act.Place)
+		}
+		tmpl.Days = append(tmpl.Days, day)
+	}
+
+	tmpl.Countries, tmpl.Cities = destinations(places)
+	return tmpl
+}
+
+// redactTripForTemplate applies the trip's privacy policy to what
+// templates copy from it, for creators who are not members of the trip
+// and may only see its public view.
+func redactTripForTemplate(trip *trips.Trip) *trips.Trip {
+	policy := trip.Privacy
+	newTrip := *trip
+	if trip.CoverImage != nil && !policy.IsCoverImageVisible(*trip.CoverImage) {
+		newTrip.CoverImage = nil
+	}
+
+	newTrip.Lodgings = trips.LodgingsMap{}
+	if !policy.HideLodgings {
+		for key, l := range trip.Lodgings {
+			lod := *l
+			// The days are kept for the template, only the times are hidden
+			lod.CheckinTime = startOfDay(lod.CheckinTime)
+			lod.CheckoutTime = startOfDay(lod.CheckoutTime)
+			lod.ConfirmationID = ""
+			lod.Place = policy.RedactPlace(lod.Place)
+			if policy.HideNotes {
+				lod.Notes = ""
+			}
+			newTrip.Lodgings[key] = &lod
+		}
+	}
+
+	newTrip.Itineraries = trips.ItineraryMap{}
+	for key, it := range trip.Itineraries {
+		itin := *it
+		// Routes would give away the exact locations
+		if policy.FuzzLocations {
+			itin.Routes = trips.RouteMap{}
+		}
+		itin.Activities = trips.ActivityMap{}
+		for aKey, a := range it.Activities {
+			act := *a
+			act.StartTime = time.Time{}
+			act.EndTime = time.Time{}
+			act.Place = policy.RedactPlace(act.Place)
+			if policy.HideNotes {
+				act.Notes = ""
+			}
+			itin.Activities[aKey] = &act
+		}
+		newTrip.Itineraries[key] = &itin
+	}
+	return &newTrip
+}
+
+func startOfDay(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func destinations(places []maps.Place) ([]string, []string) {
+	countries, cities := []string{}, []string{}
+	for _, place := range places {
+		if country := place.Labels[maps.LabelCountry]; country != "" && !common.StringContains(countries, country) {
+			countries = append(countries, country)
+		}
+		if city := place.Labels[maps.LabelCity]; city != "" && !common.StringContains(cities, city) {
+			cities = append(cities, city)
+		}
+	}
+	sort.Strings(countries)
+	sort.Strings(cities)
+	return countries, cities
+}
+
+// ToTrip makes a new trip for the creator out of the template,
+// starting at the given date.
+func (tmpl TripTemplate) ToTrip(creatorID, name string, startDate time.Time) *trips.Trip {
+	atDay := func(day int) time.Time {
+		return startDate.Add(time.Duration(day*24) * time.Hour)
+	}
+	atOffset := func(dayStart time.Time, mins *int) time.Time {
+		if mins == nil {
+			return time.Time{}
+		}
+		return dayStart.Add(time.Duration(*mins) * time.Minute)
+	}
+
+	creator := trips.NewMember(creatorID, trips.MemberRoleCreator)
+	trip := trips.NewTripWithDates(creator, name, startDate, atDay(tmpl.NumDays-1))
+	if tmpl.CoverImage != nil {
+		trip.CoverImage = tmpl.CoverImage
+	}
+
+	for _, lodging := range tmpl.Lodgings {
+		newLodging := &trips.Lodging{
+			ID:           uuid.NewString(),
+			CheckinTime:  atDay(lodging.CheckinDay),
+			CheckoutTime: atDay(lodging.CheckoutDay),
+			PriceItem:    finance.PriceItem{Price: lodging.PriceItem.Price},
+			Notes:        lodging.Notes,
+			Place:        lodging.Place,
+			Labels:       common.Labels{trips.LabelCreatedBy: creatorID},
+		}
+		trip.Lodgings[newLodging.ID] = newLodging
+	}
+
+	for i := 0; i < tmpl.NumDays; i++ {
+		itin := trips.NewItinerary(atDay(i))
+		trip.Itineraries[itin.Date.Format(trips.ItineraryDtKeyFormat)] = itin
+	}
+	for _, day := range tmpl.Days {
+		itin, ok := trip.Itineraries[atDay(day.Day).Format(trips.ItineraryDtKeyFormat)]
+		if !ok {
+			continue
+		}
+		itin.Description = day.Description
+		if color := day.Labels[trips.LabelUiColor]; color != "" {
+			itin.Labels[trips.LabelUiColor] = color
+		}
+
+		actIDMap := map[string]string{}
+		for _, act := range day.Activities {
+			newAct := &trips.Activity{
+				ID:        uuid.NewString(),
+				Title:     act.Title,
+				Place:     act.Place,
+				Notes:     act.Notes,
+				PriceItem: finance.PriceItem{Price: act.PriceItem.Price},
+				StartTime: atOffset(itin.Date, act.StartOffsetMins),
+				EndTime:   atOffset(itin.Date, act.EndOffsetMins),
+				Labels: common.Labels{
+					trips.LabelCreatedBy:       creatorID,
+					trips.LabelFractionalIndex: act.FracIndex,
+				},
+			}
+			itin.Activities[newAct.ID] = newAct
+			actIDMap[act.ID] = newAct.ID
+		}
+		for rKey, route := range day.Routes {
+			tkns := strings.Split(rKey, "|")
+			if len(tkns) != 2 || actIDMap[tkns[0]] == "" || actIDMap[tkns[1]] == "" {
+				continue
+			}
+			itin.Routes[fmt.Sprintf("%s|%s", actIDMap[tkns[0]], actIDMap[tkns[1]])] = route
+		}
+	}
+	return trip
+}
+
+type ListTripTemplatesFilter struct {
+	CreatorID *string
+	Country   *string
+	City      *string
+	Query     *string
+
+	// ViewerID and FollowingIDs restrict the templates to the public
+	// ones, the viewer's own and those the viewer's followings
+	// published to their followers.
+	ViewerID     *string
+	FollowingIDs []string
+}
+
+func MakeListTripTemplatesFilterFromURLParams(params url.Values) ListTripTemplatesFilter {
+	ff := ListTripTemplatesFilter{}
+	if params.Get("creatorID") != "" {
+		ff.CreatorID = common.StringPtr(params.Get("creatorID"))
+	}
+	if params.Get("country") != "" {
+		ff.Country = common.StringPtr(params.Get("country"))
+	}
+	if params.Get("city") != "" {
+		ff.City = common.StringPtr(params.Get("city"))
+	}
+	if params.Get("q") != "" {
+		ff.Query = common.StringPtr(params.Get("q"))
+	}
+	return ff
+}
+
+func (ff ListTripTemplatesFilter) toBSON() bson.M {
+	bsonAnd := bson.A{}
+	if ff.CreatorID != nil {
+		bsonAnd = append(bsonAnd, bson.M{"creatorID": *ff.CreatorID})
+	}
+	if ff.Country != nil {
+		bsonAnd = append(bsonAnd, bson.M{"countries": caseInsensitiveMatch(*ff.Country)})
+	}
+	if ff.City != nil {
+		bsonAnd = append(bsonAnd, bson.M{"cities": caseInsensitiveMatch(*ff.City)})
+	}
+	if ff.Query != nil {
+		bsonAnd = append(bsonAnd, bson.M{"name": bson.M{
+			"$regex": regexp.QuoteMeta(*ff.Query), "$options": "i",
+		}})
+	}
+
+	bsonVisibleOr := bson.A{bson.M{"visibility": TripTemplateVisibilityPublic}}
+	if ff.ViewerID != nil {
+		bsonVisibleOr = append(bsonVisibleOr, bson.M{"creatorID": *ff.ViewerID})
+	}
+	if len(ff.FollowingIDs) > 0 {
+		bsonVisibleOr = append(bsonVisibleOr, bson.M{
+			"visibility": TripTemplateVisibilityFollowers,
+			"creatorID":  bson.M{"$in": ff.FollowingIDs},
+		})
+	}
+	bsonAnd = append(bsonAnd, bson.M{"$or": bsonVisibleOr})
+	return bson.M{"$and": bsonAnd}
+}
+
+func caseInsensitiveMatch(value string) bson.M {
+	return bson.M{"$regex": "^" + regexp.QuoteMeta(value) + "$", "$options": "i"}
+}
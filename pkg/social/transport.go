@@ -20,11 +20,13 @@ const (
 	URLPathVarTripID    = "tid"
 	URLPathVarRequestID = "rid"
 	URLPathBindingKey   = "bindingKey"
+	URLPathTemplateID   = "templateID"
 )
 
 func errToHttpCode() func(err error) int {
 	notFoundErrors := []error{
 		ErrFollowingNotFound,
+		ErrTripTemplateNotFound,
 		trips.ErrTripNotFound,
 	}
 	appErrors := []error{
@@ -47,7 +49,7 @@ func errToHttpCode() func(err error) int {
 		if common.ErrorContains(authErrors, err) {
 			return http.StatusUnauthorized
 		}
-		if errors.Is(err, common.ErrValidation) {
+		if errors.Is(err, common.ErrValidation) || errors.Is(err, ErrInvalidTripTemplateVisibility) {
 			return http.StatusBadRequest
 		}
 		return http.StatusInternalServerError
@@ -153,6 +155,45 @@ func MakeHandler(svc Service) http.Handler {
 		encodeResponse, opts...,
 	)
 
+	createTripTemplateHandler := kithttp.NewServer(
+		NewCreateTripTemplateEndpoint(svc),
+		decodeCreateTripTemplateRequest,
+		encodeResponse, opts...,
+	)
+	listTripTemplatesHandler := kithttp.NewServer(
+		NewListTripTemplatesEndpoint(svc),
+		decodeListTripTemplatesRequest,
+		encodeResponse, opts...,
+	)
+	readTripTemplateHandler := kithttp.NewServer(
+		NewReadTripTemplateEndpoint(svc),
+		decodeReadTripTemplateRequest,
+		encodeResponse, opts...,
+	)
+	updateTripTemplateHandler := kithttp.NewServer(
+		NewUpdateTripTemplateEndpoint(svc),
+		decodeUpdateTripTemplateRequest,
+		encodeResponse, opts...,
+	)
+	deleteTripTemplateHandler := kithttp.NewServer(
+		NewDeleteTripTemplateEndpoint(svc),
+		decodeDeleteTripTemplateRequest,
+		encodeResponse, opts...,
+	)
+	instantiateTripTemplateHandler := kithttp.NewServer(
+		NewInstantiateTripTemplateEndpoint(svc),
+		decodeInstantiateTripTemplateRequest,
+		encodeResponse, opts...,
+	)
+
+	// Templates are registered first so that they are not matched as a {uid}.
+	r.Handle("/api/v1/social/templates", createTripTemplateHandler).Methods(http.MethodPost)
+	r.Handle("/api/v1/social/templates", listTripTemplatesHandler).Methods(http.MethodGet)
+	r.Handle("/api/v1/social/templates/{templateID}", readTripTemplateHandler).Methods(http.MethodGet)
+	r.Handle("/api/v1/social/templates/{templateID}", updateTripTemplateHandler).Methods(http.MethodPut)
+	r.Handle("/api/v1/social/templates/{templateID}", deleteTripTemplateHandler).Methods(http.MethodDelete)
+	r.Handle("/api/v1/social/templates/{templateID}/instantiate", instantiateTripTemplateHandler).Methods(http.MethodPost)
+
 	r.Handle("/api/v1/social/{uid}", listFollowingTripsHandler).Methods(http.MethodGet)
 	r.Handle("/api/v1/social/{uid}/profile", getProfileHandler).Methods(http.MethodGet)
 
@@ -346,3 +387,62 @@ func decodeDuplicateTripRequest(_ context.Context, r *http.Request) (interface{}
 	req.ReferrerID = userID
 	return req, nil
 }
+
+func decodeCreateTripTemplateRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	req := CreateTripTemplateRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, common.ErrInvalidJSONBody
+	}
+	return req, nil
+}
+
+func decodeListTripTemplatesRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	ff := MakeListTripTemplatesFilterFromURLParams(r.URL.Query())
+	return ListTripTemplatesRequest{ff}, nil
+}
+
+func decodeReadTripTemplateRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	ID, ok := vars[URLPathTemplateID]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	return ReadTripTemplateRequest{ID}, nil
+}
+
+func decodeUpdateTripTemplateRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	ID, ok := vars[URLPathTemplateID]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	req := UpdateTripTemplateRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, common.ErrInvalidJSONBody
+	}
+	req.ID = ID
+	return req, nil
+}
+
+func decodeDeleteTripTemplateRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	ID, ok := vars[URLPathTemplateID]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	return DeleteTripTemplateRequest{ID}, nil
+}
+
+func decodeInstantiateTripTemplateRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	ID, ok := vars[URLPathTemplateID]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	req := InstantiateTripTemplateRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, common.ErrInvalidJSONBody
+	}
+	req.ID = ID
+	return req, nil
+}
@@ -0,0 +1,536 @@
+package trips
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/travelreys/travelreys/pkg/finance"
+	"github.com/travelreys/travelreys/pkg/maps"
+)
+
+const (
+	BookletFormatHTML = "html"
+	BookletFormatPDF  = "pdf"
+
+	BookletSectionLodgings = "lodgings"
+	BookletSectionTransits = "transits"
+	BookletSectionBudget   = "budget"
+	BookletSectionLinks    = "links"
+
+	bookletTmplFilePath = "assets/tripBooklet.tmpl.html"
+	bookletTmplFileName = "tripBooklet.tmpl.html"
+
+	bookletPDFFontFamily = "DejaVu"
+
+	// bookletPDFReplacementChar stands for the characters
+	// which the PDF fonts cannot render.
+	bookletPDFReplacementChar = '?'
+)
+
+var (
+	BookletFormatsList = []string{
+		BookletFormatHTML,
+		BookletFormatPDF,
+	}
+	BookletSectionsList = []string{
+		BookletSectionLodgings,
+		BookletSectionTransits,
+		BookletSectionBudget,
+		BookletSectionLinks,
+	}
+
+	// Brand colour, rgb(124, 58, 237)
+	bookletPDFAccent = [3]int{124, 58, 237}
+
+	// bookletPDFFontFilePaths are the UTF-8 fonts of the PDF by style.
+	bookletPDFFontFilePaths = map[string]string{
+		"":   "assets/fonts/DejaVuSansCondensed.ttf",
+		"B":  "assets/fonts/DejaVuSansCondensed-Bold.ttf",
+		"I":  "assets/fonts/DejaVuSansCondensed-Oblique.ttf",
+		"BI": "assets/fonts/DejaVuSansCondensed-BoldOblique.ttf",
+	}
+)
+
+type BookletOptions struct {
+	Format   string   `json:"format"`
+	Sections []string `json:"sections"`
+	Locale   string   `json:"locale"`
+}
+
+func (opts BookletOptions) HasSection(section string) bool {
+	for _, s := range opts.Sections {
+		if s == section {
+			return true
+		}
+	}
+	return false
+}
+
+type bookletActivity struct {
+	Time    string
+	Title   string
+	Address string
+	Notes   string
+	Price   string
+	Route   string
+}
+
+type bookletDay struct {
+	Title      string
+	Desc       string
+	Lodgings   []string
+	Activities []bookletActivity
+}
+
+type bookletLodging struct {
+	Name           string
+	Address        string
+	Checkin        string
+	Checkout       string
+	ConfirmationID string
+	Notes          string
+	Price          string
+}
+
+type bookletTransit struct {
+	Type           string
+	From           string
+	To             string
+	Depart         string
+	Arrive         string
+	ConfirmationID string
+	Notes          string
+	Price          string
+}
+
+type bookletBudgetItem struct {
+	Title string
+	Desc  string
+	Price string
+}
+
+type bookletBudget struct {
+	Total string
+	Items []bookletBudgetItem
+}
+
+type bookletLink struct {
+	Title string
+	URL   string
+	Notes string
+}
+
+// bookletDoc is the presentation model shared by the HTML and PDF renderers.
+type bookletDoc struct {
+	Name     string
+	Dates    string
+	Notes    string
+	Days     []bookletDay
+	Lodgings []bookletLodging
+	Transits []bookletTransit
+	Budget   *bookletBudget
+	Links    []bookletLink
+}
+
+// GenerateBooklet renders a printable, day-by-day booklet of the trip.
+func GenerateBooklet(trip *Trip, opts BookletOptions) (ExportFile, error) {
+	doc := makeBookletDoc(trip, opts)
+
+	var (
+		data        []byte
+		contentType string
+		err         error
+	)
+	switch opts.Format {
+	case BookletFormatHTML:
+		data, err = doc.toHTML()
+		contentType = "text/html; charset=utf-8"
+	case BookletFormatPDF:
+		data, err = doc.toPDF()
+		contentType = "application/pdf"
+	default:
+		return ExportFile{}, ErrInvalidExportFormat
+	}
+	if err != nil {
+		return ExportFile{}, err
+	}
+
+	return ExportFile{
+		Filename:    exportFilename(trip, "", opts.Format),
+		ContentType: contentType,
+		Data:        data,
+	}, nil
+}
+
+func makeBookletDoc(trip *Trip, opts BookletOptions) bookletDoc {
+	loc := lookupDateLocale(opts.Locale)
+	if opts.Format == BookletFormatPDF {
+		loc = lookupPDFDateLocale(opts.Locale)
+	}
+
+	doc := bookletDoc{
+		Name:  trip.Name,
+		Notes: trip.Notes,
+	}
+	if !trip.StartDate.IsZero() {
+		doc.Dates = loc.FormatDate(trip.StartDate)
+		if !trip.EndDate.IsZero() && !trip.EndDate.Equal(trip.StartDate) {
+			doc.Dates = fmt.Sprintf("%s – %s", doc.Dates, loc.FormatDate(trip.EndDate))
+		}
+	}
+
+	for _, dtKey := range GetSortedItineraryKeys(trip) {
+		itin := trip.Itineraries[dtKey]
+		day := bookletDay{
+			Title: loc.FormatDate(itin.GetDate()),
+			Desc:  itin.Description,
+		}
+		for _, l := range sortedLodgings(trip.Lodgings.GetLodgingsForDate(itin.GetDate())) {
+			day.Lodgings = append(day.Lodgings, l.Place.Name)
+		}
+
+		sorted := itin.SortActivities()
+		for idx, act := range sorted {
+			bAct := bookletActivity{
+				Time:    bookletTimeRange(loc, act.StartTime, act.EndTime),
+				Title:   act.Title,
+				Address: act.Place.Address,
+				Notes:   act.Notes,
+				Price:   bookletPrice(act.PriceItem),
+			}
+			if bAct.Title == "" {
+				bAct.Title = act.Place.Name
+			}
+			if idx < len(sorted)-1 {
+				key := itin.routePairingKey(act, sorted[idx+1])
+				if route, err := itin.Routes[key].GetMostCommonSenseRoute(); err == nil {
+					bAct.Route = bookletRouteSummary(route)
+				}
+			}
+			day.Activities = append(day.Activities, bAct)
+		}
+		doc.Days = append(doc.Days, day)
+	}
+
+	if opts.HasSection(BookletSectionLodgings) {
+		for _, l := range sortedLodgings(trip.Lodgings) {
+			doc.Lodgings = append(doc.Lodgings, bookletLodging{
+				Name:           l.Place.Name,
+				Address:        l.Place.Address,
+				Checkin:        loc.FormatDateTime(l.CheckinTime),
+				Checkout:       loc.FormatDateTime(l.CheckoutTime),
+				ConfirmationID: l.ConfirmationID,
+				Notes:          l.Notes,
+				Price:          bookletPrice(l.PriceItem),
+			})
+		}
+	}
+
+	if opts.HasSection(BookletSectionTransits) {
+		transits := []*BaseTransit{}
+		for _, t := range trip.Transits {
+			transits = append(transits, t)
+		}
+		sort.SliceStable(transits, func(i, j int) bool {
+			return transits[i].DepartTime.Before(transits[j].DepartTime)
+		})
+		for _, t := range transits {
+			doc.Transits = append(doc.Transits, bookletTransit{
+				Type:           t.Type,
+				From:           t.DepartLocation.Name,
+				To:             t.ArrivalLocation.Name,
+				Depart:         loc.FormatDateTime(t.DepartTime),
+				Arrive:         loc.FormatDateTime(t.ArrivalTime),
+				ConfirmationID: t.ConfirmationID,
+				Notes:          t.Notes,
+				Price:          bookletPrice(t.PriceItem),
+			})
+		}
+	}
+
+	if opts.HasSection(BookletSectionBudget) {
+		budget := &bookletBudget{
+			Total: bookletPrice(finance.PriceItem{Price: trip.Budget.Amount}),
+		}
+		for _, item := range trip.Budget.Items {
+			budget.Items = append(budget.Items, bookletBudgetItem{
+				Title: item.Title,
+				Desc:  item.Desc,
+				Price: bookletPrice(item.PriceItem),
+			})
+		}
+		doc.Budget = budget
+	}
+
+	if opts.HasSection(BookletSectionLinks) {
+		for _, l := range trip.Links {
+			title := l.OGP.Title
+			if title == "" {
+				title = l.OGP.URL
+			}
+			doc.Links = append(doc.Links, bookletLink{
+				Title: title,
+				URL:   l.OGP.URL,
+				Notes: l.Notes,
+			})
+		}
+		sort.SliceStable(doc.Links, func(i, j int) bool {
+			return doc.Links[i].Title < doc.Links[j].Title
+		})
+	}
+
+	return doc
+}
+
+func sortedLodgings(m LodgingsMap) LodgingList {
+	list := LodgingList{}
+	for _, l := range m {
+		list = append(list, l)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].CheckinTime.Before(list[j].CheckinTime)
+	})
+	return list
+}
+
+func bookletTimeRange(loc dateLocale, start, end time.Time) string {
+	if start.IsZero() {
+		return ""
+	}
+	if end.IsZero() || !end.After(start) {
+		return loc.FormatTime(start)
+	}
+	return fmt.Sprintf("%s – %s", loc.FormatTime(start), loc.FormatTime(end))
+}
+
+func bookletPrice(item finance.PriceItem) string {
+	if item.Amount == 0 {
+		return ""
+	}
+	return strings.TrimSpace(fmt.Sprintf("%.2f %s", item.Amount, item.Currency))
+}
+
+func bookletRouteSummary(route maps.Route) string {
+	parts := []string{route.TravelMode}
+	if route.Duration > 0 {
+		parts = append(parts, route.Duration.Round(time.Minute).String())
+	}
+	if route.Distance >= 1000 {
+		parts = append(parts, fmt.Sprintf("%.1f km", float64(route.Distance)/1000))
+	} else if route.Distance > 0 {
+		parts = append(parts, fmt.Sprintf("%d m", route.Distance))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// HTML
+
+func (doc bookletDoc) toHTML() ([]byte, error) {
+	t, err := template.
+		New(bookletTmplFileName).
+		ParseFiles(bookletTmplFilePath)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// PDF
+
+type bookletPDFWriter struct {
+	pdf *fpdf.Fpdf
+
+	// glyphs maps the font styles to the characters they cover.
+	glyphs map[string]map[uint16]uint16
+}
+
+// bookletPDFFont is a parsed font of the PDF booklet, loaded once.
+type bookletPDFFont struct {
+	data   []byte
+	glyphs map[uint16]uint16
+}
+
+var (
+	bookletPDFFontsOnce sync.Once
+	bookletPDFFonts     map[string]bookletPDFFont
+	bookletPDFFontsErr  error
+)
+
+func loadBookletPDFFonts() (map[string]bookletPDFFont, error) {
+	bookletPDFFontsOnce.Do(func() {
+		fonts := map[string]bookletPDFFont{}
+		for style, path := range bookletPDFFontFilePaths {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				bookletPDFFontsErr = err
+				return
+			}
+			ttf, err := fpdf.TtfParse(path)
+			if err != nil {
+				bookletPDFFontsErr = err
+				return
+			}
+			fonts[style] = bookletPDFFont{data: data, glyphs: ttf.Chars}
+		}
+		bookletPDFFonts = fonts
+	})
+	return bookletPDFFonts, bookletPDFFontsErr
+}
+
+func newBookletPDFWriter(pdf *fpdf.Fpdf) (*bookletPDFWriter, error) {
+	fonts, err := loadBookletPDFFonts()
+	if err != nil {
+		return nil, err
+	}
+	w := &bookletPDFWriter{pdf: pdf, glyphs: map[string]map[uint16]uint16{}}
+	for style, font := range fonts {
+		pdf.AddUTF8FontFromBytes(bookletPDFFontFamily, style, font.data)
+		w.glyphs[style] = font.glyphs
+	}
+	return w, pdf.Error()
+}
+
+// write renders the text, replacing the characters the font has no
+// glyph for (e.g CJK), which would otherwise be left blank.
+func (w *bookletPDFWriter) write(style string, size, lineHeight float64, text string) {
+	text = strings.Map(func(r rune) rune {
+		if _, ok := w.glyphs[style][uint16(r)]; r > 0xFFFF || (!ok && r >= ' ') {
+			return bookletPDFReplacementChar
+		}
+		return r
+	}, text)
+	w.pdf.SetFont(bookletPDFFontFamily, style, size)
+	w.pdf.MultiCell(0, lineHeight, text, "", "L", false)
+}
+
+func (w *bookletPDFWriter) heading(text string, size float64) {
+	w.pdf.SetTextColor(bookletPDFAccent[0], bookletPDFAccent[1], bookletPDFAccent[2])
+	w.write("B", size, size*0.5, text)
+	w.pdf.SetTextColor(0, 0, 0)
+	w.pdf.Ln(2)
+}
+
+func (w *bookletPDFWriter) text(style, text string) {
+	if text == "" {
+		return
+	}
+	w.write(style, 10, 5, text)
+}
+
+func (w *bookletPDFWriter) field(label, value string) {
+	if value == "" {
+		return
+	}
+	w.text("", fmt.Sprintf("%s: %s", label, value))
+}
+
+// toPDF renders the booklet with the DejaVu fonts, which cover Latin,
+// Greek and Cyrillic scripts. Characters of other scripts are replaced,
+// the HTML booklet renders those instead.
+func (doc bookletDoc) toPDF() ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(doc.Name, true)
+	pdf.SetCreator(exportCreator, true)
+	w, err := newBookletPDFWriter(pdf)
+	if err != nil {
+		return nil, err
+	}
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont(bookletPDFFontFamily, "I", 8)
+		pdf.CellFormat(0, 10, fmt.Sprintf("%d", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+
+	pdf.AddPage()
+	w.heading(doc.Name, 22)
+	w.text("I", doc.Dates)
+	w.text("", doc.Notes)
+	pdf.Ln(4)
+
+	for _, day := range doc.Days {
+		w.heading(day.Title, 14)
+		w.text("I", day.Desc)
+		if len(day.Lodgings) > 0 {
+			w.field("Stay", strings.Join(day.Lodgings, ", "))
+		}
+		for _, act := range day.Activities {
+			pdf.Ln(1)
+			title := act.Title
+			if act.Time != "" {
+				title = fmt.Sprintf("%s  %s", act.Time, act.Title)
+			}
+			w.text("B", title)
+			w.text("", act.Address)
+			w.text("", act.Notes)
+			w.field("Price", act.Price)
+			if act.Route != "" {
+				w.text("I", fmt.Sprintf("-> %s", act.Route))
+			}
+		}
+		pdf.Ln(4)
+	}
+
+	if len(doc.Lodgings) > 0 {
+		w.heading("Lodgings", 16)
+		for _, l := range doc.Lodgings {
+			w.text("B", l.Name)
+			w.text("", l.Address)
+			w.field("Check-in", l.Checkin)
+			w.field("Check-out", l.Checkout)
+			w.field("Confirmation", l.ConfirmationID)
+			w.field("Price", l.Price)
+			w.text("", l.Notes)
+			pdf.Ln(2)
+		}
+	}
+
+	if len(doc.Transits) > 0 {
+		w.heading("Transits", 16)
+		for _, t := range doc.Transits {
+			w.text("B", fmt.Sprintf("%s -> %s (%s)", t.From, t.To, t.Type))
+			w.field("Departs", t.Depart)
+			w.field("Arrives", t.Arrive)
+			w.field("Confirmation", t.ConfirmationID)
+			w.field("Price", t.Price)
+			w.text("", t.Notes)
+			pdf.Ln(2)
+		}
+	}
+
+	if doc.Budget != nil {
+		w.heading("Budget", 16)
+		w.field("Total", doc.Budget.Total)
+		for _, item := range doc.Budget.Items {
+			w.text("", fmt.Sprintf("%s  %s", item.Title, item.Price))
+			w.text("I", item.Desc)
+		}
+		pdf.Ln(2)
+	}
+
+	if len(doc.Links) > 0 {
+		w.heading("Links", 16)
+		for _, l := range doc.Links {
+			w.text("B", l.Title)
+			w.text("", l.URL)
+			w.text("I", l.Notes)
+			pdf.Ln(1)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,235 @@
+package trips
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/travelreys/travelreys/pkg/common"
+	"github.com/travelreys/travelreys/pkg/finance"
+)
+
+const (
+	// TagBudgetCategory overrides the budget category of an expense,
+	// e.g food, shopping. Activities set it in their labels instead.
+	TagBudgetCategory = "budget|category"
+
+	// LabelBudgetWarnings is a derived label on the budget maintained by
+	// the coordinator, with comma-separated over-budget warnings.
+	LabelBudgetWarnings = "budget|warnings"
+
+	BudgetWarningTotal          = "total"
+	BudgetWarningCategoryPrefix = "category:"
+	BudgetWarningDayPrefix      = "day:"
+)
+
+// Currency returns the currency of the budget.
+func (b Budget) Currency() string {
+	if b.Amount.Currency != "" {
+		return b.Amount.Currency
+	}
+	return DefaultLedgerCurrency
+}
+
+// HasAmounts returns true if any budget amount is set.
+func (b Budget) HasAmounts() bool {
+	if b.Amount.Amount > 0 || b.DailyAmount > 0 {
+		return true
+	}
+	for _, amount := range b.CategoryAmounts {
+		if amount > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// budgetUpdateOps are the updates which may add, remove or move
+// the trip's expenses without editing a price.
+var budgetUpdateOps = []string{
+	SyncMsgTOBUpdateOpAddLodging,
+	SyncMsgTOBUpdateOpDeleteLodging,
+	SyncMsgTOBUpdateOpUpdateLodging,
+	SyncMsgTOBUpdateOpAddTransit,
+	SyncMsgTOBUpdateOpDeleteTransit,
+	SyncMsgTOBUpdateOpUpdateTransit,
+	SyncMsgTOBUpdateOpCascadeActivityTimes,
+	SyncMsgTOBUpdateOpCopyActivities,
+	SyncMsgTOBUpdateOpDeleteActivity,
+	SyncMsgTOBUpdateOpOptimizeTrip,
+	SyncMsgTOBUpdateOpReorderActivityToAnotherDay,
+	SyncMsgTOBUpdateOpMoveIdeaToItinerary,
+	SyncMsgTOBUpdateOpMoveActivityToIdeas,
+	SyncMsgTOBUpdateOpAcceptPollOption,
+	SyncMsgTOBUpdateOpUpdateTripDates,
+	SyncMsgTOBUpdateOpImportBudgetItems,
+}
+
+// isBudgetUpdate returns true if the update may change the
+// budget summary, so that its warnings need recomputing.
+func isBudgetUpdate(update *SyncMsgTOBPayloadUpdate) bool {
+	if common.StringContains(budgetUpdateOps, update.Op) {
+		return true
+	}
+	for _, op := range update.Ops {
+		if strings.HasPrefix(op.Path, "/budget") ||
+			strings.Contains(op.Path, "/price") ||
+			strings.Contains(op.Path, TagBudgetCategory) ||
+			strings.HasSuffix(op.Path, "Time") {
+			return true
+		}
+	}
+	return false
+}
+
+// BudgetCategory returns the category the expense counts towards.
+func (e Expense) BudgetCategory() string {
+	if category := e.Tags[TagBudgetCategory]; category != "" {
+		return category
+	}
+	if category := e.Labels[TagBudgetCategory]; category != "" {
+		return category
+	}
+	return e.Category
+}
+
+type BudgetCategorySummary struct {
+	Category   string  `json:"category"`
+	Budget     float64 `json:"budget"`
+	Spent      float64 `json:"spent"`
+	OverBudget bool    `json:"overBudget"`
+}
+
+type BudgetDaySummary struct {
+	Date       string  `json:"date"`
+	Budget     float64 `json:"budget"`
+	Spent      float64 `json:"spent"`
+	OverBudget bool    `json:"overBudget"`
+}
+
+type BudgetSummary struct {
+	Currency   string                            `json:"currency"`
+	Budget     float64                           `json:"budget"`
+	Spent      float64                           `json:"spent"`
+	Remaining  float64                           `json:"remaining"`
+	OverBudget bool                              `json:"overBudget"`
+	Categories map[string]*BudgetCategorySummary `json:"categories"`
+	Days       map[string]*BudgetDaySummary      `json:"days"`
+
+	// Unconverted lists the paths of the expenses that could not be
+	// converted into the budget's currency.
+	Unconverted []string `json:"unconverted"`
+}
+
+// Warnings returns the over-budget warnings of the summary, sorted.
+func (s BudgetSummary) Warnings() []string {
+	warnings := []string{}
+	if s.OverBudget {
+		warnings = append(warnings, BudgetWarningTotal)
+	}
+	for category, c := range s.Categories {
+		if c.OverBudget {
+			warnings = append(warnings, BudgetWarningCategoryPrefix+category)
+		}
+	}
+	for dtKey, d := range s.Days {
+		if d.OverBudget {
+			warnings = append(warnings, BudgetWarningDayPrefix+dtKey)
+		}
+	}
+	sort.Strings(warnings)
+	return warnings
+}
+
+func (s *BudgetSummary) category(category string) *BudgetCategorySummary {
+	c, ok := s.Categories[category]
+	if !ok {
+		c = &BudgetCategorySummary{Category: category}
+		s.Categories[category] = c
+	}
+	return c
+}
+
+func (s *BudgetSummary) day(dtKey string) *BudgetDaySummary {
+	d, ok := s.Days[dtKey]
+	if !ok {
+		d = &BudgetDaySummary{Date: dtKey}
+		s.Days[dtKey] = d
+	}
+	return d
+}
+
+// MakeBudgetSummary rolls up the trip's expenses per category and per day
+// and compares them to the budget, in the base currency of the rates.
+// Expenses are converted at the rates of their transaction date.
+func MakeBudgetSummary(trip *Trip, rates finance.ExchangeRatesByDate) BudgetSummary {
+	summary := BudgetSummary{
+		Currency:    rates.Base,
+		Categories:  map[string]*BudgetCategorySummary{},
+		Days:        map[string]*BudgetDaySummary{},
+		Unconverted: []string{},
+	}
+	summary.Budget = convertBudgetAmount(trip.Budget.Amount.Amount, trip.Budget, rates)
+	for category, amount := range trip.Budget.CategoryAmounts {
+		summary.category(category).Budget = convertBudgetAmount(amount, trip.Budget, rates)
+	}
+	dailyBudget := convertBudgetAmount(trip.Budget.DailyAmount, trip.Budget, rates)
+	for dtKey := range trip.Itineraries {
+		summary.day(dtKey).Budget = dailyBudget
+	}
+
+	for _, e := range trip.Expenses() {
+		amount, ok := rates.Convert(e.PriceItem.Amount, e.PriceItem.Currency, e.Date)
+		if !ok {
+			summary.Unconverted = append(summary.Unconverted, e.Path)
+			continue
+		}
+		summary.Spent += amount
+		summary.category(e.BudgetCategory()).Spent += amount
+
+		days := expenseDays(e)
+		for _, dtKey := range days {
+			if _, ok := trip.Itineraries[dtKey]; ok {
+				summary.day(dtKey).Spent += amount / float64(len(days))
+			}
+		}
+	}
+
+	summary.Budget = roundAmount(summary.Budget)
+	summary.Spent = roundAmount(summary.Spent)
+	summary.Remaining = roundAmount(summary.Budget - summary.Spent)
+	summary.OverBudget = summary.Budget > 0 && summary.Remaining < 0
+	for _, c := range summary.Categories {
+		c.Budget = roundAmount(c.Budget)
+		c.Spent = roundAmount(c.Spent)
+		c.OverBudget = c.Budget > 0 && c.Spent > c.Budget
+	}
+	for _, d := range summary.Days {
+		d.Budget = roundAmount(d.Budget)
+		d.Spent = roundAmount(d.Spent)
+		d.OverBudget = d.Budget > 0 && d.Spent > d.Budget
+	}
+	return summary
+}
+
+func convertBudgetAmount(amount float64, budget Budget, rates finance.ExchangeRatesByDate) float64 {
+	converted, _ := rates.Latest.Convert(amount, budget.Amount.Currency)
+	return converted
+}
+
+// expenseDays returns the itinerary keys the expense is spread over.
+// Lodgings are spread over their nights.
+func expenseDays(e Expense) []string {
+	if e.Date.IsZero() {
+		return []string{}
+	}
+	days := []string{e.Date.Format(ItineraryDtKeyFormat)}
+	if e.Category != ExpenseCategoryLodging || e.EndDate.IsZero() {
+		return days
+	}
+	checkin := time.Date(e.Date.Year(), e.Date.Month(), e.Date.Day(), 0, 0, 0, 0, e.Date.Location())
+	for dt := checkin.AddDate(0, 0, 1); dt.Format(ItineraryDtKeyFormat) < e.EndDate.Format(ItineraryDtKeyFormat); dt = dt.AddDate(0, 0, 1) {
+		days = append(days, dt.Format(ItineraryDtKeyFormat))
+	}
+	return days
+}
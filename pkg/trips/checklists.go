@@ -0,0 +1,145 @@
+package trips
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/travelreys/travelreys/pkg/common"
+)
+
+const (
+	JSONPathChecklistsRoot = "/checklists"
+)
+
+// ChecklistItem is a to-do of the trip, e.g a visa to apply for
+// or something to pack.
+type ChecklistItem struct {
+	ID         string `json:"id" bson:"id"`
+	Title      string `json:"title" bson:"title"`
+	AssigneeID string `json:"assigneeID" bson:"assigneeID"`
+
+	// DueOffsetDays is the number of days from the trip's start date
+	// the item is due, negative before the trip starts.
+	DueOffsetDays *int `json:"dueOffsetDays,omitempty" bson:"dueOffsetDays,omitempty"`
+
+	Checked   bool      `json:"checked" bson:"checked"`
+	CheckedBy string    `json:"checkedBy" bson:"checkedBy"`
+	CheckedAt time.Time `json:"checkedAt" bson:"checkedAt"`
+
+	Labels common.Labels `json:"labels" bson:"labels"`
+}
+
+func NewChecklistItem(title string) ChecklistItem {
+	return ChecklistItem{
+		ID:     uuid.NewString(),
+		Title:  title,
+		Labels: common.Labels{},
+	}
+}
+
+// DueDate returns the date the item is due, if it has one and the
+// trip's start date is set.
+func (item ChecklistItem) DueDate(startDate time.Time) (time.Time, bool) {
+	if item.DueOffsetDays == nil || startDate.IsZero() {
+		return time.Time{}, false
+	}
+	return startDate.AddDate(0, 0, *item.DueOffsetDays), true
+}
+
+type ChecklistItemsMap map[string]*ChecklistItem
+type ChecklistItemsList []*ChecklistItem
+
+type Checklist struct {
+	ID        string            `json:"id" bson:"id"`
+	Title     string            `json:"title" bson:"title"`
+	Items     ChecklistItemsMap `json:"items" bson:"items"`
+	CreatedAt time.Time         `json:"createdAt" bson:"createdAt"`
+	Labels    common.Labels     `json:"labels" bson:"labels"`
+}
+
+type ChecklistsMap map[string]*Checklist
+
+func NewChecklist(title string) Checklist {
+	return Checklist{
+		ID:        uuid.NewString(),
+		Title:     title,
+		Items:     ChecklistItemsMap{},
+		CreatedAt: time.Now(),
+		Labels:    common.Labels{},
+	}
+}
+
+// SortItems returns the items ordered by their fractional index.
+func (cl Checklist) SortItems() ChecklistItemsList {
+	list := ChecklistItemsList{}
+	for _, item := range cl.Items {
+		list = append(list, item)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		fi, fj := list[i].Labels[LabelFractionalIndex], list[j].Labels[LabelFractionalIndex]
+		if fi != fj {
+			return fi < fj
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// Checklists Sync Ops
+
+func MakeChecklistPath(checklistID string) string {
+	return fmt.Sprintf("%s/%s", JSONPathChecklistsRoot, checklistID)
+}
+
+func MakeChecklistItemPath(checklistID, itemID string) string {
+	return fmt.Sprintf("%s/items/%s", MakeChecklistPath(checklistID), itemID)
+}
+
+// SyncMsgTOBUpdateOpAddChecklist
+func MakeSyncMsgTOBUpdateOpAddChecklistOps(cl Checklist) []SyncOp {
+	return []SyncOp{MakeAddSyncOp(MakeChecklistPath(cl.ID), cl)}
+}
+
+// SyncMsgTOBUpdateOpDeleteChecklist
+func MakeSyncMsgTOBUpdateOpDeleteChecklistOps(checklistID string) []SyncOp {
+	return []SyncOp{MakeRemoveSyncOp(MakeChecklistPath(checklistID), "")}
+}
+
+// SyncMsgTOBUpdateOpAddChecklistItem
+func MakeSyncMsgTOBUpdateOpAddChecklistItemOps(checklistID string, item ChecklistItem) []SyncOp {
+	return []SyncOp{MakeAddSyncOp(MakeChecklistItemPath(checklistID, item.ID), item)}
+}
+
+// SyncMsgTOBUpdateOpUpdateChecklistItem
+func MakeSyncMsgTOBUpdateOpUpdateChecklistItemOps(checklistID string, item ChecklistItem) []SyncOp {
+	return []SyncOp{MakeRepSyncOp(MakeChecklistItemPath(checklistID, item.ID), item)}
+}
+
+// SyncMsgTOBUpdateOpDeleteChecklistItem
+func MakeSyncMsgTOBUpdateOpDeleteChecklistItemOps(checklistID, itemID string) []SyncOp {
+	return []SyncOp{MakeRemoveSyncOp(MakeChecklistItemPath(checklistID, itemID), "")}
+}
+
+// SyncMsgTOBUpdateOpToggleChecklistItem
+func MakeSyncMsgTOBUpdateOpToggleChecklistItemOps(checklistID, itemID, memberID string, checked bool) []SyncOp {
+	path := MakeChecklistItemPath(checklistID, itemID)
+	checkedAt := time.Time{}
+	if checked {
+		checkedAt = time.Now()
+	} else {
+		memberID = ""
+	}
+	return []SyncOp{
+		MakeRepSyncOp(path+"/checked", checked),
+		MakeRepSyncOp(path+"/checkedBy", memberID),
+		MakeRepSyncOp(path+"/checkedAt", checkedAt),
+	}
+}
+
+// SyncMsgTOBUpdateOpReorderChecklistItem
+func MakeSyncMsgTOBUpdateOpReorderChecklistItemOps(checklistID, itemID, fIndex string) []SyncOp {
+	path := fmt.Sprintf("%s/labels/%s", MakeChecklistItemPath(checklistID, itemID), LabelFractionalIndex)
+	return []SyncOp{MakeAddSyncOp(path, fIndex)}
+}
@@ -11,8 +11,10 @@ import (
 	jsonpatch "github.com/evanphx/json-patch/v5"
 	"github.com/google/uuid"
 	"github.com/travelreys/travelreys/pkg/common"
+	"github.com/travelreys/travelreys/pkg/finance"
 	"github.com/travelreys/travelreys/pkg/maps"
 	"github.com/travelreys/travelreys/pkg/media"
+	"github.com/travelreys/travelreys/pkg/storage"
 	"go.uber.org/zap"
 )
 
@@ -42,11 +44,13 @@ type Coordinator struct {
 	tobMsgCh  <-chan SyncMsgTOB
 	tobDoneCh chan<- bool
 
-	mapsSvc   maps.Service
-	mediaSvc  media.Service
-	store     Store
-	sessStore SessionStore
-	msgStore  SyncMsgStore
+	mapsSvc    maps.Service
+	mediaSvc   media.Service
+	finSvc     finance.Service
+	storageSvc storage.Service
+	store      Store
+	sessStore  SessionStore
+	msgStore   SyncMsgStore
 
 	doneCh chan bool
 	logger *zap.Logger
@@ -56,6 +60,8 @@ func NewCoordinator(
 	tripID string,
 	mapsSvc maps.Service,
 	mediaSvc media.Service,
+	finSvc finance.Service,
+	storageSvc storage.Service,
 	store Store,
 	sessStore SessionStore,
 	msgStore SyncMsgStore,
@@ -69,6 +75,8 @@ func NewCoordinator(
 		dataFifoMsgQueue: make(chan SyncMsgTOB, common.DefaultChSize),
 		mapsSvc:          mapsSvc,
 		mediaSvc:         mediaSvc,
+		finSvc:           finSvc,
+		storageSvc:       storageSvc,
 		store:            store,
 		msgStore:         msgStore,
 		sessStore:        sessStore,
@@ -87,6 +95,21 @@ func (crd *Coordinator) Init() (<-chan bool, error) {
 	if err != nil {
 		return nil, err
 	}
+	if trip.Ideas == nil {
+		trip.Ideas = ActivityMap{}
+	}
+	if trip.Polls == nil {
+		trip.Polls = PollsMap{}
+	}
+	if trip.Checklists == nil {
+		trip.Checklists = ChecklistsMap{}
+	}
+	if trip.Settlements == nil {
+		trip.Settlements = SettlementsMap{}
+	}
+	if trip.Budget.CategoryAmounts == nil {
+		trip.Budget.CategoryAmounts = map[string]float64{}
+	}
 	tripBytes, _ := json.Marshal(trip)
 	crd.trip = tripBytes
 
@@ -226,34 +249,58 @@ func (crd *Coordinator) handleSyncMsgTOBLeave(ctx context.Context, msg *SyncMsgT
 // based on message topic.
 func (crd *Coordinator) applyDataFifoMsg(ctx context.Context, msg *SyncMsgTOB) {
 	crd.logger.Info("applying", zap.Uint64("counter", msg.Counter))
+	if !crd.canApplyUpdate(msg) {
+		crd.logger.Warn("update rejected",
+			zap.String("memberID", msg.MemberID),
+			zap.String("op", msg.Update.Op),
+		)
+		msg.Update.Ops = []SyncOp{}
+		msg.Update.Err = ErrRBAC.Error()
+		return
+	}
+
+	// Checked before the processing appends its own ops.
+	budgetUpdate := isBudgetUpdate(msg.Update)
+
 	patchOps, _ := json.Marshal(msg.Update.Ops)
 	patch, _ := jsonpatch.DecodePatch(patchOps)
 	modified, err := patch.Apply(crd.trip)
 	if err != nil {
 		crd.logger.Error("json patch apply", zap.Error(err))
+		msg.Update.Ops = []SyncOp{}
+		msg.Update.Err = ErrUpdateNotApplied.Error()
 		return
 	}
 
+	prevTrip := crd.trip
 	crd.trip = modified
 
 	var toSave Trip
 	if err = json.Unmarshal(crd.trip, &toSave); err != nil {
 		crd.logger.Error("json unmarshall fails", zap.Error(err))
+		crd.trip = prevTrip
+		msg.Update.Ops = []SyncOp{}
+		msg.Update.Err = ErrUpdateNotApplied.Error()
 		return
 	}
 
 	switch msg.Update.Op {
 	case SyncMsgTOBUpdateOpAddLodging,
 		SyncMsgTOBUpdateOpUpdateLodging,
-		SyncMsgTOBUpdateOpDeleteLodging:
+		SyncMsgTOBUpdateOpDeleteLodging,
+		SyncMsgTOBUpdateOpAddTransit,
+		SyncMsgTOBUpdateOpUpdateTransit,
+		SyncMsgTOBUpdateOpDeleteTransit:
 		crd.processLodgingChanged(ctx, &toSave, msg)
 		crd.trip, _ = json.Marshal(toSave)
 	case SyncMsgTOBUpdateOpUpdateTripDates:
 		crd.processDatesChanged(&toSave, msg)
 		crd.trip, _ = json.Marshal(toSave)
 	case SyncMsgTOBUpdateOpReorderItinerary,
+		SyncMsgTOBUpdateOpCopyActivities,
 		SyncMsgTOBUpdateOpUpdateActivityPlace,
-		SyncMsgTOBUpdateOpDeleteActivity:
+		SyncMsgTOBUpdateOpDeleteActivity,
+		SyncMsgTOBUpdateOpUpdateRouteMode:
 		crd.processActivityChangedSameDay(ctx, &toSave, msg)
 		crd.trip, _ = json.Marshal(toSave)
 	case SyncMsgTOBUpdateOpReorderActivityToAnotherDay:
@@ -262,19 +309,84 @@ func (crd *Coordinator) applyDataFifoMsg(ctx context.Context, msg *SyncMsgTOB) {
 	case SyncMsgTOBUpdateOpOptimizeItinerary:
 		crd.processOptimizeRoute(ctx, &toSave, msg)
 		crd.trip, _ = json.Marshal(toSave)
+	case SyncMsgTOBUpdateOpOptimizeTrip:
+		crd.processOptimizeTrip(ctx, &toSave, msg)
+		crd.trip, _ = json.Marshal(toSave)
+	case SyncMsgTOBUpdateOpVoteIdea:
+		crd.processIdeaVoted(&toSave, msg)
+		crd.trip, _ = json.Marshal(toSave)
+	case SyncMsgTOBUpdateOpMoveIdeaToItinerary:
+		crd.processIdeaMovedToItinerary(ctx, &toSave, msg)
+		crd.trip, _ = json.Marshal(toSave)
+	case SyncMsgTOBUpdateOpMoveActivityToIdeas:
+		crd.processActivityMovedToIdeas(ctx, &toSave, msg)
+		crd.trip, _ = json.Marshal(toSave)
+	case SyncMsgTOBUpdateOpVotePoll:
+		crd.processPollVoted(&toSave, msg)
+		crd.trip, _ = json.Marshal(toSave)
+	case SyncMsgTOBUpdateOpAcceptPollOption:
+		crd.processPollOptionAccepted(ctx, &toSave, msg)
+		crd.trip, _ = json.Marshal(toSave)
+	case SyncMsgTOBUpdateOpAddChecklistItem:
+		crd.processChecklistItemAdded(&toSave, msg)
+		crd.trip, _ = json.Marshal(toSave)
+	case SyncMsgTOBUpdateOpRemoveTripMember:
+		crd.processMemberRemoved(&toSave, msg)
+		crd.trip, _ = json.Marshal(toSave)
+	case SyncMsgTOBUpdateOpCascadeActivityTimes:
+		crd.processCascadeActivityTimes(ctx, &toSave, msg)
+		crd.trip, _ = json.Marshal(toSave)
 	case SyncMsgTOBUpdateOpAddMediaItem:
 		crd.processAugmentMediaItemSignedURL(ctx, &toSave, msg)
 	}
 
+	if msg.Update.Op != SyncMsgTOBUpdateOpAddMediaItem {
+		crd.processScheduleWarnings(&toSave, msg)
+		if budgetUpdate {
+			crd.processBudgetWarnings(ctx, &toSave, msg)
+		}
+		crd.processRemovedReceipts(ctx, prevTrip, &toSave, msg)
+		crd.trip, _ = json.Marshal(toSave)
+	}
+
 	// Persist trip state to database
 	crd.logger.Info("saving", zap.Uint64("counter", msg.Counter))
 	if err := crd.store.Save(ctx, &toSave); err != nil {
 		crd.logger.Error("save fails", zap.Error(err))
+		msg.Update.Err = ErrUpdateNotApplied.Error()
 	}
 
 	crd.sessStore.IncrCounter(ctx, crd.tripID)
 }
 
+// processMemberRemoved hands the content of the removed members
+// over to the trip creator, if the trip's content policy says so.
+func (crd *Coordinator) processMemberRemoved(
+	toSave *Trip,
+	msg *SyncMsgTOB,
+) {
+	if toSave.MemberContentPolicy() != MemberContentPolicyReassign {
+		return
+	}
+	for _, memberID := range RemovedMemberIDs(msg.Update.Ops) {
+		msg.Update.Ops = append(
+			msg.Update.Ops,
+			toSave.ReassignContent(memberID, toSave.Creator.ID)...,
+		)
+	}
+}
+
+// canApplyUpdate checks the role of the member against
+// the parts of the trip changed by the update.
+func (crd *Coordinator) canApplyUpdate(msg *SyncMsgTOB) bool {
+	var curr Trip
+	if err := json.Unmarshal(crd.trip, &curr); err != nil {
+		crd.logger.Error("json unmarshall fails", zap.Error(err))
+		return false
+	}
+	return curr.CanApplyOps(msg.MemberID, msg.Update.Ops)
+}
+
 func (crd *Coordinator) processLodgingChanged(
 	ctx context.Context,
 	toSave *Trip,
@@ -448,6 +560,527 @@ func (crd *Coordinator) processOptimizeRoute(
 	crd.UpdateRoutes(ctx, dtKey, routeMaps, msg, toSave)
 }
 
+// processCascadeActivityTimes derives the start and end times of the
+// day's activities from the first activity's start time, the activities'
+// planned durations and the routes between them.
+func (crd *Coordinator) processCascadeActivityTimes(
+	ctx context.Context,
+	toSave *Trip,
+	msg *SyncMsgTOB,
+) {
+	dtKey := crd.parseItinDtKeyFromOps(msg.Update.Ops)
+	itin, ok := toSave.Itineraries[dtKey]
+	if !ok {
+		return
+	}
+
+	// Routes have to be up to date before their durations are used
+	routesMap := crd.calculateRoute(ctx, itin, toSave)
+	crd.UpdateRoutes(ctx, dtKey, routesMap, msg, toSave)
+
+	sorted := itin.SortActivities()
+	if len(sorted) <= 0 || sorted[0].StartTime.IsZero() {
+		return
+	}
+
+	lodgings := toSave.Lodgings.GetLodgingsForDate(itin.GetDate())
+	pairings := itin.RoutePairings(lodgings, toSave.Transits)
+
+	setTimes := func(act *Activity, start, end time.Time) {
+		actPath := fmt.Sprintf("/itineraries/%s/activities/%s", dtKey, act.ID)
+		if !act.StartTime.Equal(start) {
+			act.StartTime = start
+			msg.Update.Ops = append(msg.Update.Ops, MakeRepSyncOp(actPath+"/startTime", start))
+		}
+		if !act.EndTime.Equal(end) {
+			act.EndTime = end
+			msg.Update.Ops = append(msg.Update.Ops, MakeRepSyncOp(actPath+"/endTime", end))
+		}
+	}
+
+	first := sorted[0]
+	cursor := first.StartTime.Add(first.PlannedDuration())
+	setTimes(first, first.StartTime, cursor)
+
+	for i := 1; i < len(sorted); i++ {
+		act := sorted[i]
+		var travel time.Duration
+		pair := itin.routePairingKey(sorted[i-1], act)
+		if _, ok := pairings[pair]; ok {
+			if route, err := itin.Routes[pair].GetMostCommonSenseRoute(); err == nil {
+				travel = route.Duration
+			}
+		}
+		start := cursor.Add(travel)
+		cursor = start.Add(act.PlannedDuration())
+		setTimes(act, start, cursor)
+	}
+}
+
+// processIdeaVoted updates the derived votes count of the voted ideas.
+func (crd *Coordinator) processIdeaVoted(
+	toSave *Trip,
+	msg *SyncMsgTOB,
+) {
+	// /ideas/<ideaID>/labels/vote|<memberID>
+	for _, op := range msg.Update.Ops {
+		tkns := strings.Split(op.Path, "/")
+		if len(tkns) < 3 || "/"+tkns[1] != JSONPathIdeasRoot {
+			continue
+		}
+		idea, ok := toSave.Ideas[tkns[2]]
+		if !ok {
+			continue
+		}
+		crd.setActivityLabel(MakeIdeaPath(idea.ID), idea, LabelIdeaVotesCount, ideaVotesCount(idea), msg)
+	}
+}
+
+// processIdeaMovedToItinerary places the idea moved into a day
+// in the day's itinerary.
+func (crd *Coordinator) processIdeaMovedToItinerary(
+	ctx context.Context,
+	toSave *Trip,
+	msg *SyncMsgTOB,
+) {
+	// move from /ideas/<ideaID> to /itineraries/<dtKey>/activities/<ideaID>
+	var dtKey, actID string
+	for _, op := range msg.Update.Ops {
+		tkns := strings.Split(op.Path, "/")
+		if op.Op != "move" || !strings.HasPrefix(op.From, JSONPathIdeasRoot) || len(tkns) < 5 {
+			continue
+		}
+		dtKey, actID = tkns[2], tkns[4]
+		break
+	}
+	// The votes only matter while the activity is an idea.
+	if itin, ok := toSave.Itineraries[dtKey]; ok {
+		if act, ok := itin.Activities[actID]; ok {
+			for key := range act.Labels {
+				if strings.HasPrefix(key, LabelIdeaVotePrefix) || key == LabelIdeaVotesCount {
+					crd.removeActivityLabel(MakeActivityPath(dtKey, actID), act, key, msg)
+				}
+			}
+		}
+	}
+	crd.placeActivityInItinerary(ctx, toSave, dtKey, actID, msg)
+}
+
+// placeActivityInItinerary gives the activity added to a day a fractional
+// index after the day's last activity, unless it was dropped at a free
+// index, and recalculates the day's routes.
+func (crd *Coordinator) placeActivityInItinerary(
+	ctx context.Context,
+	toSave *Trip,
+	dtKey,
+	actID string,
+	msg *SyncMsgTOB,
+) {
+	itin, ok := toSave.Itineraries[dtKey]
+	if !ok {
+		return
+	}
+	act, ok := itin.Activities[actID]
+	if !ok {
+		return
+	}
+
+	fIndex := act.Labels[LabelFractionalIndex]
+	lastFIndex := ""
+	for _, other := range itin.SortActivities() {
+		if other.ID == act.ID {
+			continue
+		}
+		if other.Labels[LabelFractionalIndex] == fIndex {
+			fIndex = ""
+		}
+		lastFIndex = other.Labels[LabelFractionalIndex]
+	}
+	if fIndex == "" {
+		newFIndex, err := common.GenerateFracIndexBetween(lastFIndex, "")
+		if err != nil {
+			crd.logger.Error("generate fractional index", zap.Error(err))
+			return
+		}
+		crd.setActivityLabel(MakeActivityPath(dtKey, act.ID), act, LabelFractionalIndex, newFIndex, msg)
+	}
+	crd.removeActivityLabel(MakeActivityPath(dtKey, act.ID), act, LabelIdeaVotesCount, msg)
+
+	routesMap := crd.calculateRoute(ctx, itin, toSave)
+	crd.UpdateRoutes(ctx, dtKey, routesMap, msg, toSave)
+}
+
+// processActivityMovedToIdeas clears the day specific labels of the
+// activity moved to the ideas and recalculates the day's routes.
+func (crd *Coordinator) processActivityMovedToIdeas(
+	ctx context.Context,
+	toSave *Trip,
+	msg *SyncMsgTOB,
+) {
+	// move from /itineraries/<dtKey>/activities/<actID> to /ideas/<actID>
+	var dtKey, actID string
+	for _, op := range msg.Update.Ops {
+		tkns := strings.Split(op.From, "/")
+		if op.Op != "move" || !strings.HasPrefix(op.Path, JSONPathIdeasRoot) || len(tkns) < 5 {
+			continue
+		}
+		dtKey, actID = tkns[2], tkns[4]
+		break
+	}
+	idea, ok := toSave.Ideas[actID]
+	if !ok {
+		return
+	}
+	for _, key := range []string{LabelFractionalIndex, LabelScheduleWarnings} {
+		crd.removeActivityLabel(MakeIdeaPath(idea.ID), idea, key, msg)
+	}
+	crd.setActivityLabel(MakeIdeaPath(idea.ID), idea, LabelIdeaVotesCount, ideaVotesCount(idea), msg)
+
+	if itin, ok := toSave.Itineraries[dtKey]; ok {
+		routesMap := crd.calculateRoute(ctx, itin, toSave)
+		crd.UpdateRoutes(ctx, dtKey, routesMap, msg, toSave)
+	}
+}
+
+// processPollVoted discards the votes on closed polls and, unless the
+// poll is multiple choice, the voter's previous votes on other options.
+func (crd *Coordinator) processPollVoted(
+	toSave *Trip,
+	msg *SyncMsgTOB,
+) {
+	// /polls/<pollID>/options/<optionID>/votes/<memberID>
+	for _, op := range msg.Update.Ops {
+		tkns := strings.Split(op.Path, "/")
+		if op.Op != "add" || len(tkns) != 7 || "/"+tkns[1] != JSONPathPollsRoot {
+			continue
+		}
+		pollID, optionID, memberID := tkns[2], tkns[4], tkns[6]
+		poll, ok := toSave.Polls[pollID]
+		if !ok {
+			continue
+		}
+		for _, opt := range poll.Options {
+			if _, voted := opt.Votes[memberID]; !voted {
+				continue
+			}
+			if opt.ID == optionID && !poll.IsClosed() {
+				continue
+			}
+			if opt.ID != optionID && poll.MultipleChoice {
+				continue
+			}
+			delete(opt.Votes, memberID)
+			msg.Update.Ops = append(msg.Update.Ops, MakeRemoveSyncOp(
+				fmt.Sprintf("%s/votes/%s", MakePollOptionPath(pollID, opt.ID), memberID), "",
+			))
+		}
+	}
+}
+
+// processPollOptionAccepted places the accepted activity in its day's
+// itinerary, or recalculates the routes for an accepted lodging.
+func (crd *Coordinator) processPollOptionAccepted(
+	ctx context.Context,
+	toSave *Trip,
+	msg *SyncMsgTOB,
+) {
+	for _, op := range msg.Update.Ops {
+		tkns := strings.Split(op.Path, "/")
+		if op.Op != "add" || len(tkns) < 3 {
+			continue
+		}
+		if "/"+tkns[1] == JSONPathItineraryRoot && len(tkns) == 5 {
+			crd.placeActivityInItinerary(ctx, toSave, tkns[2], tkns[4], msg)
+			return
+		}
+		if tkns[1] == "lodgings" {
+			crd.processLodgingChanged(ctx, toSave, msg)
+			return
+		}
+	}
+}
+
+// processChecklistItemAdded gives the items added without a free
+// fractional index one after the checklist's last item.
+func (crd *Coordinator) processChecklistItemAdded(
+	toSave *Trip,
+	msg *SyncMsgTOB,
+) {
+	// /checklists/<checklistID>/items/<itemID>
+	for _, op := range msg.Update.Ops {
+		tkns := strings.Split(op.Path, "/")
+		if op.Op != "add" || len(tkns) != 5 || "/"+tkns[1] != JSONPathChecklistsRoot {
+			continue
+		}
+		cl, ok := toSave.Checklists[tkns[2]]
+		if !ok {
+			continue
+		}
+		item, ok := cl.Items[tkns[4]]
+		if !ok {
+			continue
+		}
+
+		fIndex := item.Labels[LabelFractionalIndex]
+		lastFIndex := ""
+		for _, other := range cl.SortItems() {
+			if other.ID == item.ID {
+				continue
+			}
+			if other.Labels[LabelFractionalIndex] == fIndex {
+				fIndex = ""
+			}
+			lastFIndex = other.Labels[LabelFractionalIndex]
+		}
+		if fIndex != "" {
+			continue
+		}
+		newFIndex, err := common.GenerateFracIndexBetween(lastFIndex, "")
+		if err != nil {
+			crd.logger.Error("generate fractional index", zap.Error(err))
+			continue
+		}
+		itemPath := MakeChecklistItemPath(cl.ID, item.ID)
+		if item.Labels == nil {
+			item.Labels = common.Labels{LabelFractionalIndex: newFIndex}
+			msg.Update.Ops = append(msg.Update.Ops, MakeAddSyncOp(itemPath+"/labels", item.Labels))
+			continue
+		}
+		item.Labels[LabelFractionalIndex] = newFIndex
+		msg.Update.Ops = append(msg.Update.Ops, MakeAddSyncOp(
+			fmt.Sprintf("%s/labels/%s", itemPath, LabelFractionalIndex), newFIndex,
+		))
+	}
+}
+
+// processScheduleWarnings recomputes the schedule of every itinerary
+// and broadcasts the changes to the activities' derived warnings label.
+func (crd *Coordinator) processScheduleWarnings(
+	toSave *Trip,
+	msg *SyncMsgTOB,
+) {
+	for dtKey, itin := range toSave.Itineraries {
+		lodgings := toSave.Lodgings.GetLodgingsForDate(itin.GetDate())
+		for actID, warnings := range itin.Schedule(lodgings, toSave.Transits).Warnings() {
+			act := itin.Activities[actID]
+			if warnings == "" {
+				crd.removeActivityLabel(MakeActivityPath(dtKey, act.ID), act, LabelScheduleWarnings, msg)
+				continue
+			}
+			crd.setActivityLabel(MakeActivityPath(dtKey, act.ID), act, LabelScheduleWarnings, warnings, msg)
+		}
+	}
+}
+
+// processBudgetWarnings compares the trip's expenses to its budget and
+// broadcasts the changes to the budget's derived warnings label.
+func (crd *Coordinator) processBudgetWarnings(
+	ctx context.Context,
+	toSave *Trip,
+	msg *SyncMsgTOB,
+) {
+	budget := &toSave.Budget
+	if _, ok := budget.Labels[LabelBudgetWarnings]; !ok && !budget.HasAmounts() {
+		return
+	}
+
+	// The providers are not called here, as the session
+	// would wait on them before applying the next update.
+	currency := budget.Currency()
+	rates, err := crd.finSvc.GetCachedFxRatesForDates(ctx, currency, toSave.TransactionDates())
+	if err != nil {
+		crd.logger.Warn("fx rates unavailable", zap.String("base", currency), zap.Error(err))
+		rates = finance.NewExchangeRatesByDate(finance.ExchangeRates{
+			Base: currency, Rates: map[string]float64{currency: 1},
+		})
+	}
+	warnings := strings.Join(MakeBudgetSummary(toSave, rates).Warnings(), ",")
+
+	labelsPath := "/budget/labels"
+	if warnings == "" {
+		if _, ok := budget.Labels[LabelBudgetWarnings]; ok {
+			delete(budget.Labels, LabelBudgetWarnings)
+			msg.Update.Ops = append(msg.Update.Ops, MakeRemoveSyncOp(
+				fmt.Sprintf("%s/%s", labelsPath, LabelBudgetWarnings), "",
+			))
+		}
+		return
+	}
+	if budget.Labels == nil {
+		budget.Labels = common.Labels{LabelBudgetWarnings: warnings}
+		msg.Update.Ops = append(msg.Update.Ops, MakeAddSyncOp(labelsPath, budget.Labels))
+		return
+	}
+	if budget.Labels[LabelBudgetWarnings] == warnings {
+		return
+	}
+	budget.Labels[LabelBudgetWarnings] = warnings
+	msg.Update.Ops = append(msg.Update.Ops, MakeAddSyncOp(
+		fmt.Sprintf("%s/%s", labelsPath, LabelBudgetWarnings), warnings,
+	))
+}
+
+// processRemovedReceipts deletes the receipt files removed by the
+// update, with their expense or on their own, from the previous trip.
+func (crd *Coordinator) processRemovedReceipts(
+	ctx context.Context,
+	prevTrip []byte,
+	toSave *Trip,
+	msg *SyncMsgTOB,
+) {
+	var prev Trip
+	if err := json.Unmarshal(prevTrip, &prev); err != nil {
+		crd.logger.Error("json unmarshall fails", zap.Error(err))
+		return
+	}
+	for _, obj := range toSave.RemovedReceipts(prev, msg.Update.Ops) {
+		toRemove := *obj
+		toRemove.Bucket = attachmentBucket
+		if err := crd.storageSvc.Remove(ctx, toRemove); err != nil {
+			crd.logger.Error("remove receipt", zap.String("path", obj.Path), zap.Error(err))
+			continue
+		}
+		delete(toSave.Files, obj.ID)
+		msg.Update.Ops = append(msg.Update.Ops, MakeRemoveSyncOp(fmt.Sprintf("/files/%s", obj.ID), ""))
+	}
+}
+
+// processOptimizeTrip moves and reorders the trip's activities
+// according to the trip optimiser's plan.
+func (crd *Coordinator) processOptimizeTrip(
+	ctx context.Context,
+	toSave *Trip,
+	msg *SyncMsgTOB,
+) {
+	mode := toSave.Labels[LabelOptimizerTravelMode]
+	if !common.StringContains(maps.DirectionModesAllList, mode) {
+		mode = maps.DefaultDirectionMode
+	}
+	plan := OptimizeTrip(toSave, crd.travelCostFunc(ctx, mode))
+
+	origDtKeys := map[string]string{}
+	for dtKey, itin := range toSave.Itineraries {
+		for actID := range itin.Activities {
+			origDtKeys[actID] = dtKey
+		}
+	}
+
+	changedDtKeys := map[string]bool{}
+	for _, dtKey := range GetSortedItineraryKeys(toSave) {
+		for _, act := range plan[dtKey] {
+			origDtKey := origDtKeys[act.ID]
+			if origDtKey == dtKey {
+				continue
+			}
+			delete(toSave.Itineraries[origDtKey].Activities, act.ID)
+			toSave.Itineraries[dtKey].Activities[act.ID] = act
+			msg.Update.Ops = append(msg.Update.Ops, MakeMoveSyncOp(
+				fmt.Sprintf("/itineraries/%s/activities/%s", origDtKey, act.ID),
+				fmt.Sprintf("/itineraries/%s/activities/%s", dtKey, act.ID),
+			))
+			changedDtKeys[origDtKey] = true
+			changedDtKeys[dtKey] = true
+		}
+	}
+
+	for _, dtKey := range GetSortedItineraryKeys(toSave) {
+		list := plan[dtKey]
+		if !changedDtKeys[dtKey] && sameActivityOrder(list, toSave.Itineraries[dtKey].SortActivities()) {
+			continue
+		}
+		fIndexes, err := common.GenerateNFracIndexesBetween("", "", len(list))
+		if err != nil {
+			crd.logger.Error("generate fractional indexes", zap.Error(err))
+			return
+		}
+		for i, act := range list {
+			crd.setActivityLabel(MakeActivityPath(dtKey, act.ID), act, LabelFractionalIndex, fIndexes[i], msg)
+		}
+		changedDtKeys[dtKey] = true
+	}
+
+	for dtKey := range changedDtKeys {
+		routesMap := crd.calculateRoute(ctx, toSave.Itineraries[dtKey], toSave)
+		crd.UpdateRoutes(ctx, dtKey, routesMap, msg, toSave)
+	}
+}
+
+// travelCostFunc returns travel durations from the maps provider, falling
+// back to straight-line distances when the provider is unavailable.
+func (crd *Coordinator) travelCostFunc(ctx context.Context, mode string) TravelCostFunc {
+	return func(places []maps.Place) [][]float64 {
+		placeIDs := []string{}
+		for _, p := range places {
+			if p.PlaceID() == "" {
+				return StraightLineTravelCost(places)
+			}
+			placeIDs = append(placeIDs, p.PlaceID())
+		}
+		matrix, err := crd.mapsSvc.DistanceMatrix(ctx, placeIDs, mode)
+		if err != nil {
+			crd.logger.Warn("distance matrix unavailable, using straight-line distances", zap.Error(err))
+			return StraightLineTravelCost(places)
+		}
+		cost := make([][]float64, len(matrix))
+		for i, row := range matrix {
+			cost[i] = make([]float64, len(row))
+			for j, d := range row {
+				cost[i][j] = d.Seconds()
+			}
+		}
+		return cost
+	}
+}
+
+func sameActivityOrder(a, b ActivityList) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].ID != b[i].ID {
+			return false
+		}
+	}
+	return true
+}
+
+// setActivityLabel sets the label of the activity at actPath and
+// appends the matching op to the message.
+func (crd *Coordinator) setActivityLabel(
+	actPath string,
+	act *Activity,
+	key,
+	value string,
+	msg *SyncMsgTOB,
+) {
+	labelsPath := actPath + "/labels"
+	if act.Labels == nil {
+		act.Labels = common.Labels{key: value}
+		msg.Update.Ops = append(msg.Update.Ops, MakeAddSyncOp(labelsPath, act.Labels))
+		return
+	}
+	if curr, ok := act.Labels[key]; ok && curr == value {
+		return
+	}
+	act.Labels[key] = value
+	msg.Update.Ops = append(msg.Update.Ops, MakeAddSyncOp(fmt.Sprintf("%s/%s", labelsPath, key), value))
+}
+
+func (crd *Coordinator) removeActivityLabel(
+	actPath string,
+	act *Activity,
+	key string,
+	msg *SyncMsgTOB,
+) {
+	if _, ok := act.Labels[key]; !ok {
+		return
+	}
+	delete(act.Labels, key)
+	msg.Update.Ops = append(msg.Update.Ops, MakeRemoveSyncOp(
+		fmt.Sprintf("%s/labels/%s", actPath, key), "",
+	))
+}
+
 // Update Trip Helpers
 
 // parseItinDtKeyFromOps gets the itinerary dt from the ops array
@@ -474,35 +1107,39 @@ func (crd *Coordinator) calculateRoute(
 	result := maps.RouteListMap{}
 
 	lodgings := toSave.Lodgings.GetLodgingsForDate(itin.GetDate())
-	pairings := itin.RoutePairings(lodgings)
+	pairings := itin.RoutePairings(lodgings, toSave.Transits)
 
-	for pair := range pairings {
-		if _, ok := itin.Routes[pair]; ok {
-			result[pair] = itin.Routes[pair]
+	for pair, pairing := range pairings {
+		if !pairing.HasPlaces() {
 			continue
 		}
-		actIds := strings.Split(pair, LabelDelimeter)
-
-		// Origin could be lodging
-		orig, ok := itin.Activities[actIds[0]]
-		if !ok {
-			lod := lodgings[actIds[0]]
-			orig = &Activity{ID: lod.ID, Place: lod.Place}
-		}
-		dest := itin.Activities[actIds[1]]
-		if !(orig.HasPlace() && dest.HasPlace()) {
+		preferredMode := itin.PreferredRouteMode(pair)
+		if itin.IsRouteCacheValid(pair, pairing, preferredMode) {
+			result[pair] = itin.Routes[pair]
 			continue
 		}
 
+		modes := maps.DirectionModesAllList
+		if preferredMode != "" {
+			modes = []string{preferredMode}
+		}
 		routes, err := crd.mapsSvc.Directions(
-			ctx, orig.Place.PlaceID(), dest.Place.PlaceID(), maps.DirectionModesAllList,
+			ctx, pairing.Origin.PlaceID(), pairing.Destination.PlaceID(), modes,
 		)
 		if err != nil {
 			continue
 		}
 		if len(routes) > 0 {
-			shortestRoute, _ := routes.GetMostCommonSenseRoute()
-			routes = maps.RouteList{shortestRoute}
+			route, _ := routes.GetMostCommonSenseRoute()
+			route.Labels[LabelRouteOriginPlaceID] = pairing.Origin.PlaceID()
+			route.Labels[LabelRouteDestPlaceID] = pairing.Destination.PlaceID()
+			route.Labels[LabelRoutePreferredMode] = preferredMode
+			routes = maps.RouteList{route}
+		} else {
+			if itin.Labels == nil {
+				itin.Labels = common.Labels{}
+			}
+			itin.Labels[MakeNoRouteLabel(pair)] = MakeRouteCacheKey(pairing, preferredMode)
 		}
 		result[pair] = routes
 	}
@@ -538,6 +1175,24 @@ func (crd *Coordinator) UpdateRoutes(
 		delete(toSave.Itineraries[dtKey].Routes, pair)
 	}
 
+	// Pairs without directions are cached in the itinerary labels,
+	// the labels of the other pairs are stale.
+	itin := toSave.Itineraries[dtKey]
+	hasNoRouteLabels := false
+	for key := range itin.Labels {
+		if !strings.HasPrefix(key, LabelNoRoutePrefix) {
+			continue
+		}
+		hasNoRouteLabels = true
+		routes, ok := routesMap[strings.TrimPrefix(key, LabelNoRoutePrefix)]
+		if !ok || len(routes) > 0 {
+			delete(itin.Labels, key)
+		}
+	}
+	if hasNoRouteLabels {
+		jop := MakeAddSyncOp(fmt.Sprintf("/itineraries/%s/labels", dtKey), itin.Labels)
+		msg.Update.Ops = append(msg.Update.Ops, jop)
+	}
 }
 
 // SendFirstMemberJoinMsg sends a memberUpdate message to the very first member
@@ -0,0 +1,219 @@
+package trips
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/travelreys/travelreys/pkg/common"
+	"github.com/travelreys/travelreys/pkg/finance"
+	"github.com/travelreys/travelreys/pkg/reqctx"
+)
+
+const (
+	maxCopyActivities = 100
+)
+
+var (
+	ErrActivityNotFound = errors.New("trips.ErrActivityNotFound")
+)
+
+// CopyActivitiesOptions are the activities of a day to copy into a
+// day of another trip. No activities copies the whole day.
+type CopyActivitiesOptions struct {
+	DtKey       string   `json:"dtKey"`
+	ActivityIDs []string `json:"activityIDs"`
+	DestTripID  string   `json:"destTripID"`
+	DestDtKey   string   `json:"destDtKey"`
+
+	// Move removes the activities from the source trip once the
+	// copies are applied in the destination. It is not atomic, the
+	// originals are kept if the copies are rejected.
+	Move bool `json:"move"`
+}
+
+func (opts CopyActivitiesOptions) IsValid() bool {
+	if opts.DtKey == "" || opts.DestTripID == "" || opts.DestDtKey == "" {
+		return false
+	}
+	return len(opts.ActivityIDs) <= maxCopyActivities
+}
+
+// CopyActivities returns copies of the activities for another trip,
+// appended after the activities of its day in their original order,
+// and the ops adding them, with the routes between them, to the day.
+// Expenses are not shared across trips, so only the price is kept.
+func (itin Itinerary) CopyActivities(
+	activityIDs []string,
+	dest *Itinerary,
+	destDtKey,
+	creatorID string,
+) (ActivityList, []SyncOp, error) {
+	toCopy := ActivityList{}
+	if len(activityIDs) == 0 {
+		toCopy = itin.SortActivities()
+	} else {
+		for _, act := range itin.SortActivities() {
+			if common.StringContains(activityIDs, act.ID) {
+				toCopy = append(toCopy, act)
+			}
+		}
+		if len(toCopy) != len(activityIDs) {
+			return nil, nil, ErrActivityNotFound
+		}
+	}
+
+	lastFIndex := ""
+	if sorted := dest.SortActivities(); len(sorted) > 0 {
+		lastFIndex = sorted[len(sorted)-1].Labels[LabelFractionalIndex]
+	}
+	fIndexes, err := common.GenerateNFracIndexesBetween(lastFIndex, "", len(toCopy))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Activities keep their time of day on the new day.
+	offset := dest.GetDate().Sub(itin.GetDate())
+	newIDs := map[string]string{}
+	copies := ActivityList{}
+	ops := []SyncOp{}
+	for i, act := range toCopy {
+		cp := &Activity{
+			ID:        uuid.New().String(),
+			Title:     act.Title,
+			Place:     act.Place,
+			Notes:     act.Notes,
+			PriceItem: finance.PriceItem{Price: act.PriceItem.Price},
+			StartTime: act.StartTime,
+			EndTime:   act.EndTime,
+			Labels: common.Labels{
+				LabelCreatedBy:       creatorID,
+				LabelFractionalIndex: fIndexes[i],
+			},
+		}
+		if !cp.StartTime.IsZero() {
+			cp.StartTime = cp.StartTime.Add(offset)
+		}
+		if !cp.EndTime.IsZero() {
+			cp.EndTime = cp.EndTime.Add(offset)
+		}
+		newIDs[act.ID] = cp.ID
+		copies = append(copies, cp)
+		ops = append(ops, MakeAddSyncOp(MakeActivityPath(destDtKey, cp.ID), cp))
+	}
+
+	// Routes between copied activities are still valid, the coordinator
+	// calculates those to the activities already in the day.
+	for pair, routes := range itin.Routes {
+		newPair, ok := remapRoutePairingKey(pair, newIDs)
+		if !ok || dest.Routes == nil {
+			continue
+		}
+		ops = append(ops, MakeAddSyncOp(
+			fmt.Sprintf("%s/%s/routes/%s", JSONPathItineraryRoot, destDtKey, newPair),
+			routes,
+		))
+		if mode := itin.PreferredRouteMode(pair); mode != "" && dest.Labels != nil {
+			ops = append(ops, MakeAddSyncOp(
+				fmt.Sprintf("%s/%s/labels/%s", JSONPathItineraryRoot, destDtKey, MakeRouteModeLabel(newPair)),
+				mode,
+			))
+		}
+	}
+
+	if len(activityIDs) == 0 && dest.Description == "" && itin.Description != "" {
+		ops = append(ops, MakeRepSyncOp(
+			fmt.Sprintf("%s/%s/desc", JSONPathItineraryRoot, destDtKey),
+			itin.Description,
+		))
+	}
+	return copies, ops, nil
+}
+
+// remapRoutePairingKey returns the pairing key of the route between
+// the copies of its activities, if both were copied.
+func remapRoutePairingKey(pair string, newIDs map[string]string) (string, bool) {
+	tkns := strings.Split(pair, LabelDelimeter)
+	if len(tkns) != 2 {
+		return "", false
+	}
+	origID, ok := newIDs[tkns[0]]
+	if !ok {
+		return "", false
+	}
+	destID, ok := newIDs[tkns[1]]
+	if !ok {
+		return "", false
+	}
+	return routePairingKeyFromIDs(origID, destID), true
+}
+
+// SyncMsgTOBUpdateOpDeleteActivity
+func MakeSyncMsgTOBUpdateOpDeleteActivitiesOps(dtKey string, list ActivityList) []SyncOp {
+	ops := []SyncOp{}
+	for _, act := range list {
+		ops = append(ops, MakeRemoveSyncOp(MakeActivityPath(dtKey, act.ID), ""))
+	}
+	return ops
+}
+
+// CopyActivities copies activities, or a whole day, of the trip into a
+// day of another trip, where they show up live for the other members.
+func (svc *service) CopyActivities(ctx context.Context, ID string, opts CopyActivitiesOptions) error {
+	trip, err := svc.tripFromContext(ctx, ID)
+	if err != nil {
+		return err
+	}
+	dest, err := svc.store.Read(ctx, opts.DestTripID)
+	if err != nil {
+		return err
+	}
+	if dest.Deleted {
+		return ErrTripNotFound
+	}
+	itin, ok := trip.Itineraries[opts.DtKey]
+	if !ok {
+		return ErrItineraryNotFound
+	}
+	destItin, ok := dest.Itineraries[opts.DestDtKey]
+	if !ok {
+		return ErrItineraryNotFound
+	}
+	if opts.Move && ID == opts.DestTripID && opts.DtKey == opts.DestDtKey {
+		return nil
+	}
+
+	ci, err := reqctx.ClientInfoFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+	copies, ops, err := itin.CopyActivities(opts.ActivityIDs, destItin, opts.DestDtKey, ci.UserID)
+	if err != nil || len(copies) == 0 {
+		return err
+	}
+	if err := svc.syncUpdate(ctx, opts.DestTripID, SyncMsgTOBUpdateOpCopyActivities, ops); err != nil {
+		return err
+	}
+	// The originals are only removed once the destination
+	// coordinator has applied the copies.
+	if !opts.Move {
+		return nil
+	}
+
+	moved := ActivityList{}
+	if len(opts.ActivityIDs) == 0 {
+		moved = itin.SortActivities()
+	} else {
+		for _, actID := range opts.ActivityIDs {
+			moved = append(moved, itin.Activities[actID])
+		}
+	}
+	return svc.syncUpdate(
+		ctx,
+		ID,
+		SyncMsgTOBUpdateOpDeleteActivity,
+		MakeSyncMsgTOBUpdateOpDeleteActivitiesOps(opts.DtKey, moved),
+	)
+}
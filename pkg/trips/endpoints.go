@@ -120,8 +120,9 @@ type ListRequest struct {
 }
 
 type ListResponse struct {
-	Trips TripsList `json:"trips"`
-	Err   error     `json:"error,omitempty"`
+	Trips      TripsList `json:"trips"`
+	NextCursor string    `json:"nextCursor,omitempty"`
+	Err        error     `json:"error,omitempty"`
 }
 
 func (r ListResponse) Error() error {
@@ -129,9 +130,10 @@ func (r ListResponse) Error() error {
 }
 
 type ListWithMembersResponse struct {
-	Trips   TripsList     `json:"trips"`
-	Members auth.UsersMap `json:"members"`
-	Err     error         `json:"error,omitempty"`
+	Trips      TripsList     `json:"trips"`
+	Members    auth.UsersMap `json:"members"`
+	NextCursor string        `json:"nextCursor,omitempty"`
+	Err        error         `json:"error,omitempty"`
 }
 
 func (r ListWithMembersResponse) Error() error {
@@ -147,11 +149,18 @@ func NewListEndpoint(svc Service) endpoint.Endpoint {
 		if req.WithMembers {
 			trips, members, err := svc.ListWithMembers(ctx, req.ListFilter)
 			return ListWithMembersResponse{
-				Trips: trips, Members: members, Err: err,
+				Trips:      trips,
+				Members:    members,
+				NextCursor: req.ListFilter.NextCursor(trips),
+				Err:        err,
 			}, nil
 		}
 		trips, err := svc.List(ctx, req.ListFilter)
-		return ListResponse{Trips: trips, Err: err}, nil
+		return ListResponse{
+			Trips:      trips,
+			NextCursor: req.ListFilter.NextCursor(trips),
+			Err:        err,
+		}, nil
 	}
 }
 
@@ -178,6 +187,68 @@ func NewDeleteEndpoint(svc Service) endpoint.Endpoint {
 	}
 }
 
+type ArchiveRequest struct {
+	ID       string `json:"id"`
+	Archived bool   `json:"archived"`
+}
+
+type ArchiveResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r ArchiveResponse) Error() error {
+	return r.Err
+}
+
+func NewArchiveEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(ArchiveRequest)
+		if !ok {
+			return ArchiveResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		err := svc.Archive(ctx, req.ID, req.Archived)
+		return ArchiveResponse{Err: err}, nil
+	}
+}
+
+type ListTrashRequest struct {
+	UserID string `json:"userID"`
+}
+
+func NewListTrashEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(ListTrashRequest)
+		if !ok {
+			return ListResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		trips, err := svc.ListTrash(ctx, req.UserID)
+		return ListResponse{Trips: trips, Err: err}, nil
+	}
+}
+
+type RestoreRequest struct {
+	ID string `json:"id"`
+}
+
+type RestoreResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r RestoreResponse) Error() error {
+	return r.Err
+}
+
+func NewRestoreEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(RestoreRequest)
+		if !ok {
+			return RestoreResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		err := svc.Restore(ctx, req.ID)
+		return RestoreResponse{Err: err}, nil
+	}
+}
+
 type DeleteAttachmentRequest struct {
 	ID  string         `json:"id"`
 	Obj storage.Object `json:"object"`
@@ -359,3 +430,311 @@ func NewDeleteMediaItemsEndpoint(svc Service) endpoint.Endpoint {
 		return DeleteMediaItemsResponse{Err: err}, nil
 	}
 }
+
+type ExportItineraryRequest struct {
+	ID     string `json:"id"`
+	Format string `json:"format"`
+	Date   string `json:"date"`
+}
+
+type ExportItineraryResponse struct {
+	File ExportFile `json:"file"`
+	Err  error      `json:"error,omitempty"`
+}
+
+func (r ExportItineraryResponse) Error() error {
+	return r.Err
+}
+
+func NewExportItineraryEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(ExportItineraryRequest)
+		if !ok {
+			return ExportItineraryResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		file, err := svc.ExportItinerary(ctx, req.ID, req.Format, req.Date)
+		return ExportItineraryResponse{File: file, Err: err}, nil
+	}
+}
+
+type GenerateBookletRequest struct {
+	ID   string         `json:"id"`
+	Opts BookletOptions `json:"opts"`
+}
+
+type GenerateBookletResponse = ExportItineraryResponse
+
+func NewGenerateBookletEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(GenerateBookletRequest)
+		if !ok {
+			return GenerateBookletResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		file, err := svc.GenerateBooklet(ctx, req.ID, req.Opts)
+		return GenerateBookletResponse{File: file, Err: err}, nil
+	}
+}
+
+type CopyActivitiesRequest struct {
+	ID   string                `json:"id"`
+	Opts CopyActivitiesOptions `json:"opts"`
+}
+
+type CopyActivitiesResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r CopyActivitiesResponse) Error() error {
+	return r.Err
+}
+
+func NewCopyActivitiesEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(CopyActivitiesRequest)
+		if !ok {
+			return CopyActivitiesResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		err := svc.CopyActivities(ctx, req.ID, req.Opts)
+		return CopyActivitiesResponse{Err: err}, nil
+	}
+}
+
+type ReadLedgerRequest struct {
+	ID       string `json:"id"`
+	Currency string `json:"currency"`
+}
+
+type ReadLedgerResponse struct {
+	Ledger Ledger `json:"ledger"`
+	Err    error  `json:"error,omitempty"`
+}
+
+func (r ReadLedgerResponse) Error() error {
+	return r.Err
+}
+
+func NewReadLedgerEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(ReadLedgerRequest)
+		if !ok {
+			return ReadLedgerResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		ledger, err := svc.ReadLedger(ctx, req.ID, req.Currency)
+		return ReadLedgerResponse{Ledger: ledger, Err: err}, nil
+	}
+}
+
+type ReadBudgetSummaryRequest struct {
+	ID       string `json:"id"`
+	Currency string `json:"currency"`
+}
+
+type ReadBudgetSummaryResponse struct {
+	Summary BudgetSummary `json:"summary"`
+	Err     error         `json:"error,omitempty"`
+}
+
+func (r ReadBudgetSummaryResponse) Error() error {
+	return r.Err
+}
+
+func NewReadBudgetSummaryEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(ReadBudgetSummaryRequest)
+		if !ok {
+			return ReadBudgetSummaryResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		summary, err := svc.ReadBudgetSummary(ctx, req.ID, req.Currency)
+		return ReadBudgetSummaryResponse{Summary: summary, Err: err}, nil
+	}
+}
+
+type ExportExpensesRequest struct {
+	ID       string `json:"id"`
+	Format   string `json:"format"`
+	Currency string `json:"currency"`
+}
+
+type ExportExpensesResponse = ExportItineraryResponse
+
+func NewExportExpensesEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(ExportExpensesRequest)
+		if !ok {
+			return ExportExpensesResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		file, err := svc.ExportExpenses(ctx, req.ID, req.Format, req.Currency)
+		return ExportExpensesResponse{File: file, Err: err}, nil
+	}
+}
+
+type ImportBudgetItemsRequest struct {
+	ID   string `json:"id"`
+	Data []byte `json:"data"`
+}
+
+type ImportBudgetItemsResponse struct {
+	Items BudgetItemsList `json:"items"`
+	Err   error           `json:"error,omitempty"`
+}
+
+func (r ImportBudgetItemsResponse) Error() error {
+	return r.Err
+}
+
+func NewImportBudgetItemsEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(ImportBudgetItemsRequest)
+		if !ok {
+			return ImportBudgetItemsResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		items, err := svc.ImportBudgetItems(ctx, req.ID, req.Data)
+		return ImportBudgetItemsResponse{Items: items, Err: err}, nil
+	}
+}
+
+type AcceptPollOptionRequest struct {
+	ID       string `json:"id"`
+	PollID   string `json:"pollID"`
+	OptionID string `json:"optionID"`
+}
+
+type AcceptPollOptionResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r AcceptPollOptionResponse) Error() error {
+	return r.Err
+}
+
+func NewAcceptPollOptionEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(AcceptPollOptionRequest)
+		if !ok {
+			return AcceptPollOptionResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		err := svc.AcceptPollOption(ctx, req.ID, req.PollID, req.OptionID)
+		return AcceptPollOptionResponse{Err: err}, nil
+	}
+}
+
+type UpdateMemberRoleRequest struct {
+	ID       string `json:"id"`
+	MemberID string `json:"memberID"`
+	Role     string `json:"role"`
+}
+
+type UpdateMemberRoleResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r UpdateMemberRoleResponse) Error() error {
+	return r.Err
+}
+
+func NewUpdateMemberRoleEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(UpdateMemberRoleRequest)
+		if !ok {
+			return UpdateMemberRoleResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		err := svc.UpdateMemberRole(ctx, req.ID, req.MemberID, req.Role)
+		return UpdateMemberRoleResponse{Err: err}, nil
+	}
+}
+
+type TransferOwnershipRequest struct {
+	ID       string `json:"id"`
+	MemberID string `json:"memberID"`
+}
+
+type TransferOwnershipResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r TransferOwnershipResponse) Error() error {
+	return r.Err
+}
+
+func NewTransferOwnershipEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(TransferOwnershipRequest)
+		if !ok {
+			return TransferOwnershipResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		err := svc.TransferOwnership(ctx, req.ID, req.MemberID)
+		return TransferOwnershipResponse{Err: err}, nil
+	}
+}
+
+type RemoveMemberRequest struct {
+	ID       string `json:"id"`
+	MemberID string `json:"memberID"`
+}
+
+type RemoveMemberResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r RemoveMemberResponse) Error() error {
+	return r.Err
+}
+
+func NewRemoveMemberEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(RemoveMemberRequest)
+		if !ok {
+			return RemoveMemberResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		err := svc.RemoveMember(ctx, req.ID, req.MemberID)
+		return RemoveMemberResponse{Err: err}, nil
+	}
+}
+
+type LeaveTripRequest struct {
+	ID       string `json:"id"`
+	MemberID string `json:"memberID"`
+}
+
+type LeaveTripResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r LeaveTripResponse) Error() error {
+	return r.Err
+}
+
+func NewLeaveTripEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(LeaveTripRequest)
+		if !ok {
+			return LeaveTripResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		err := svc.LeaveTrip(ctx, req.ID, req.MemberID)
+		return LeaveTripResponse{Err: err}, nil
+	}
+}
+
+type UpdatePrivacyPolicyRequest struct {
+	ID      string        `json:"id"`
+	Privacy PrivacyPolicy `json:"privacy"`
+}
+
+type UpdatePrivacyPolicyResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r UpdatePrivacyPolicyResponse) Error() error {
+	return r.Err
+}
+
+func NewUpdatePrivacyPolicyEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, epReq interface{}) (interface{}, error) {
+		req, ok := epReq.(UpdatePrivacyPolicyRequest)
+		if !ok {
+			return UpdatePrivacyPolicyResponse{Err: common.ErrEndpointReqMismatch}, nil
+		}
+		err := svc.UpdatePrivacyPolicy(ctx, req.ID, req.Privacy)
+		return UpdatePrivacyPolicyResponse{Err: err}, nil
+	}
+}
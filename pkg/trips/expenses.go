@@ -0,0 +1,159 @@
+package trips
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/travelreys/travelreys/pkg/common"
+	"github.com/travelreys/travelreys/pkg/finance"
+	"github.com/travelreys/travelreys/pkg/storage"
+)
+
+const (
+	ExpenseCategoryActivity = "activity"
+	ExpenseCategoryLodging  = "lodging"
+	ExpenseCategoryTransit  = "transit"
+	ExpenseCategoryBudget   = "budget"
+)
+
+// Expense is a price item of the trip, together with where it comes from.
+type Expense struct {
+	// Path is the JSON path of the price item in the trip
+	Path      string            `json:"path"`
+	Category  string            `json:"category"`
+	Title     string            `json:"title"`
+	Date      time.Time         `json:"date"`
+	EndDate   time.Time         `json:"endDate"`
+	PriceItem finance.PriceItem `json:"price"`
+	Labels    common.Labels     `json:"labels"`
+	Tags      common.Tags       `json:"tags"`
+
+	Receipts storage.ObjectList `json:"receipts"`
+}
+
+type ExpenseList []Expense
+
+// Payer returns the ID of the member who paid for the expense. It
+// defaults to the member who created the entry, then the trip creator.
+func (e Expense) Payer(trip *Trip) string {
+	if e.PriceItem.PaidBy != "" {
+		return e.PriceItem.PaidBy
+	}
+	if createdBy := e.Labels[LabelCreatedBy]; createdBy != "" {
+		return createdBy
+	}
+	return trip.Creator.ID
+}
+
+// Expenses walks the trip for all price items with an amount.
+// Ideas are not expenses until they are moved to the itinerary.
+func (trip Trip) Expenses() ExpenseList {
+	result := ExpenseList{}
+	for _, e := range trip.priceItems() {
+		if e.PriceItem.Amount != 0 && !strings.HasPrefix(e.Path, JSONPathIdeasRoot) {
+			e.Receipts = trip.receiptFiles(e.PriceItem)
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// priceItems walks the trip for all price items, including ideas.
+func (trip Trip) priceItems() ExpenseList {
+	expenses := ExpenseList{}
+
+	for _, dtKey := range GetSortedItineraryKeys(&trip) {
+		itin := trip.Itineraries[dtKey]
+		for _, act := range itin.SortActivities() {
+			date := act.StartTime
+			if date.IsZero() {
+				date = itin.GetDate()
+			}
+			title := act.Title
+			if title == "" {
+				title = act.Place.Name
+			}
+			expenses = append(expenses, Expense{
+				Path:      fmt.Sprintf("%s/price", MakeActivityPath(dtKey, act.ID)),
+				Category:  ExpenseCategoryActivity,
+				Title:     title,
+				Date:      date,
+				PriceItem: act.PriceItem,
+				Labels:    act.Labels,
+				Tags:      common.Tags{},
+			})
+		}
+	}
+
+	for _, l := range sortedLodgings(trip.Lodgings) {
+		expenses = append(expenses, Expense{
+			Path:      fmt.Sprintf("/lodgings/%s/price", l.ID),
+			Category:  ExpenseCategoryLodging,
+			Title:     l.Place.Name,
+			Date:      l.CheckinTime,
+			EndDate:   l.CheckoutTime,
+			PriceItem: l.PriceItem,
+			Labels:    l.Labels,
+			Tags:      l.Tags,
+		})
+	}
+
+	transits := []*BaseTransit{}
+	for _, t := range trip.Transits {
+		transits = append(transits, t)
+	}
+	sort.SliceStable(transits, func(i, j int) bool {
+		return transits[i].DepartTime.Before(transits[j].DepartTime)
+	})
+	for _, t := range transits {
+		expenses = append(expenses, Expense{
+			Path:      fmt.Sprintf("/transits/%s/price", t.ID),
+			Category:  ExpenseCategoryTransit,
+			Title:     fmt.Sprintf("%s - %s", t.DepartLocation.Name, t.ArrivalLocation.Name),
+			Date:      t.DepartTime,
+			PriceItem: t.PriceItem,
+			Labels:    t.Labels,
+			Tags:      t.Tags,
+		})
+	}
+
+	for idx, item := range trip.Budget.Items {
+		expenses = append(expenses, Expense{
+			Path:      fmt.Sprintf("/budget/items/%d/price", idx),
+			Category:  ExpenseCategoryBudget,
+			Title:     item.Title,
+			PriceItem: item.PriceItem,
+			Labels:    item.Labels,
+			Tags:      item.Tag,
+		})
+	}
+
+	for _, act := range trip.Ideas {
+		expenses = append(expenses, Expense{
+			Path:      fmt.Sprintf("%s/price", MakeIdeaPath(act.ID)),
+			Category:  ExpenseCategoryActivity,
+			Title:     act.Title,
+			PriceItem: act.PriceItem,
+			Labels:    act.Labels,
+			Tags:      common.Tags{},
+		})
+	}
+	return expenses
+}
+
+// TransactionDates returns the dates of the trip's expenses and
+// settlements, for which exchange rates are needed.
+func (trip Trip) TransactionDates() []time.Time {
+	dates := []time.Time{}
+	for _, e := range trip.Expenses() {
+		if !e.Date.IsZero() {
+			dates = append(dates, e.Date)
+		}
+	}
+	for _, s := range trip.Settlements {
+		dates = append(dates, s.CreatedAt)
+	}
+	return dates
+}
@@ -0,0 +1,367 @@
+package trips
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/travelreys/travelreys/pkg/common"
+	"github.com/travelreys/travelreys/pkg/finance"
+)
+
+const (
+	ExpenseExportFormatCSV = "csv"
+	// ExpenseExportFormatSplitwise follows the CSV layout used by
+	// Splitwise, with the net balance of each member per expense.
+	ExpenseExportFormatSplitwise = "splitwise"
+
+	expensesCSVContentType = "text/csv; charset=utf-8"
+	expensesCSVDateLayout  = "2006-01-02"
+
+	// maxImportBudgetItems caps the number of rows in a CSV import
+	maxImportBudgetItems        = 500
+	maxImportBudgetItemsCSVSize = 1 << 20
+
+	// csvFormulaPrefixes start the cells evaluated by spreadsheets
+	csvFormulaPrefixes = "=+-@\t\r"
+)
+
+var (
+	ExpenseExportFormatsList = []string{
+		ExpenseExportFormatCSV,
+		ExpenseExportFormatSplitwise,
+	}
+
+	ErrInvalidExpensesCSV = errors.New("trips.ErrInvalidExpensesCSV")
+
+	// currencyCodeRegex matches ISO 4217 currency codes, e.g SGD
+	currencyCodeRegex = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// ExportExpenses writes the trip's expenses, their split targets and the
+// per-member totals, converted into the base currency of the rates.
+// names maps member IDs to display names.
+func ExportExpenses(
+	trip *Trip,
+	format string,
+	rates finance.ExchangeRatesByDate,
+	names map[string]string,
+) (ExportFile, error) {
+	memberIDs := trip.GetMemberIDs()
+	sort.SliceStable(memberIDs, func(i, j int) bool {
+		return memberName(names, memberIDs[i]) < memberName(names, memberIDs[j])
+	})
+
+	var (
+		rows [][]string
+		err  error
+	)
+	switch format {
+	case ExpenseExportFormatCSV:
+		rows = expensesCSVRows(trip, memberIDs, rates, names)
+	case ExpenseExportFormatSplitwise:
+		rows = expensesSplitwiseRows(trip, memberIDs, names)
+	default:
+		return ExportFile{}, ErrInvalidExportFormat
+	}
+
+	for _, row := range rows {
+		for idx, cell := range row {
+			row[idx] = escapeCSVFormula(cell)
+		}
+	}
+
+	buf := bytes.Buffer{}
+	w := csv.NewWriter(&buf)
+	if err = w.WriteAll(rows); err != nil {
+		return ExportFile{}, err
+	}
+	return ExportFile{
+		Filename:    exportFilename(trip, "expenses", "csv"),
+		ContentType: expensesCSVContentType,
+		Data:        buf.Bytes(),
+	}, nil
+}
+
+// escapeCSVFormula prefixes the cells which spreadsheets would evaluate
+// as formulas, e.g =HYPERLINK(...) in a title, with a quote. Numbers,
+// e.g negative balances, are left as they are.
+func escapeCSVFormula(cell string) string {
+	if cell == "" || !strings.ContainsRune(csvFormulaPrefixes, rune(cell[0])) {
+		return cell
+	}
+	if _, err := strconv.ParseFloat(cell, 64); err == nil {
+		return cell
+	}
+	return "'" + cell
+}
+
+// unescapeCSVFormula removes the quote added by escapeCSVFormula.
+func unescapeCSVFormula(cell string) string {
+	if len(cell) > 1 && cell[0] == '\'' && strings.ContainsRune(csvFormulaPrefixes, rune(cell[1])) {
+		return cell[1:]
+	}
+	return cell
+}
+
+func expensesCSVRows(
+	trip *Trip,
+	memberIDs []string,
+	rates finance.ExchangeRatesByDate,
+	names map[string]string,
+) [][]string {
+	header := []string{
+		"Date", "Category", "Title", "Amount", "Currency",
+		fmt.Sprintf("Amount (%s)", rates.Base), "Paid By", "Split Method",
+	}
+	for _, id := range memberIDs {
+		header = append(header, memberName(names, id))
+	}
+	header = append(header, "Receipts")
+	rows := [][]string{header}
+
+	paid, owed := map[string]float64{}, map[string]float64{}
+	for _, e := range trip.Expenses() {
+		payer := e.Payer(trip)
+		shares := expenseShares(e, payer)
+
+		converted := ""
+		rate := 0.0
+		if amount, ok := rates.Convert(e.PriceItem.Amount, e.PriceItem.Currency, e.Date); ok {
+			converted = formatAmount(amount)
+			rate = amount / e.PriceItem.Amount
+			paid[payer] += amount
+			for id, share := range shares {
+				owed[id] += share * rate
+			}
+		}
+
+		row := []string{
+			formatExpenseDate(e),
+			e.BudgetCategory(),
+			e.Title,
+			formatAmount(e.PriceItem.Amount),
+			e.PriceItem.Currency,
+			converted,
+			memberName(names, payer),
+			e.PriceItem.SplitOptions.Method,
+		}
+		for _, id := range memberIDs {
+			row = append(row, formatAmount(shares[id]))
+		}
+		receipts := []string{}
+		for _, obj := range e.Receipts {
+			receipts = append(receipts, obj.Name)
+		}
+		rows = append(rows, append(row, strings.Join(receipts, ";")))
+	}
+
+	// Separate the totals with a blank row, which the CSV
+	// reader does not skip, unlike an empty line.
+	rows = append(rows, make([]string, len(header)))
+	rows = append(rows, []string{
+		"Member",
+		fmt.Sprintf("Paid (%s)", rates.Base),
+		fmt.Sprintf("Share (%s)", rates.Base),
+		fmt.Sprintf("Balance (%s)", rates.Base),
+	})
+	for _, id := range memberIDs {
+		rows = append(rows, []string{
+			memberName(names, id),
+			formatAmount(paid[id]),
+			formatAmount(owed[id]),
+			formatAmount(paid[id] - owed[id]),
+		})
+	}
+	return rows
+}
+
+func expensesSplitwiseRows(trip *Trip, memberIDs []string, names map[string]string) [][]string {
+	header := []string{"Date", "Description", "Category", "Cost", "Currency"}
+	for _, id := range memberIDs {
+		header = append(header, memberName(names, id))
+	}
+	rows := [][]string{header}
+
+	for _, e := range trip.Expenses() {
+		payer := e.Payer(trip)
+		shares := expenseShares(e, payer)
+		row := []string{
+			formatExpenseDate(e),
+			e.Title,
+			e.BudgetCategory(),
+			formatAmount(e.PriceItem.Amount),
+			e.PriceItem.Currency,
+		}
+		for _, id := range memberIDs {
+			balance := -shares[id]
+			if id == payer {
+				balance += e.PriceItem.Amount
+			}
+			row = append(row, formatAmount(balance))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// expenseShares returns the share of each member in the expense, in its
+// currency, including settled targets. The payer bears the remainder.
+func expenseShares(e Expense, payer string) map[string]float64 {
+	shares := map[string]float64{}
+	item := e.PriceItem
+	total := 0.0
+	for id, target := range item.SplitOptions.Targets {
+		switch item.SplitOptions.Method {
+		case finance.PriceSplitMethodAbsolute:
+			shares[id] += target.Value
+		case finance.PriceSplitMethodPercentage:
+			shares[id] += item.Amount * target.Value / 100
+		}
+		total += shares[id]
+	}
+	if _, ok := shares[payer]; !ok {
+		shares[payer] = roundAmount(item.Amount - total)
+	}
+	return shares
+}
+
+func memberName(names map[string]string, id string) string {
+	if name := names[id]; name != "" {
+		return name
+	}
+	return id
+}
+
+func formatExpenseDate(e Expense) string {
+	if e.Date.IsZero() {
+		return ""
+	}
+	return e.Date.Format(expensesCSVDateLayout)
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(roundAmount(amount), 'f', 2, 64)
+}
+
+// ImportBudgetItemsCSV reads budget items from a CSV with a header row,
+// as exported by ExportExpenses. Title (or Description) and Amount (or
+// Cost) columns are required. Rows stop at the first empty row.
+func ImportBudgetItemsCSV(r io.Reader, defaultCurrency string) (BudgetItemsList, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, ErrInvalidExpensesCSV
+	}
+	cols := map[string]int{}
+	for idx, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = idx
+	}
+	titleCol, ok := csvColumn(cols, "title", "description")
+	if !ok {
+		return nil, ErrInvalidExpensesCSV
+	}
+	amountCol, ok := csvColumn(cols, "amount", "cost")
+	if !ok {
+		return nil, ErrInvalidExpensesCSV
+	}
+	currencyCol, hasCurrency := csvColumn(cols, "currency")
+	categoryCol, hasCategory := csvColumn(cols, "category")
+	descCol, hasDesc := csvColumn(cols, "notes", "desc")
+
+	items := BudgetItemsList{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, ErrInvalidExpensesCSV
+		}
+		if isBlankCSVRecord(record) {
+			break
+		}
+		if len(items) >= maxImportBudgetItems {
+			return nil, ErrInvalidExpensesCSV
+		}
+
+		amount, err := strconv.ParseFloat(strings.TrimSpace(csvValue(record, amountCol)), 64)
+		if err != nil || math.IsNaN(amount) || math.IsInf(amount, 0) || amount < 0 {
+			return nil, ErrInvalidExpensesCSV
+		}
+		currency := defaultCurrency
+		if hasCurrency && csvValue(record, currencyCol) != "" {
+			currency = strings.ToUpper(strings.TrimSpace(csvValue(record, currencyCol)))
+		}
+		if !currencyCodeRegex.MatchString(currency) {
+			return nil, ErrInvalidExpensesCSV
+		}
+		item := &BudgetItem{
+			Title: csvValue(record, titleCol),
+			PriceItem: finance.PriceItem{
+				Price: finance.Price{Amount: amount, Currency: currency},
+				SplitOptions: finance.PriceSplitOptions{
+					Method:  finance.PriceSplitMethodSolo,
+					Targets: map[string]finance.PriceSplitTarget{},
+				},
+				Receipts: []string{},
+			},
+			Labels: common.Labels{},
+			Tag:    common.Tags{},
+		}
+		if hasDesc {
+			item.Desc = csvValue(record, descCol)
+		}
+		if hasCategory && csvValue(record, categoryCol) != "" {
+			item.Tag[TagBudgetCategory] = csvValue(record, categoryCol)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func csvColumn(cols map[string]int, names ...string) (int, bool) {
+	for _, name := range names {
+		if idx, ok := cols[name]; ok {
+			return idx, true
+		}
+	}
+	return 0, false
+}
+
+func isBlankCSVRecord(record []string) bool {
+	for _, val := range record {
+		if strings.TrimSpace(val) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func csvValue(record []string, idx int) string {
+	if idx >= len(record) {
+		return ""
+	}
+	return unescapeCSVFormula(strings.TrimSpace(record[idx]))
+}
+
+// Budget Sync Ops
+
+// SyncMsgTOBUpdateOpImportBudgetItems
+func MakeSyncMsgTOBUpdateOpImportBudgetItemsOps(items BudgetItemsList) []SyncOp {
+	ops := []SyncOp{}
+	for _, item := range items {
+		ops = append(ops, MakeAddSyncOp("/budget/items/-", item))
+	}
+	return ops
+}
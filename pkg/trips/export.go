@@ -0,0 +1,542 @@
+package trips
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/travelreys/travelreys/pkg/maps"
+)
+
+const (
+	ExportFormatGPX     = "gpx"
+	ExportFormatKML     = "kml"
+	ExportFormatGeoJSON = "geojson"
+
+	exportCreator      = "travelreys"
+	exportDefaultColor = "7c3aed"
+
+	exportKindActivity = "activity"
+	exportKindLodging  = "lodging"
+)
+
+var (
+	ErrInvalidExportFormat = errors.New("trips.ErrInvalidExportFormat")
+	ErrItineraryNotFound   = errors.New("trips.ErrItineraryNotFound")
+
+	ExportFormatsList = []string{
+		ExportFormatGPX,
+		ExportFormatKML,
+		ExportFormatGeoJSON,
+	}
+
+	exportColorRegexp    = regexp.MustCompile(`^#?([0-9a-fA-F]{6})$`)
+	exportFilenameRegexp = regexp.MustCompile(`[^a-zA-Z0-9_\-]+`)
+)
+
+// ExportFile is a rendered document ready to be downloaded by clients.
+type ExportFile struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType"`
+	Data        []byte `json:"-"`
+}
+
+type exportWaypoint struct {
+	Name   string
+	Desc   string
+	Kind   string
+	Color  string
+	LatLng maps.LatLng
+}
+
+type exportTrack struct {
+	Name     string
+	Mode     string
+	Color    string
+	Distance int
+	Duration time.Duration
+	Points   []maps.LatLng
+}
+
+// exportSection groups the waypoints and tracks of a single itinerary day.
+type exportSection struct {
+	Name      string
+	Waypoints []exportWaypoint
+	Tracks    []exportTrack
+}
+
+type exportDoc struct {
+	Name     string
+	Lodgings []exportWaypoint
+	Sections []exportSection
+}
+
+// ExportItinerary renders the trip's itineraries in the given format.
+// If dtKey is not empty, only the itinerary of that day is exported.
+func ExportItinerary(trip *Trip, format, dtKey string) (ExportFile, error) {
+	doc, err := makeExportDoc(trip, dtKey)
+	if err != nil {
+		return ExportFile{}, err
+	}
+
+	var (
+		data        []byte
+		contentType string
+	)
+	switch format {
+	case ExportFormatGPX:
+		data, err = doc.toGPX()
+		contentType = "application/gpx+xml"
+	case ExportFormatKML:
+		data, err = doc.toKML()
+		contentType = "application/vnd.google-earth.kml+xml"
+	case ExportFormatGeoJSON:
+		data, err = doc.toGeoJSON()
+		contentType = "application/geo+json"
+	default:
+		return ExportFile{}, ErrInvalidExportFormat
+	}
+	if err != nil {
+		return ExportFile{}, err
+	}
+
+	return ExportFile{
+		Filename:    exportFilename(trip, dtKey, format),
+		ContentType: contentType,
+		Data:        data,
+	}, nil
+}
+
+func exportFilename(trip *Trip, dtKey, ext string) string {
+	name := exportFilenameRegexp.ReplaceAllString(trip.Name, "-")
+	name = strings.Trim(name, "-")
+	if name == "" {
+		name = trip.ID
+	}
+	if dtKey != "" {
+		name = fmt.Sprintf("%s-%s", name, dtKey)
+	}
+	return fmt.Sprintf("%s.%s", name, ext)
+}
+
+// exportColor normalises LabelUiColor values (e.g #7c3aed) into
+// a lowercase rrggbb hex string.
+func exportColor(label string) string {
+	matches := exportColorRegexp.FindStringSubmatch(label)
+	if len(matches) < 2 {
+		return exportDefaultColor
+	}
+	return strings.ToLower(matches[1])
+}
+
+func hasLatLng(place maps.Place) bool {
+	return !(place.LatLng.Lat == 0 && place.LatLng.Lng == 0)
+}
+
+func makeExportDoc(trip *Trip, dtKey string) (exportDoc, error) {
+	doc := exportDoc{Name: trip.Name}
+
+	dtKeys := GetSortedItineraryKeys(trip)
+	if dtKey != "" {
+		if _, ok := trip.Itineraries[dtKey]; !ok {
+			return doc, ErrItineraryNotFound
+		}
+		dtKeys = []string{dtKey}
+	}
+
+	lodgings := trip.Lodgings
+	if dtKey != "" {
+		lodgings = lodgings.GetLodgingsForDate(trip.Itineraries[dtKey].GetDate())
+	}
+	lodgingIDs := []string{}
+	for id := range lodgings {
+		lodgingIDs = append(lodgingIDs, id)
+	}
+	sort.Strings(lodgingIDs)
+	for _, id := range lodgingIDs {
+		lod := lodgings[id]
+		if !hasLatLng(lod.Place) {
+			continue
+		}
+		doc.Lodgings = append(doc.Lodgings, exportWaypoint{
+			Name:   lod.Place.Name,
+			Desc:   lod.Place.Address,
+			Kind:   exportKindLodging,
+			Color:  exportDefaultColor,
+			LatLng: lod.Place.LatLng,
+		})
+	}
+
+	places := map[string]maps.Place{}
+	for _, lod := range trip.Lodgings {
+		places[lod.ID] = lod.Place
+	}
+
+	for _, key := range dtKeys {
+		itin := trip.Itineraries[key]
+		color := exportColor(itin.Labels[LabelUiColor])
+		section := exportSection{Name: key}
+
+		for _, act := range itin.SortActivities() {
+			places[act.ID] = act.Place
+			if !hasLatLng(act.Place) {
+				continue
+			}
+			name := act.Title
+			if name == "" {
+				name = act.Place.Name
+			}
+			desc := act.Place.Address
+			if act.Notes != "" {
+				desc = strings.TrimSpace(fmt.Sprintf("%s\n%s", desc, act.Notes))
+			}
+			section.Waypoints = append(section.Waypoints, exportWaypoint{
+				Name:   name,
+				Desc:   desc,
+				Kind:   exportKindActivity,
+				Color:  color,
+				LatLng: act.Place.LatLng,
+			})
+		}
+
+		pairs := []string{}
+		for pair := range itin.Routes {
+			pairs = append(pairs, pair)
+		}
+		sort.Strings(pairs)
+		for _, pair := range pairs {
+			ids := strings.Split(pair, LabelDelimeter)
+			if len(ids) != 2 {
+				continue
+			}
+			orig, dest := places[ids[0]], places[ids[1]]
+			if t, ok := trip.Transits[ids[0]]; ok {
+				orig = t.ArrivalLocation
+			}
+			if t, ok := trip.Transits[ids[1]]; ok {
+				dest = t.DepartLocation
+			}
+			name := fmt.Sprintf("%s - %s", orig.Name, dest.Name)
+			for _, route := range itin.Routes[pair] {
+				points, err := route.Polyline.Decode()
+				if err != nil || len(points) == 0 {
+					continue
+				}
+				section.Tracks = append(section.Tracks, exportTrack{
+					Name:     name,
+					Mode:     route.TravelMode,
+					Color:    color,
+					Distance: route.Distance,
+					Duration: route.Duration,
+					Points:   points,
+				})
+			}
+		}
+		doc.Sections = append(doc.Sections, section)
+	}
+	return doc, nil
+}
+
+// GPX
+
+type gpxDoc struct {
+	XMLName    xml.Name    `xml:"gpx"`
+	Version    string      `xml:"version,attr"`
+	Creator    string      `xml:"creator,attr"`
+	Xmlns      string      `xml:"xmlns,attr"`
+	XmlnsStyle string      `xml:"xmlns:gpx_style,attr"`
+	Metadata   gpxMetadata `xml:"metadata"`
+	Waypoints  []gpxWpt    `xml:"wpt"`
+	Tracks     []gpxTrack  `xml:"trk"`
+}
+
+type gpxMetadata struct {
+	Name string `xml:"name"`
+}
+
+type gpxWpt struct {
+	Lat  float64 `xml:"lat,attr"`
+	Lon  float64 `xml:"lon,attr"`
+	Name string  `xml:"name"`
+	Desc string  `xml:"desc,omitempty"`
+	Type string  `xml:"type,omitempty"`
+}
+
+type gpxTrack struct {
+	Name       string        `xml:"name"`
+	Type       string        `xml:"type,omitempty"`
+	Extensions gpxExtensions `xml:"extensions"`
+	Segment    gpxSegment    `xml:"trkseg"`
+}
+
+type gpxExtensions struct {
+	Line gpxStyleLine `xml:"gpx_style:line"`
+}
+
+type gpxStyleLine struct {
+	Color string `xml:"gpx_style:color"`
+}
+
+type gpxSegment struct {
+	Points []gpxPoint `xml:"trkpt"`
+}
+
+type gpxPoint struct {
+	Lat float64 `xml:"lat,attr"`
+	Lon float64 `xml:"lon,attr"`
+}
+
+func (doc exportDoc) toGPX() ([]byte, error) {
+	gpx := gpxDoc{
+		Version:    "1.1",
+		Creator:    exportCreator,
+		Xmlns:      "http://www.topografix.com/GPX/1/1",
+		XmlnsStyle: "http://www.topografix.com/GPX/gpx_style/0/2",
+		Metadata:   gpxMetadata{Name: doc.Name},
+	}
+	for _, wp := range doc.Lodgings {
+		gpx.Waypoints = append(gpx.Waypoints, makeGPXWpt(wp))
+	}
+	for _, section := range doc.Sections {
+		for _, wp := range section.Waypoints {
+			gpx.Waypoints = append(gpx.Waypoints, makeGPXWpt(wp))
+		}
+		for _, trk := range section.Tracks {
+			points := []gpxPoint{}
+			for _, ll := range trk.Points {
+				points = append(points, gpxPoint{Lat: ll.Lat, Lon: ll.Lng})
+			}
+			gpx.Tracks = append(gpx.Tracks, gpxTrack{
+				Name:       fmt.Sprintf("%s: %s", section.Name, trk.Name),
+				Type:       trk.Mode,
+				Extensions: gpxExtensions{Line: gpxStyleLine{Color: trk.Color}},
+				Segment:    gpxSegment{Points: points},
+			})
+		}
+	}
+	return marshalXMLDoc(gpx)
+}
+
+func makeGPXWpt(wp exportWaypoint) gpxWpt {
+	return gpxWpt{
+		Lat:  wp.LatLng.Lat,
+		Lon:  wp.LatLng.Lng,
+		Name: wp.Name,
+		Desc: wp.Desc,
+		Type: wp.Kind,
+	}
+}
+
+// KML
+
+type kmlDoc struct {
+	XMLName  xml.Name    `xml:"kml"`
+	Xmlns    string      `xml:"xmlns,attr"`
+	Document kmlDocument `xml:"Document"`
+}
+
+type kmlDocument struct {
+	Name       string         `xml:"name"`
+	Styles     []kmlStyle     `xml:"Style"`
+	Placemarks []kmlPlacemark `xml:"Placemark"`
+	Folders    []kmlFolder    `xml:"Folder"`
+}
+
+type kmlStyle struct {
+	ID        string       `xml:"id,attr"`
+	IconStyle kmlIconStyle `xml:"IconStyle"`
+	LineStyle kmlLineStyle `xml:"LineStyle"`
+}
+
+type kmlIconStyle struct {
+	Color string `xml:"color"`
+}
+
+type kmlLineStyle struct {
+	Color string `xml:"color"`
+	Width int    `xml:"width"`
+}
+
+type kmlFolder struct {
+	Name       string         `xml:"name"`
+	Placemarks []kmlPlacemark `xml:"Placemark"`
+}
+
+type kmlPlacemark struct {
+	Name        string         `xml:"name"`
+	Description string         `xml:"description,omitempty"`
+	StyleURL    string         `xml:"styleUrl"`
+	Point       *kmlPoint      `xml:"Point,omitempty"`
+	LineString  *kmlLineString `xml:"LineString,omitempty"`
+}
+
+type kmlPoint struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+type kmlLineString struct {
+	Tessellate  int    `xml:"tessellate"`
+	Coordinates string `xml:"coordinates"`
+}
+
+// kmlColor converts a rrggbb hex color into KML's aabbggrr format.
+func kmlColor(color string) string {
+	return fmt.Sprintf("ff%s%s%s", color[4:6], color[2:4], color[0:2])
+}
+
+func kmlStyleID(color string) string {
+	return "color-" + color
+}
+
+func kmlCoordinates(latlngs ...maps.LatLng) string {
+	coords := []string{}
+	for _, ll := range latlngs {
+		coords = append(coords, fmt.Sprintf("%f,%f,0", ll.Lng, ll.Lat))
+	}
+	return strings.Join(coords, " ")
+}
+
+func makeKMLPointPlacemark(wp exportWaypoint) kmlPlacemark {
+	return kmlPlacemark{
+		Name:        wp.Name,
+		Description: wp.Desc,
+		StyleURL:    "#" + kmlStyleID(wp.Color),
+		Point:       &kmlPoint{Coordinates: kmlCoordinates(wp.LatLng)},
+	}
+}
+
+func (doc exportDoc) toKML() ([]byte, error) {
+	kml := kmlDoc{
+		Xmlns:    "http://www.opengis.net/kml/2.2",
+		Document: kmlDocument{Name: doc.Name},
+	}
+
+	colors := map[string]bool{exportDefaultColor: true}
+	for _, wp := range doc.Lodgings {
+		kml.Document.Placemarks = append(
+			kml.Document.Placemarks, makeKMLPointPlacemark(wp),
+		)
+	}
+	for _, section := range doc.Sections {
+		folder := kmlFolder{Name: section.Name}
+		for _, wp := range section.Waypoints {
+			colors[wp.Color] = true
+			folder.Placemarks = append(folder.Placemarks, makeKMLPointPlacemark(wp))
+		}
+		for _, trk := range section.Tracks {
+			colors[trk.Color] = true
+			folder.Placemarks = append(folder.Placemarks, kmlPlacemark{
+				Name:        trk.Name,
+				Description: trk.Mode,
+				StyleURL:    "#" + kmlStyleID(trk.Color),
+				LineString: &kmlLineString{
+					Tessellate:  1,
+					Coordinates: kmlCoordinates(trk.Points...),
+				},
+			})
+		}
+		kml.Document.Folders = append(kml.Document.Folders, folder)
+	}
+
+	sortedColors := []string{}
+	for color := range colors {
+		sortedColors = append(sortedColors, color)
+	}
+	sort.Strings(sortedColors)
+	for _, color := range sortedColors {
+		kml.Document.Styles = append(kml.Document.Styles, kmlStyle{
+			ID:        kmlStyleID(color),
+			IconStyle: kmlIconStyle{Color: kmlColor(color)},
+			LineStyle: kmlLineStyle{Color: kmlColor(color), Width: 4},
+		})
+	}
+	return marshalXMLDoc(kml)
+}
+
+func marshalXMLDoc(v interface{}) ([]byte, error) {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// GeoJSON
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   geoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+func makeGeoJSONPointFeature(wp exportWaypoint, day string) geoJSONFeature {
+	props := map[string]interface{}{
+		"name":         wp.Name,
+		"description":  wp.Desc,
+		"kind":         wp.Kind,
+		"marker-color": "#" + wp.Color,
+	}
+	if day != "" {
+		props["day"] = day
+	}
+	return geoJSONFeature{
+		Type: "Feature",
+		Geometry: geoJSONGeometry{
+			Type:        "Point",
+			Coordinates: []float64{wp.LatLng.Lng, wp.LatLng.Lat},
+		},
+		Properties: props,
+	}
+}
+
+func (doc exportDoc) toGeoJSON() ([]byte, error) {
+	fc := geoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: []geoJSONFeature{},
+	}
+	for _, wp := range doc.Lodgings {
+		fc.Features = append(fc.Features, makeGeoJSONPointFeature(wp, ""))
+	}
+	for _, section := range doc.Sections {
+		for _, wp := range section.Waypoints {
+			fc.Features = append(fc.Features, makeGeoJSONPointFeature(wp, section.Name))
+		}
+		for _, trk := range section.Tracks {
+			coords := [][]float64{}
+			for _, ll := range trk.Points {
+				coords = append(coords, []float64{ll.Lng, ll.Lat})
+			}
+			fc.Features = append(fc.Features, geoJSONFeature{
+				Type: "Feature",
+				Geometry: geoJSONGeometry{
+					Type:        "LineString",
+					Coordinates: coords,
+				},
+				Properties: map[string]interface{}{
+					"name":       trk.Name,
+					"day":        section.Name,
+					"travelMode": trk.Mode,
+					"distance":   trk.Distance,
+					"duration":   trk.Duration.Seconds(),
+					"stroke":     "#" + trk.Color,
+				},
+			})
+		}
+	}
+	return json.Marshal(fc)
+}
@@ -1,14 +1,38 @@
 package trips
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/travelreys/travelreys/pkg/common"
 	"go.mongodb.org/mongo-driver/bson"
 )
 
+const (
+	ListSortByStartDate = "startDate"
+	ListSortByUpdatedAt = "updatedAt"
+
+	ListOrderAsc  = "asc"
+	ListOrderDesc = "desc"
+
+	ListStatusUpcoming = "upcoming"
+	ListStatusOngoing  = "ongoing"
+	ListStatusPast     = "past"
+
+	MaxListLimit       = 100
+	maxListQueryLength = 200
+)
+
 var (
 	ErrInvalidFilter = errors.New("trips.ErrInvalidFilter")
+
+	ListSortByList = []string{ListSortByStartDate, ListSortByUpdatedAt}
+	ListOrderList  = []string{ListOrderAsc, ListOrderDesc}
+	ListStatusList = []string{ListStatusUpcoming, ListStatusOngoing, ListStatusPast}
 )
 
 type ListFilter struct {
@@ -16,6 +40,62 @@ type ListFilter struct {
 	UserIDs []string
 
 	OnlyPublic bool
+
+	// Archived keeps the archived trips if true, or the others if false.
+	Archived *bool
+
+	// OnlyDeleted keeps the trips in the trash instead, DeletedBefore
+	// those deleted before the time.
+	OnlyDeleted   bool
+	DeletedBefore *time.Time
+
+	// StartsAfter and StartsBefore bound the trips' start date.
+	StartsAfter  *time.Time
+	StartsBefore *time.Time
+
+	// Status keeps the upcoming, ongoing or past trips.
+	Status string
+
+	// Countries, Cities and Tags keep the trips with any of them.
+	Countries []string
+	Cities    []string
+	Tags      []string
+
+	// Query searches the name, notes and activities' titles of the trips.
+	Query string
+
+	// SortBy defaults to the start date, with the latest first.
+	SortBy string
+	Order  string
+
+	// Limit pages the trips, the next pages start at the cursor
+	// returned with the previous one. No limit returns every trip.
+	Limit  int64
+	Cursor string
+}
+
+// listCursor is the position of the last trip of a page
+// in the sort order, ties are broken by the trip's ID.
+type listCursor struct {
+	Value time.Time `json:"v"`
+	ID    string    `json:"id"`
+}
+
+func decodeListCursor(cursor string) (listCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return listCursor{}, ErrInvalidFilter
+	}
+	var c listCursor
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == "" {
+		return listCursor{}, ErrInvalidFilter
+	}
+	return c, nil
+}
+
+func (c listCursor) encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
 }
 
 func (ff ListFilter) Validate() error {
@@ -25,6 +105,28 @@ func (ff ListFilter) Validate() error {
 	if ff.UserID != nil && *ff.UserID == "" {
 		return ErrInvalidFilter
 	}
+	if ff.Status != "" && !common.StringContains(ListStatusList, ff.Status) {
+		return ErrInvalidFilter
+	}
+	if ff.SortBy != "" && !common.StringContains(ListSortByList, ff.SortBy) {
+		return ErrInvalidFilter
+	}
+	if ff.Order != "" && !common.StringContains(ListOrderList, ff.Order) {
+		return ErrInvalidFilter
+	}
+	if ff.Limit < 0 || ff.Limit > MaxListLimit || len(ff.Query) > maxListQueryLength {
+		return ErrInvalidFilter
+	}
+	for _, tag := range ff.Tags {
+		if tag == "" || strings.ContainsAny(tag, ".$") {
+			return ErrInvalidFilter
+		}
+	}
+	if ff.Cursor != "" {
+		if _, err := decodeListCursor(ff.Cursor); err != nil {
+			return err
+		}
+	}
 	_, ok := ff.toBSON()
 	if !ok {
 		return ErrInvalidFilter
@@ -32,6 +134,41 @@ func (ff ListFilter) Validate() error {
 	return nil
 }
 
+func (f ListFilter) sortKey() string {
+	if f.SortBy == ListSortByUpdatedAt {
+		return ListSortByUpdatedAt
+	}
+	return ListSortByStartDate
+}
+
+func (f ListFilter) sortDirection() int {
+	if f.Order == ListOrderAsc {
+		return 1
+	}
+	return -1
+}
+
+func (f ListFilter) toSortBSON() bson.D {
+	return bson.D{
+		{Key: f.sortKey(), Value: f.sortDirection()},
+		{Key: bsonKeyID, Value: f.sortDirection()},
+	}
+}
+
+// NextCursor returns the cursor of the page after the trips, or an
+// empty string if they are the last page.
+func (f ListFilter) NextCursor(list TripsList) string {
+	if f.Limit == 0 || int64(len(list)) < f.Limit {
+		return ""
+	}
+	last := list[len(list)-1]
+	c := listCursor{Value: last.StartDate, ID: last.ID}
+	if f.sortKey() == ListSortByUpdatedAt {
+		c.Value = last.UpdatedAt
+	}
+	return c.encode()
+}
+
 func (f ListFilter) toBSON() (bson.M, bool) {
 	bsonAnd := bson.A{}
 	isSet := false
@@ -64,6 +201,79 @@ func (f ListFilter) toBSON() (bson.M, bool) {
 		isSet = true
 	}
 
-	bsonAnd = append(bsonAnd, bson.M{bsonKeyDeleted: false})
+	if f.StartsAfter != nil || f.StartsBefore != nil {
+		bsonStart := bson.M{}
+		if f.StartsAfter != nil {
+			bsonStart["$gte"] = *f.StartsAfter
+		}
+		if f.StartsBefore != nil {
+			bsonStart["$lte"] = *f.StartsBefore
+		}
+		bsonAnd = append(bsonAnd, bson.M{"startDate": bsonStart})
+		isSet = true
+	}
+
+	if f.Archived != nil {
+		if *f.Archived {
+			bsonAnd = append(bsonAnd, bson.M{bsonKeyArchived: true})
+		} else {
+			bsonAnd = append(bsonAnd, bson.M{bsonKeyArchived: bson.M{"$ne": true}})
+		}
+	}
+
+	now := time.Now()
+	switch f.Status {
+	case ListStatusUpcoming:
+		bsonAnd = append(bsonAnd, bson.M{"startDate": bson.M{"$gt": now}})
+	case ListStatusOngoing:
+		bsonAnd = append(bsonAnd, bson.M{
+			"startDate": bson.M{"$lte": now},
+			"endDate":   bson.M{"$gte": now},
+		})
+	case ListStatusPast:
+		bsonAnd = append(bsonAnd, bson.M{"endDate": bson.M{"$lt": now}})
+	}
+
+	if len(f.Countries) > 0 {
+		bsonAnd = append(bsonAnd, bson.M{bsonKeyIndexCountries: bson.M{"$in": f.Countries}})
+	}
+	if len(f.Cities) > 0 {
+		bsonAnd = append(bsonAnd, bson.M{bsonKeyIndexCities: bson.M{"$in": f.Cities}})
+	}
+	if len(f.Tags) > 0 {
+		bsonTagsOr := bson.A{}
+		for _, tag := range f.Tags {
+			bsonTagsOr = append(bsonTagsOr, bson.M{
+				fmt.Sprintf("tags.%s", tag): bson.M{"$exists": true},
+			})
+		}
+		bsonAnd = append(bsonAnd, bson.M{"$or": bsonTagsOr})
+	}
+	if f.Query != "" {
+		bsonAnd = append(bsonAnd, bson.M{"$text": bson.M{"$search": f.Query}})
+	}
+
+	if f.Cursor != "" {
+		if c, err := decodeListCursor(f.Cursor); err == nil {
+			op := "$lt"
+			if f.sortDirection() > 0 {
+				op = "$gt"
+			}
+			bsonAnd = append(bsonAnd, bson.M{"$or": bson.A{
+				bson.M{f.sortKey(): bson.M{op: c.Value}},
+				bson.M{f.sortKey(): c.Value, bsonKeyID: bson.M{op: c.ID}},
+			}})
+		}
+	}
+
+	if !f.OnlyDeleted {
+		bsonAnd = append(bsonAnd, bson.M{bsonKeyDeleted: false})
+		return bson.M{"$and": bsonAnd}, isSet
+	}
+	bsonAnd = append(bsonAnd, bson.M{bsonKeyDeleted: true})
+	if f.DeletedBefore != nil {
+		bsonAnd = append(bsonAnd, bson.M{bsonKeyDeletedAt: bson.M{"$lt": *f.DeletedBefore}})
+		isSet = true
+	}
 	return bson.M{"$and": bsonAnd}, isSet
 }
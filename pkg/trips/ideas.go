@@ -0,0 +1,134 @@
+package trips
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/travelreys/travelreys/pkg/common"
+)
+
+const (
+	// LabelIdeaVotePrefix prefixes the vote label of each member,
+	// e.g vote|<memberID>.
+	LabelIdeaVotePrefix = "vote|"
+
+	// LabelIdeaVotesCount is a derived label maintained by the coordinator.
+	LabelIdeaVotesCount = "votes"
+
+	IdeaVoteUp = "up"
+
+	JSONPathIdeasRoot = "/ideas"
+)
+
+func MakeIdeaVoteLabel(memberID string) string {
+	return LabelIdeaVotePrefix + memberID
+}
+
+// Voters returns the IDs of the members who voted for the activity.
+func (a Activity) Voters() []string {
+	voters := []string{}
+	for key, val := range a.Labels {
+		if strings.HasPrefix(key, LabelIdeaVotePrefix) && val == IdeaVoteUp {
+			voters = append(voters, strings.TrimPrefix(key, LabelIdeaVotePrefix))
+		}
+	}
+	sort.Strings(voters)
+	return voters
+}
+
+// SortByVotes returns the ideas, most voted first.
+func (m ActivityMap) SortByVotes() ActivityList {
+	list := ActivityList{}
+	for _, act := range m {
+		list = append(list, act)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		vi, vj := len(list[i].Voters()), len(list[j].Voters())
+		if vi != vj {
+			return vi > vj
+		}
+		return list[i].Title < list[j].Title
+	})
+	return list
+}
+
+// Ideas Sync Ops
+
+func MakeIdeaPath(ideaID string) string {
+	return fmt.Sprintf("%s/%s", JSONPathIdeasRoot, ideaID)
+}
+
+func MakeActivityPath(dtKey, actID string) string {
+	return fmt.Sprintf("%s/%s/activities/%s", JSONPathItineraryRoot, dtKey, actID)
+}
+
+// SyncMsgTOBUpdateOpVoteIdea
+func MakeSyncMsgTOBUpdateOpVoteIdeaOps(ideaID, memberID string, vote bool) []SyncOp {
+	path := fmt.Sprintf("%s/labels/%s", MakeIdeaPath(ideaID), MakeIdeaVoteLabel(memberID))
+	if !vote {
+		return []SyncOp{MakeRemoveSyncOp(path, "")}
+	}
+	return []SyncOp{MakeAddSyncOp(path, IdeaVoteUp)}
+}
+
+// SyncMsgTOBUpdateOpMoveIdeaToItinerary
+func MakeSyncMsgTOBUpdateOpMoveIdeaToItineraryOps(ideaID, dtKey string) []SyncOp {
+	return []SyncOp{MakeMoveSyncOp(MakeIdeaPath(ideaID), MakeActivityPath(dtKey, ideaID))}
+}
+
+// SyncMsgTOBUpdateOpMoveActivityToIdeas
+func MakeSyncMsgTOBUpdateOpMoveActivityToIdeasOps(dtKey, actID string) []SyncOp {
+	return []SyncOp{MakeMoveSyncOp(MakeActivityPath(dtKey, actID), MakeIdeaPath(actID))}
+}
+
+// canVoteIdea checks that the votes changed by the op on an idea are the
+// member's own, e.g /ideas/<ideaID>/labels/vote|<memberID>.
+func (t Trip) canVoteIdea(memberID string, op SyncOp, tkns []string) bool {
+	if len(tkns) < 3 || op.Op == "move" {
+		return true
+	}
+	curr := common.Labels{}
+	if idea, ok := t.Ideas[tkns[2]]; ok && idea.Labels != nil {
+		curr = idea.Labels
+	}
+
+	labels := common.Labels{}
+	switch {
+	case len(tkns) == 5 && tkns[3] == "labels":
+		if !strings.HasPrefix(tkns[4], LabelIdeaVotePrefix) {
+			return true
+		}
+		return tkns[4] == MakeIdeaVoteLabel(memberID)
+	case len(tkns) == 4 && tkns[3] == "labels":
+		if op.Op != "remove" && decodeSyncOpValue(op, &labels) != nil {
+			return false
+		}
+	case len(tkns) == 3:
+		if op.Op == "remove" {
+			return true
+		}
+		var idea Activity
+		if decodeSyncOpValue(op, &idea) != nil {
+			return false
+		}
+		labels = idea.Labels
+	default:
+		return true
+	}
+
+	ownVote := MakeIdeaVoteLabel(memberID)
+	for _, pair := range [][2]common.Labels{{labels, curr}, {curr, labels}} {
+		for key, val := range pair[0] {
+			if strings.HasPrefix(key, LabelIdeaVotePrefix) && key != ownVote && pair[1][key] != val {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func ideaVotesCount(act *Activity) string {
+	return strconv.Itoa(len(act.Voters()))
+}
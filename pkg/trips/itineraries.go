@@ -3,6 +3,7 @@ package trips
 import (
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,6 +14,20 @@ import (
 
 const (
 	ItineraryDtKeyFormat = "2006-01-02"
+
+	// LabelRouteModePrefix prefixes the itinerary labels holding the
+	// travel mode chosen by users for a route, e.g routeMode|<pair>.
+	LabelRouteModePrefix = "routeMode|"
+
+	// LabelNoRoutePrefix prefixes the itinerary labels caching the routes
+	// without directions, with the places and travel mode they were
+	// requested for, e.g noRoute|<pair>.
+	LabelNoRoutePrefix = "noRoute|"
+
+	// Route labels used to invalidate cached routes
+	LabelRouteOriginPlaceID = "origin|placeID"
+	LabelRouteDestPlaceID   = "destination|placeID"
+	LabelRoutePreferredMode = "preferredMode"
 )
 
 type Activity struct {
@@ -103,22 +118,52 @@ func (itin Itinerary) SortActivities() ActivityList {
 }
 
 func (itin Itinerary) routePairingKey(a1 *Activity, a2 *Activity) string {
-	return fmt.Sprintf("%s%s%s", a1.ID, LabelDelimeter, a2.ID)
+	return routePairingKeyFromIDs(a1.ID, a2.ID)
+}
+
+func routePairingKeyFromIDs(origID, destID string) string {
+	return fmt.Sprintf("%s%s%s", origID, LabelDelimeter, destID)
+}
+
+// RoutePairing is the origin and destination of a route of the day.
+type RoutePairing struct {
+	Origin      maps.Place
+	Destination maps.Place
 }
 
-func (itin Itinerary) RoutePairings(lodgings LodgingsMap) map[string]bool {
-	pairings := map[string]bool{}
+func (p RoutePairing) HasPlaces() bool {
+	return p.Origin.PlaceID() != "" && p.Destination.PlaceID() != ""
+}
+
+// RoutePairings returns the routes of the day, keyed by route pairing key:
+//   - from the transit arriving on the day, or else the lodgings, to the first activity
+//   - between consecutive activities
+//   - from the last activity to the transit departing on the day, or else
+//     back to the lodging of the night.
+func (itin Itinerary) RoutePairings(lodgings LodgingsMap, transits TransitsMap) map[string]RoutePairing {
+	pairings := map[string]RoutePairing{}
 	sorted := itin.SortActivities()
 
 	if len(sorted) <= 0 {
 		return pairings
 	}
-
-	// Find routes between lodging and first activity
-	if sorted[0].Place.ID != "" {
-		for _, l := range lodgings {
-			act := Activity{ID: l.ID, Place: l.Place}
-			pairings[itin.routePairingKey(&act, sorted[0])] = true
+	dtKey := itin.GetDate().Format(ItineraryDtKeyFormat)
+	first, last := sorted[0], sorted[len(sorted)-1]
+
+	// Find routes from transit arrival or lodging to first activity
+	if first.Place.ID != "" {
+		arrivals := 0
+		for _, t := range transits {
+			if t.ArrivalTime.Format(ItineraryDtKeyFormat) != dtKey || t.ArrivalLocation.PlaceID() == "" {
+				continue
+			}
+			arrivals++
+			pairings[routePairingKeyFromIDs(t.ID, first.ID)] = RoutePairing{t.ArrivalLocation, first.Place}
+		}
+		if arrivals == 0 {
+			for _, l := range lodgings {
+				pairings[routePairingKeyFromIDs(l.ID, first.ID)] = RoutePairing{l.Place, first.Place}
+			}
 		}
 	}
 
@@ -128,9 +173,69 @@ func (itin Itinerary) RoutePairings(lodgings LodgingsMap) map[string]bool {
 		if sorted[i-1].Place.ID == "" || sorted[i].Place.ID == "" {
 			continue
 		}
-		pairings[itin.routePairingKey(sorted[i-1], sorted[i])] = true
+		pairings[itin.routePairingKey(sorted[i-1], sorted[i])] = RoutePairing{sorted[i-1].Place, sorted[i].Place}
+	}
+
+	// Find routes from last activity to transit departure or lodging
+	if last.Place.ID != "" {
+		departures := 0
+		for _, t := range transits {
+			if t.DepartTime.Format(ItineraryDtKeyFormat) != dtKey || t.DepartLocation.PlaceID() == "" {
+				continue
+			}
+			departures++
+			pairings[routePairingKeyFromIDs(last.ID, t.ID)] = RoutePairing{last.Place, t.DepartLocation}
+		}
+		if departures == 0 {
+			if l := lodgings.SleepingLodging(dtKey); l != nil && l.Place.ID != "" {
+				pairings[routePairingKeyFromIDs(last.ID, l.ID)] = RoutePairing{last.Place, l.Place}
+			}
+		}
 	}
 	return pairings
 }
 
+func MakeRouteModeLabel(pair string) string {
+	return LabelRouteModePrefix + pair
+}
+
+// PreferredRouteMode returns the travel mode chosen for the route, if any.
+func (itin Itinerary) PreferredRouteMode(pair string) string {
+	mode := itin.Labels[MakeRouteModeLabel(pair)]
+	for _, m := range maps.DirectionModesAllList {
+		if m == mode {
+			return mode
+		}
+	}
+	return ""
+}
+
+func MakeNoRouteLabel(pair string) string {
+	return LabelNoRoutePrefix + pair
+}
+
+// MakeRouteCacheKey is the places and travel mode the route of a pairing
+// is requested for, as stored in the LabelNoRoutePrefix labels.
+func MakeRouteCacheKey(pairing RoutePairing, preferredMode string) string {
+	return strings.Join([]string{
+		pairing.Origin.PlaceID(), pairing.Destination.PlaceID(), preferredMode,
+	}, LabelDelimeter)
+}
+
+// IsRouteCacheValid reports whether the cached routes of the pair were
+// calculated for the pairing's places and the preferred travel mode.
+func (itin Itinerary) IsRouteCacheValid(pair string, pairing RoutePairing, preferredMode string) bool {
+	routes, ok := itin.Routes[pair]
+	if !ok {
+		return false
+	}
+	if len(routes) <= 0 {
+		return itin.Labels[MakeNoRouteLabel(pair)] == MakeRouteCacheKey(pairing, preferredMode)
+	}
+	labels := routes[0].Labels
+	return labels[LabelRouteOriginPlaceID] == pairing.Origin.PlaceID() &&
+		labels[LabelRouteDestPlaceID] == pairing.Destination.PlaceID() &&
+		labels[LabelRoutePreferredMode] == preferredMode
+}
+
 type ItineraryMap map[string]*Itinerary
@@ -0,0 +1,454 @@
+package trips
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/google/uuid"
+	"github.com/travelreys/travelreys/pkg/common"
+	"github.com/travelreys/travelreys/pkg/finance"
+)
+
+const (
+	DefaultLedgerCurrency = "USD"
+
+	JSONPathSettlementsRoot = "/settlements"
+
+	// ledgerEpsilon is the smallest amount considered as a debt
+	ledgerEpsilon = 0.005
+)
+
+// Settlement is a payment made by a member to another to settle up.
+type Settlement struct {
+	ID        string        `json:"id" bson:"id"`
+	From      string        `json:"from" bson:"from"`
+	To        string        `json:"to" bson:"to"`
+	Price     finance.Price `json:"price" bson:"price"`
+	CreatedAt time.Time     `json:"createdAt" bson:"createdAt"`
+	Labels    common.Labels `json:"labels" bson:"labels"`
+}
+
+type SettlementsMap map[string]*Settlement
+
+func NewSettlement(from, to string, price finance.Price) Settlement {
+	return Settlement{
+		ID:        uuid.NewString(),
+		From:      from,
+		To:        to,
+		Price:     price,
+		CreatedAt: time.Now(),
+		Labels:    common.Labels{},
+	}
+}
+
+type LedgerBalance struct {
+	MemberID string `json:"memberID"`
+
+	// Lent is what other members owe for expenses the member paid
+	Lent float64 `json:"lent"`
+	// Borrowed is what the member owes for expenses paid by others
+	Borrowed float64 `json:"borrowed"`
+	// Sent and Received are the settlements paid and received
+	Sent     float64 `json:"sent"`
+	Received float64 `json:"received"`
+
+	// Net is positive when the member is owed money
+	Net float64 `json:"net"`
+}
+
+type LedgerTransfer struct {
+	From   string  `json:"from"`
+	To     string  `json:"to"`
+	Amount float64 `json:"amount"`
+}
+
+type Ledger struct {
+	Currency  string                    `json:"currency"`
+	Balances  map[string]*LedgerBalance `json:"balances"`
+	Transfers []LedgerTransfer          `json:"transfers"`
+
+	// Expenses are the expenses accounted for, with their receipts.
+	Expenses ExpenseList `json:"expenses"`
+
+	// Unconverted lists the paths of the expenses and settlements
+	// that could not be converted into the ledger's currency.
+	Unconverted []string `json:"unconverted"`
+}
+
+func (l *Ledger) balance(memberID string) *LedgerBalance {
+	b, ok := l.Balances[memberID]
+	if !ok {
+		b = &LedgerBalance{MemberID: memberID}
+		l.Balances[memberID] = b
+	}
+	return b
+}
+
+// MakeLedger computes who owes whom from the trip's expenses and
+// settlements, in the base currency of the rates. Amounts are converted
+// at the rates of their transaction date.
+func MakeLedger(trip *Trip, rates finance.ExchangeRatesByDate) Ledger {
+	ledger := Ledger{
+		Currency:    rates.Base,
+		Balances:    map[string]*LedgerBalance{},
+		Transfers:   []LedgerTransfer{},
+		Unconverted: []string{},
+	}
+	for _, id := range trip.GetMemberIDs() {
+		ledger.balance(id)
+	}
+
+	ledger.Expenses = trip.Expenses()
+	for _, e := range ledger.Expenses {
+		payer := e.Payer(trip)
+		for memberID, share := range e.PriceItem.Shares() {
+			if memberID == payer {
+				continue
+			}
+			amount, ok := rates.Convert(share, e.PriceItem.Currency, e.Date)
+			if !ok {
+				ledger.Unconverted = append(ledger.Unconverted, e.Path)
+				break
+			}
+			ledger.balance(payer).Lent += amount
+			ledger.balance(memberID).Borrowed += amount
+		}
+	}
+
+	for _, s := range trip.Settlements {
+		amount, ok := rates.Convert(s.Price.Amount, s.Price.Currency, s.CreatedAt)
+		if !ok {
+			ledger.Unconverted = append(ledger.Unconverted, fmt.Sprintf("%s/%s", JSONPathSettlementsRoot, s.ID))
+			continue
+		}
+		ledger.balance(s.From).Sent += amount
+		ledger.balance(s.To).Received += amount
+	}
+
+	for _, b := range ledger.Balances {
+		b.Lent = roundAmount(b.Lent)
+		b.Borrowed = roundAmount(b.Borrowed)
+		b.Sent = roundAmount(b.Sent)
+		b.Received = roundAmount(b.Received)
+		b.Net = roundAmount(b.Lent - b.Borrowed + b.Sent - b.Received)
+	}
+	ledger.Transfers = SettleUp(ledger.Balances)
+	return ledger
+}
+
+// SettleUp returns the transfers that bring every balance back to zero,
+// greedily matching the largest debtor with the largest creditor.
+func SettleUp(balances map[string]*LedgerBalance) []LedgerTransfer {
+	type entry struct {
+		id     string
+		amount float64
+	}
+	creditors, debtors := []*entry{}, []*entry{}
+	for id, b := range balances {
+		if b.Net > ledgerEpsilon {
+			creditors = append(creditors, &entry{id, b.Net})
+		} else if b.Net < -ledgerEpsilon {
+			debtors = append(debtors, &entry{id, -b.Net})
+		}
+	}
+
+	transfers := []LedgerTransfer{}
+	for len(creditors) > 0 && len(debtors) > 0 {
+		for _, list := range [][]*entry{creditors, debtors} {
+			sort.SliceStable(list, func(i, j int) bool {
+				if list[i].amount != list[j].amount {
+					return list[i].amount > list[j].amount
+				}
+				return list[i].id < list[j].id
+			})
+		}
+		c, d := creditors[0], debtors[0]
+		amount := math.Min(c.amount, d.amount)
+		transfers = append(transfers, LedgerTransfer{
+			From:   d.id,
+			To:     c.id,
+			Amount: roundAmount(amount),
+		})
+		c.amount -= amount
+		d.amount -= amount
+		if c.amount <= ledgerEpsilon {
+			creditors = creditors[1:]
+		}
+		if d.amount <= ledgerEpsilon {
+			debtors = debtors[1:]
+		}
+	}
+	return transfers
+}
+
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// canChangeSettlement checks that the member sent or received the
+// settlement at /settlements/<settlementID>.
+func (t Trip) canChangeSettlement(memberID string, op SyncOp, tkns []string) bool {
+	if len(tkns) < 3 {
+		return false
+	}
+	if curr, ok := t.Settlements[tkns[2]]; ok && curr.From != memberID && curr.To != memberID {
+		return false
+	}
+	if op.Op == "remove" || len(tkns) > 3 {
+		return true
+	}
+	var s Settlement
+	if err := decodeSyncOpValue(op, &s); err != nil {
+		return false
+	}
+	return s.From == memberID || s.To == memberID
+}
+
+// canSettlePriceItem checks that the shares of the expenses settled,
+// unsettled or dropped by the op, whether it changes the expenses, their
+// parents (e.g the activity) or their shares, e.g
+// <expensePath>/splitOptions/targets/<memberID>/settled, are owed by
+// the member, or that the member paid for the expense.
+func (t Trip) canSettlePriceItem(memberID string, op SyncOp) bool {
+	ops := []SyncOp{op}
+	if op.Op == "move" || op.Op == "copy" {
+		val, err := t.valueAt(op.From)
+		if err != nil {
+			return false
+		}
+		ops = []SyncOp{MakeAddSyncOp(op.Path, val)}
+		// Moving a whole expense, or one of its parents, keeps its shares.
+		if op.Op == "move" && t.isWithinPriceItem(op.From) {
+			ops = append(ops, MakeRemoveSyncOp(op.From, ""))
+		}
+	}
+	for _, op := range ops {
+		if !t.canChangeSettledShares(memberID, op) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t Trip) canChangeSettledShares(memberID string, op SyncOp) bool {
+	changed := false
+	for _, e := range t.priceItems() {
+		next, ok, err := priceItemAfterOp(e, op)
+		if err != nil {
+			return false
+		}
+		if !ok {
+			continue
+		}
+		changed = true
+		curr := e.PriceItem
+		targetIDs := map[string]bool{}
+		for ID := range curr.SplitOptions.Targets {
+			targetIDs[ID] = true
+		}
+		for ID := range next.SplitOptions.Targets {
+			targetIDs[ID] = true
+		}
+		for targetID := range targetIDs {
+			if curr.SplitOptions.Targets[targetID].Settled == next.SplitOptions.Targets[targetID].Settled {
+				continue
+			}
+			if targetID != memberID && curr.PaidBy != memberID {
+				return false
+			}
+		}
+	}
+	if changed || op.Op == "remove" {
+		return true
+	}
+
+	// New expenses can only have the member's own share settled.
+	settled, err := settledTargetsFromOp(op, strings.Split(op.Path, "/"))
+	if err != nil {
+		return false
+	}
+	for targetID, isSettled := range settled {
+		if isSettled && targetID != memberID {
+			return false
+		}
+	}
+	return true
+}
+
+// priceItemAfterOp returns the price item of the expense as changed by
+// the op, if the op changes it, part of it or one of its parents.
+func priceItemAfterOp(e Expense, op SyncOp) (finance.PriceItem, bool, error) {
+	var item finance.PriceItem
+	switch {
+	// <parentPath>, <expensePath>
+	case isSubPath(e.Path, op.Path):
+		if op.Op == "remove" {
+			return item, true, nil
+		}
+		var val interface{}
+		if err := decodeSyncOpValue(op, &val); err != nil {
+			return item, false, err
+		}
+		for _, key := range strings.Split(strings.TrimPrefix(e.Path, op.Path), "/")[1:] {
+			val = jsonChild(val, key)
+		}
+		if val == nil {
+			return item, true, nil
+		}
+		err := decodeSyncOpValue(SyncOp{Value: val}, &item)
+		return item, true, err
+	// <expensePath>/...
+	case strings.HasPrefix(op.Path, e.Path+"/"):
+		op.Path = strings.TrimPrefix(op.Path, e.Path)
+		data, err := json.Marshal(e.PriceItem)
+		if err != nil {
+			return item, false, err
+		}
+		patchOps, err := json.Marshal([]SyncOp{op})
+		if err != nil {
+			return item, false, err
+		}
+		patch, err := jsonpatch.DecodePatch(patchOps)
+		if err != nil {
+			return item, false, err
+		}
+		if data, err = patch.Apply(data); err != nil {
+			return item, false, err
+		}
+		err = json.Unmarshal(data, &item)
+		return item, true, err
+	}
+	return item, false, nil
+}
+
+func (t Trip) isWithinPriceItem(path string) bool {
+	for _, e := range t.priceItems() {
+		if strings.HasPrefix(path, e.Path+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// valueAt returns the JSON value of the trip at the JSON patch path.
+func (t Trip) valueAt(path string) (interface{}, error) {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	var val interface{}
+	if err := json.Unmarshal(data, &val); err != nil {
+		return nil, err
+	}
+	for _, key := range strings.Split(path, "/")[1:] {
+		val = jsonChild(val, key)
+	}
+	return val, nil
+}
+
+// jsonChild returns the value of the JSON object's key or array's
+// index, or nil if there is none.
+func jsonChild(val interface{}, key string) interface{} {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		return v[key]
+	case []interface{}:
+		idx, err := strconv.Atoi(key)
+		if err != nil || idx < 0 || idx >= len(v) {
+			return nil
+		}
+		return v[idx]
+	}
+	return nil
+}
+
+// isSubPath returns true if the JSON patch path is the parent path or
+// one of its children.
+func isSubPath(path, parent string) bool {
+	return path == parent || strings.HasPrefix(path, parent+"/")
+}
+
+// settledTargetsFromOp returns the settled state of the shares set by
+// the op on an expense, if any.
+func settledTargetsFromOp(op SyncOp, tkns []string) (map[string]bool, error) {
+	n := len(tkns)
+	settled := map[string]bool{}
+	switch {
+	// <expensePath>/splitOptions/targets/<memberID>/settled
+	case n > 4 && tkns[n-1] == "settled" && tkns[n-3] == "targets" && tkns[n-4] == "splitOptions":
+		var val bool
+		if err := decodeSyncOpValue(op, &val); err != nil {
+			return nil, err
+		}
+		settled[tkns[n-2]] = val
+	// <expensePath>/splitOptions/targets/<memberID>
+	case n > 3 && tkns[n-2] == "targets" && tkns[n-3] == "splitOptions":
+		var target finance.PriceSplitTarget
+		if err := decodeSyncOpValue(op, &target); err != nil {
+			return nil, err
+		}
+		settled[tkns[n-1]] = target.Settled
+	// <expensePath>/splitOptions/targets
+	case n > 2 && tkns[n-1] == "targets" && tkns[n-2] == "splitOptions":
+		targets := map[string]finance.PriceSplitTarget{}
+		if err := decodeSyncOpValue(op, &targets); err != nil {
+			return nil, err
+		}
+		for ID, target := range targets {
+			settled[ID] = target.Settled
+		}
+	// <expensePath>/splitOptions
+	case n > 1 && tkns[n-1] == "splitOptions":
+		var opts finance.PriceSplitOptions
+		if err := decodeSyncOpValue(op, &opts); err != nil {
+			return nil, err
+		}
+		for ID, target := range opts.Targets {
+			settled[ID] = target.Settled
+		}
+	// <expensePath>
+	case tkns[n-1] == "price":
+		var item finance.PriceItem
+		if err := decodeSyncOpValue(op, &item); err != nil {
+			return nil, err
+		}
+		for ID, target := range item.SplitOptions.Targets {
+			settled[ID] = target.Settled
+		}
+	}
+	return settled, nil
+}
+
+func decodeSyncOpValue(op SyncOp, v interface{}) error {
+	data, err := json.Marshal(op.Value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Settle-up Sync Ops
+
+// SyncMsgTOBUpdateOpSettleTransfer
+func MakeSyncMsgTOBUpdateOpSettleTransferOps(s Settlement) []SyncOp {
+	return []SyncOp{
+		MakeAddSyncOp(fmt.Sprintf("%s/%s", JSONPathSettlementsRoot, s.ID), s),
+	}
+}
+
+// SyncMsgTOBUpdateOpSettlePriceItem marks the share of a member in
+// an expense, given its path, as settled.
+func MakeSyncMsgTOBUpdateOpSettlePriceItemOps(expensePath, memberID string, settled bool) []SyncOp {
+	return []SyncOp{
+		MakeRepSyncOp(
+			fmt.Sprintf("%s/splitOptions/targets/%s/settled", expensePath, memberID),
+			settled,
+		),
+	}
+}
@@ -0,0 +1,163 @@
+package trips
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/travelreys/travelreys/pkg/media"
+	"go.uber.org/zap"
+)
+
+const (
+	// TrashRetention is how long deleted trips can be restored
+	// before they are purged.
+	TrashRetention = 30 * 24 * time.Hour
+
+	DefaultPurgeInterval = time.Hour
+
+	purgeBatchSize = 100
+)
+
+var (
+	ErrTripNotInTrash = errors.New("trips.ErrTripNotInTrash")
+)
+
+// PurgeHook deletes what other services keep about a purged trip.
+type PurgeHook func(ctx context.Context, tripID string) error
+
+// RunPurge purges the trips in the trash for longer than the retention
+// every interval, until the context is done.
+func RunPurge(
+	ctx context.Context,
+	svc Service,
+	interval time.Duration,
+	hooks []PurgeHook,
+	logger *zap.Logger,
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		purgedIDs, err := svc.PurgeTrash(ctx, time.Now())
+		if err != nil {
+			logger.Error("PurgeTrash", zap.Error(err))
+		}
+		for _, ID := range purgedIDs {
+			for _, hook := range hooks {
+				if err := hook(ctx, ID); err != nil {
+					logger.Error("RunPurge", zap.String("tripID", ID), zap.Error(err))
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (trip *Trip) Restore() {
+	trip.Deleted = false
+	trip.DeletedAt = nil
+}
+
+// IsRestorable checks that the trip is in the trash, and was
+// deleted recently enough to be restored.
+func (trip Trip) IsRestorable(now time.Time) bool {
+	if !trip.Deleted {
+		return false
+	}
+	return trip.DeletedAt != nil && now.Sub(*trip.DeletedAt) < TrashRetention
+}
+
+// SyncMsgTOBUpdateOpArchiveTrip
+func MakeSyncMsgTOBUpdateOpArchiveTripOps(archived bool) []SyncOp {
+	return []SyncOp{MakeRepSyncOp("/archived", archived)}
+}
+
+func (svc *service) Archive(ctx context.Context, ID string, archived bool) error {
+	return svc.syncUpdate(
+		ctx,
+		ID,
+		SyncMsgTOBUpdateOpArchiveTrip,
+		MakeSyncMsgTOBUpdateOpArchiveTripOps(archived),
+	)
+}
+
+// ListTrash returns the deleted trips which the user can restore.
+func (svc *service) ListTrash(ctx context.Context, userID string) (TripsList, error) {
+	list, err := svc.store.List(ctx, ListFilter{UserID: &userID, OnlyDeleted: true})
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	trash := TripsList{}
+	for _, trip := range list {
+		if trip.IsRestorable(now) && trip.HasPermission(userID, PermissionManageTrip) {
+			svc.augmentCoverImageURL(ctx, trip)
+			trash = append(trash, trip)
+		}
+	}
+	return trash, nil
+}
+
+func (svc *service) Restore(ctx context.Context, ID string) error {
+	trip, err := svc.tripFromContext(ctx, ID)
+	if err != nil {
+		return err
+	}
+	if !trip.IsRestorable(time.Now()) {
+		return ErrTripNotInTrash
+	}
+	trip.Restore()
+	return svc.store.Save(ctx, trip)
+}
+
+// PurgeTrash hard-deletes the trips deleted for longer than the
+// retention, with their media and attachments, and returns their IDs.
+func (svc *service) PurgeTrash(ctx context.Context, now time.Time) ([]string, error) {
+	deletedBefore := now.Add(-TrashRetention)
+	list, err := svc.store.List(ctx, ListFilter{
+		OnlyDeleted:   true,
+		DeletedBefore: &deletedBefore,
+		Limit:         purgeBatchSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	purgedIDs := []string{}
+	for _, trip := range list {
+		if err := svc.purge(ctx, trip); err != nil {
+			svc.logger.Error("PurgeTrash", zap.String("tripID", trip.ID), zap.Error(err))
+			continue
+		}
+		purgedIDs = append(purgedIDs, trip.ID)
+	}
+	return purgedIDs, nil
+}
+
+func (svc *service) purge(ctx context.Context, trip *Trip) error {
+	items := media.MediaItemList{}
+	for _, list := range trip.MediaItems {
+		items = append(items, list...)
+	}
+	if len(items) > 0 {
+		if err := svc.mediaSvc.Delete(ctx, items); err != nil {
+			return err
+		}
+	}
+	for _, obj := range trip.Files {
+		// The files are editable by the members, only remove the trip's own.
+		if obj == nil || !trip.isAttachmentPath(obj.Path) {
+			continue
+		}
+		toRemove := *obj
+		toRemove.Bucket = attachmentBucket
+		if err := svc.storageSvc.Remove(ctx, toRemove); err != nil {
+			svc.logger.Warn("purge", zap.String("path", obj.Path), zap.Error(err))
+		}
+	}
+	return svc.store.Delete(ctx, trip.ID)
+}
@@ -0,0 +1,151 @@
+package trips
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultLocale = "en-US"
+)
+
+// dateLocale describes how dates and times are written in a locale.
+// DateLayout accepts the {weekday}, {day}, {month}, {monthNumber}
+// and {year} tokens.
+type dateLocale struct {
+	Weekdays   [7]string // starting on Sunday
+	Months     [12]string
+	DateLayout string
+	TimeLayout string
+}
+
+var (
+	enWeekdays = [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+	enMonths   = [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"}
+	cjkMonths  = [12]string{"1月", "2月", "3月", "4月", "5月", "6月", "7月", "8月", "9月", "10月", "11月", "12月"}
+
+	dateLocales = map[string]dateLocale{
+		"en-us": {enWeekdays, enMonths, "{weekday}, {month} {day}, {year}", "3:04 PM"},
+		"en":    {enWeekdays, enMonths, "{weekday} {day} {month} {year}", "15:04"},
+		"fr": {
+			[7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+			[12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+			"{weekday} {day} {month} {year}",
+			"15:04",
+		},
+		"de": {
+			[7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+			[12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+			"{weekday}, {day}. {month} {year}",
+			"15:04",
+		},
+		"es": {
+			[7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+			[12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+			"{weekday}, {day} de {month} de {year}",
+			"15:04",
+		},
+		"it": {
+			[7]string{"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+			[12]string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+			"{weekday} {day} {month} {year}",
+			"15:04",
+		},
+		"pt": {
+			[7]string{"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado"},
+			[12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+			"{weekday}, {day} de {month} de {year}",
+			"15:04",
+		},
+		"nl": {
+			[7]string{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+			[12]string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+			"{weekday} {day} {month} {year}",
+			"15:04",
+		},
+		"id": {
+			[7]string{"Minggu", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu"},
+			[12]string{"Januari", "Februari", "Maret", "April", "Mei", "Juni", "Juli", "Agustus", "September", "Oktober", "November", "Desember"},
+			"{weekday}, {day} {month} {year}",
+			"15.04",
+		},
+		"ja": {
+			[7]string{"日", "月", "火", "水", "木", "金", "土"},
+			cjkMonths,
+			"{year}年{month}{day}日({weekday})",
+			"15:04",
+		},
+		"zh": {
+			[7]string{"星期日", "星期一", "星期二", "星期三", "星期四", "星期五", "星期六"},
+			cjkMonths,
+			"{year}年{month}{day}日 {weekday}",
+			"15:04",
+		},
+	}
+
+	// pdfDateLayouts write the dates of the locales whose names have no
+	// glyphs in the PDF booklet fonts with numbers only.
+	pdfDateLayouts = map[string]string{
+		"ja": "{year}/{monthNumber}/{day}",
+		"zh": "{year}-{monthNumber}-{day}",
+	}
+)
+
+// lookupDateLocale returns the date locale matching the given BCP 47
+// tag (e.g en-US, fr_FR), falling back to its base language and then
+// to DefaultLocale.
+func lookupDateLocale(locale string) dateLocale {
+	return dateLocales[dateLocaleKey(locale)]
+}
+
+// lookupPDFDateLocale returns the date locale matching the given tag,
+// with a numeric date layout if its names cannot be rendered in PDFs.
+func lookupPDFDateLocale(locale string) dateLocale {
+	key := dateLocaleKey(locale)
+	loc := dateLocales[key]
+	if layout, ok := pdfDateLayouts[key]; ok {
+		loc.DateLayout = layout
+	}
+	return loc
+}
+
+func dateLocaleKey(locale string) string {
+	tag := strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+	if _, ok := dateLocales[tag]; ok {
+		return tag
+	}
+	if base, _, found := strings.Cut(tag, "-"); found {
+		if _, ok := dateLocales[base]; ok {
+			return base
+		}
+	}
+	return strings.ToLower(DefaultLocale)
+}
+
+func (loc dateLocale) FormatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return strings.NewReplacer(
+		"{weekday}", loc.Weekdays[t.Weekday()],
+		"{day}", strconv.Itoa(t.Day()),
+		"{monthNumber}", strconv.Itoa(int(t.Month())),
+		"{month}", loc.Months[t.Month()-1],
+		"{year}", strconv.Itoa(t.Year()),
+	).Replace(loc.DateLayout)
+}
+
+func (loc dateLocale) FormatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(loc.TimeLayout)
+}
+
+func (loc dateLocale) FormatDateTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return loc.FormatDate(t) + " " + loc.FormatTime(t)
+}
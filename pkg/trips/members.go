@@ -0,0 +1,192 @@
+package trips
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/travelreys/travelreys/pkg/common"
+	"github.com/travelreys/travelreys/pkg/finance"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrCreatorCannotLeave = errors.New("trips.ErrCreatorCannotLeave")
+)
+
+const (
+	// LabelMemberContentPolicy decides what happens to the content
+	// created by a member who leaves or is removed from the trip.
+	LabelMemberContentPolicy = "members|contentPolicy"
+
+	// MemberContentPolicyKeep keeps the content as it is, attributed
+	// to the former member. It is the default.
+	MemberContentPolicyKeep = "keep"
+
+	// MemberContentPolicyReassign hands the content over to the
+	// creator of the trip.
+	MemberContentPolicyReassign = "reassign"
+
+	defaultMemberEmailSender = "notifications@travelreys.com"
+
+	memberRemovedTmplFilePath = "assets/tripMemberRemovedEmail.tmpl.html"
+	memberRemovedTmplFileName = "tripMemberRemovedEmail.tmpl.html"
+)
+
+func (t Trip) MemberContentPolicy() string {
+	if t.Labels[LabelMemberContentPolicy] == MemberContentPolicyReassign {
+		return MemberContentPolicyReassign
+	}
+	return MemberContentPolicyKeep
+}
+
+// SyncMsgTOBUpdateOpRemoveTripMember
+func MakeSyncMsgTOBUpdateOpRemoveTripMemberOps(memberID string) []SyncOp {
+	return []SyncOp{
+		MakeRemoveSyncOp(fmt.Sprintf("/members/%s", memberID), ""),
+		MakeRemoveSyncOp(fmt.Sprintf("/membersId/%s", memberID), ""),
+	}
+}
+
+// RemovedMemberIDs returns the IDs of the members removed by the ops.
+func RemovedMemberIDs(ops []SyncOp) []string {
+	memberIDs := []string{}
+	for _, op := range ops {
+		// /members/<memberID>
+		tkns := strings.Split(op.Path, "/")
+		if op.Op != "remove" || len(tkns) != 3 || tkns[1] != "members" {
+			continue
+		}
+		memberIDs = append(memberIDs, tkns[2])
+	}
+	return memberIDs
+}
+
+// ReassignContent hands the entities created by a member over to
+// another one, and returns the ops replacing them. The expenses of the
+// former member are pinned to them as the payer so that the ledger
+// does not change. Their checklist items are unassigned.
+func (trip *Trip) ReassignContent(fromID, toID string) []SyncOp {
+	ops := []SyncOp{}
+	reassign := func(labels common.Labels, price *finance.PriceItem) bool {
+		if labels[LabelCreatedBy] != fromID {
+			return false
+		}
+		labels[LabelCreatedBy] = toID
+		if price != nil && price.Amount != 0 && price.PaidBy == "" {
+			price.PaidBy = fromID
+		}
+		return true
+	}
+
+	for ID, l := range trip.Lodgings {
+		if reassign(l.Labels, &l.PriceItem) {
+			ops = append(ops, MakeRepSyncOp(fmt.Sprintf("/lodgings/%s", ID), l))
+		}
+	}
+	for ID, t := range trip.Transits {
+		if reassign(t.Labels, &t.PriceItem) {
+			ops = append(ops, MakeRepSyncOp(fmt.Sprintf("/transits/%s", ID), t))
+		}
+	}
+	for dtKey, itin := range trip.Itineraries {
+		for actID, act := range itin.Activities {
+			if reassign(act.Labels, &act.PriceItem) {
+				ops = append(ops, MakeRepSyncOp(MakeActivityPath(dtKey, actID), act))
+			}
+		}
+	}
+	for ID, idea := range trip.Ideas {
+		if reassign(idea.Labels, &idea.PriceItem) {
+			ops = append(ops, MakeRepSyncOp(MakeIdeaPath(ID), idea))
+		}
+	}
+	for ID, link := range trip.Links {
+		if reassign(link.Labels, nil) {
+			ops = append(ops, MakeRepSyncOp(fmt.Sprintf("/links/%s", ID), link))
+		}
+	}
+
+	budgetChanged := false
+	for _, item := range trip.Budget.Items {
+		if reassign(item.Labels, &item.PriceItem) {
+			budgetChanged = true
+		}
+	}
+	if budgetChanged {
+		ops = append(ops, MakeRepSyncOp("/budget/items", trip.Budget.Items))
+	}
+
+	for ID, poll := range trip.Polls {
+		if poll.CreatorID == fromID {
+			poll.CreatorID = toID
+			ops = append(ops, MakeRepSyncOp(fmt.Sprintf("%s/creatorID", MakePollPath(ID)), toID))
+		}
+	}
+	for clID, cl := range trip.Checklists {
+		for itemID, item := range cl.Items {
+			if item.AssigneeID == fromID {
+				item.AssigneeID = ""
+				ops = append(ops, MakeRepSyncOp(fmt.Sprintf("%s/assigneeID", MakeChecklistItemPath(clID, itemID)), ""))
+			}
+		}
+	}
+	return ops
+}
+
+// sendMemberRemovedEmail lets the former member know
+// they left, or were removed from the trip.
+func (svc *service) sendMemberRemovedEmail(
+	ctx context.Context,
+	trip *Trip,
+	memberID string,
+	hasLeft bool,
+) {
+	user, err := svc.authSvc.Read(ctx, memberID)
+	if err != nil {
+		svc.logger.Error("sendMemberRemovedEmail", zap.Error(err))
+		return
+	}
+
+	svc.logger.Info("sending member removed email", zap.String("to", user.Email))
+	t, err := template.
+		New(memberRemovedTmplFileName).
+		ParseFiles(memberRemovedTmplFilePath)
+	if err != nil {
+		svc.logger.Error("sendMemberRemovedEmail", zap.Error(err))
+		return
+	}
+
+	var doc bytes.Buffer
+	data := struct {
+		TripName string
+		HasLeft  bool
+	}{trip.Name, hasLeft}
+	if err := t.Execute(&doc, data); err != nil {
+		svc.logger.Error("sendMemberRemovedEmail", zap.Error(err))
+		return
+	}
+
+	mailBody, err := svc.mailSvc.InsertContentOnTemplate(doc.String())
+	if err != nil {
+		svc.logger.Error("sendMemberRemovedEmail", zap.Error(err))
+		return
+	}
+
+	subj := fmt.Sprintf("You were removed from %s", trip.Name)
+	if hasLeft {
+		subj = fmt.Sprintf("You left %s", trip.Name)
+	}
+	if err := svc.mailSvc.SendMail(
+		ctx,
+		user.Email,
+		defaultMemberEmailSender,
+		subj,
+		mailBody,
+	); err != nil {
+		svc.logger.Error("sendMemberRemovedEmail", zap.Error(err))
+	}
+}
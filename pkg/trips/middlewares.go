@@ -1,8 +1,9 @@
 package trips
 
 import (
-	context "context"
+	"context"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/travelreys/travelreys/pkg/auth"
@@ -96,6 +97,34 @@ func (mw validationMiddleware) Delete(ctx context.Context, ID string) error {
 	return mw.next.Delete(ctx, ID)
 }
 
+func (mw validationMiddleware) Archive(ctx context.Context, ID string, archived bool) error {
+	if ID == "" {
+		mw.logger.Warn("Archive")
+		return common.ErrValidation
+	}
+	return mw.next.Archive(ctx, ID, archived)
+}
+
+func (mw validationMiddleware) ListTrash(ctx context.Context, userID string) (TripsList, error) {
+	if userID == "" {
+		mw.logger.Warn("ListTrash")
+		return nil, common.ErrValidation
+	}
+	return mw.next.ListTrash(ctx, userID)
+}
+
+func (mw validationMiddleware) Restore(ctx context.Context, ID string) error {
+	if ID == "" {
+		mw.logger.Warn("Restore")
+		return common.ErrValidation
+	}
+	return mw.next.Restore(ctx, ID)
+}
+
+func (mw validationMiddleware) PurgeTrash(ctx context.Context, now time.Time) ([]string, error) {
+	return mw.next.PurgeTrash(ctx, now)
+}
+
 func (mw validationMiddleware) DeleteAttachment(ctx context.Context, ID string, obj storage.Object) error {
 	if ID == "" || obj.Path == "" || obj.Name == "" {
 		mw.logger.Warn("DeleteAttachment")
@@ -199,6 +228,126 @@ func (mw validationMiddleware) GenerateGetSignedURLs(
 
 }
 
+func (mw validationMiddleware) ExportItinerary(
+	ctx context.Context,
+	ID,
+	format,
+	dtKey string,
+) (ExportFile, error) {
+	if ID == "" || !common.StringContains(ExportFormatsList, format) {
+		mw.logger.Warn("ExportItinerary")
+		return ExportFile{}, common.ErrValidation
+	}
+	return mw.next.ExportItinerary(ctx, ID, format, dtKey)
+}
+
+func (mw validationMiddleware) GenerateBooklet(
+	ctx context.Context,
+	ID string,
+	opts BookletOptions,
+) (ExportFile, error) {
+	if ID == "" || !common.StringContains(BookletFormatsList, opts.Format) {
+		mw.logger.Warn("GenerateBooklet")
+		return ExportFile{}, common.ErrValidation
+	}
+	for _, section := range opts.Sections {
+		if !common.StringContains(BookletSectionsList, section) {
+			mw.logger.Warn("GenerateBooklet")
+			return ExportFile{}, common.ErrValidation
+		}
+	}
+	return mw.next.GenerateBooklet(ctx, ID, opts)
+}
+
+func (mw validationMiddleware) CopyActivities(ctx context.Context, ID string, opts CopyActivitiesOptions) error {
+	if ID == "" || !opts.IsValid() {
+		mw.logger.Warn("CopyActivities")
+		return common.ErrValidation
+	}
+	return mw.next.CopyActivities(ctx, ID, opts)
+}
+
+func (mw validationMiddleware) ReadLedger(ctx context.Context, ID, currency string) (Ledger, error) {
+	if ID == "" || !(currency == "" || len(currency) == 3) {
+		mw.logger.Warn("ReadLedger")
+		return Ledger{}, common.ErrValidation
+	}
+	return mw.next.ReadLedger(ctx, ID, strings.ToUpper(currency))
+}
+
+func (mw validationMiddleware) ReadBudgetSummary(ctx context.Context, ID, currency string) (BudgetSummary, error) {
+	if ID == "" || !(currency == "" || len(currency) == 3) {
+		mw.logger.Warn("ReadBudgetSummary")
+		return BudgetSummary{}, common.ErrValidation
+	}
+	return mw.next.ReadBudgetSummary(ctx, ID, strings.ToUpper(currency))
+}
+
+func (mw validationMiddleware) ExportExpenses(ctx context.Context, ID, format, currency string) (ExportFile, error) {
+	if ID == "" || !common.StringContains(ExpenseExportFormatsList, format) ||
+		!(currency == "" || len(currency) == 3) {
+		mw.logger.Warn("ExportExpenses")
+		return ExportFile{}, common.ErrValidation
+	}
+	return mw.next.ExportExpenses(ctx, ID, format, strings.ToUpper(currency))
+}
+
+func (mw validationMiddleware) ImportBudgetItems(ctx context.Context, ID string, data []byte) (BudgetItemsList, error) {
+	if ID == "" || len(data) == 0 {
+		mw.logger.Warn("ImportBudgetItems")
+		return nil, common.ErrValidation
+	}
+	return mw.next.ImportBudgetItems(ctx, ID, data)
+}
+
+func (mw validationMiddleware) AcceptPollOption(ctx context.Context, ID, pollID, optionID string) error {
+	if ID == "" || pollID == "" || optionID == "" {
+		mw.logger.Warn("AcceptPollOption")
+		return common.ErrValidation
+	}
+	return mw.next.AcceptPollOption(ctx, ID, pollID, optionID)
+}
+
+func (mw validationMiddleware) UpdateMemberRole(ctx context.Context, ID, memberID, role string) error {
+	if ID == "" || memberID == "" || !common.StringContains(AssignableMemberRoles, role) {
+		mw.logger.Warn("UpdateMemberRole")
+		return common.ErrValidation
+	}
+	return mw.next.UpdateMemberRole(ctx, ID, memberID, role)
+}
+
+func (mw validationMiddleware) TransferOwnership(ctx context.Context, ID, memberID string) error {
+	if ID == "" || memberID == "" {
+		mw.logger.Warn("TransferOwnership")
+		return common.ErrValidation
+	}
+	return mw.next.TransferOwnership(ctx, ID, memberID)
+}
+
+func (mw validationMiddleware) RemoveMember(ctx context.Context, ID, memberID string) error {
+	if ID == "" || memberID == "" {
+		mw.logger.Warn("RemoveMember")
+		return common.ErrValidation
+	}
+	return mw.next.RemoveMember(ctx, ID, memberID)
+}
+
+func (mw validationMiddleware) LeaveTrip(ctx context.Context, ID, memberID string) error {
+	if ID == "" || memberID == "" {
+		mw.logger.Warn("LeaveTrip")
+		return common.ErrValidation
+	}
+	return mw.next.LeaveTrip(ctx, ID, memberID)
+}
+
+func (mw validationMiddleware) UpdatePrivacyPolicy(ctx context.Context, ID string, policy PrivacyPolicy) error {
+	if ID == "" || !policy.IsValid() {
+		mw.logger.Warn("UpdatePrivacyPolicy")
+		return common.ErrValidation
+	}
+	return mw.next.UpdatePrivacyPolicy(ctx, ID, policy)
+}
+
 type rbacMiddleware struct {
 	next   Service
 	logger *zap.Logger
@@ -211,6 +360,27 @@ func SvcWithRBACMw(
 	return &rbacMiddleware{svc, logger}
 }
 
+// readTripWithPermission reads the trip if the
+// user's role in it grants the permission.
+func (mw rbacMiddleware) readTripWithPermission(
+	ctx context.Context,
+	ID,
+	permission string,
+) (*Trip, reqctx.ClientInfo, error) {
+	ci, err := reqctx.ClientInfoFromCtx(ctx)
+	if err != nil || ci.HasEmptyID() {
+		return nil, ci, ErrRBAC
+	}
+	trip, err := mw.next.Read(ctx, ID)
+	if err != nil {
+		return nil, ci, err
+	}
+	if !trip.HasPermission(ci.UserID, permission) {
+		return nil, ci, ErrRBAC
+	}
+	return trip, ci, nil
+}
+
 func (mw rbacMiddleware) Create(
 	ctx context.Context,
 	creatorID,
@@ -230,19 +400,8 @@ func (mw rbacMiddleware) Save(ctx context.Context, trip *Trip) error {
 }
 
 func (mw rbacMiddleware) Read(ctx context.Context, ID string) (*Trip, error) {
-	ci, err := reqctx.ClientInfoFromCtx(ctx)
-	if err != nil || ci.HasEmptyID() {
-		return nil, ErrRBAC
-	}
-
-	trip, err := mw.next.Read(ctx, ID)
-	if err != nil {
-		return nil, err
-	}
-	if !common.StringContains(trip.GetMemberIDs(), ci.UserID) {
-		return nil, ErrRBAC
-	}
-	return trip, nil
+	trip, _, err := mw.readTripWithPermission(ctx, ID, PermissionView)
+	return trip, err
 }
 
 func (mw rbacMiddleware) ReadOGP(ctx context.Context, ID string) (TripOGP, error) {
@@ -250,17 +409,10 @@ func (mw rbacMiddleware) ReadOGP(ctx context.Context, ID string) (TripOGP, error
 }
 
 func (mw rbacMiddleware) ReadMembers(ctx context.Context, ID string) (MembersMap, error) {
-	ci, err := reqctx.ClientInfoFromCtx(ctx)
-	if err != nil || ci.HasEmptyID() {
-		return nil, ErrRBAC
-	}
-	trip, err := mw.next.Read(ctx, ID)
+	trip, _, err := mw.readTripWithPermission(ctx, ID, PermissionView)
 	if err != nil {
 		return nil, err
 	}
-	if !common.StringContains(trip.GetMemberIDs(), ci.UserID) {
-		return nil, ErrRBAC
-	}
 	return mw.next.ReadMembers(ContextWithTripInfo(ctx, trip), ID)
 }
 
@@ -284,49 +436,67 @@ func (mw rbacMiddleware) ListWithMembers(
 }
 
 func (mw rbacMiddleware) Delete(ctx context.Context, ID string) error {
-	ci, err := reqctx.ClientInfoFromCtx(ctx)
-	if err != nil || ci.HasEmptyID() {
-		return ErrRBAC
+	trip, _, err := mw.readTripWithPermission(ctx, ID, PermissionManageTrip)
+	if err != nil {
+		return err
 	}
+	return mw.next.Delete(ContextWithTripInfo(ctx, trip), ID)
+}
 
-	trip, err := mw.next.Read(ctx, ID)
-	if err != nil && err != ErrTripNotFound {
+func (mw rbacMiddleware) Archive(ctx context.Context, ID string, archived bool) error {
+	trip, _, err := mw.readTripWithPermission(ctx, ID, PermissionManageTrip)
+	if err != nil {
 		return err
 	}
-	if trip.Creator.ID != ci.UserID {
-		return ErrRBAC
+	return mw.next.Archive(ContextWithTripInfo(ctx, trip), ID, archived)
+}
+
+func (mw rbacMiddleware) ListTrash(ctx context.Context, userID string) (TripsList, error) {
+	ci, err := reqctx.ClientInfoFromCtx(ctx)
+	if err != nil || ci.HasEmptyID() || ci.UserID != userID {
+		return nil, ErrRBAC
 	}
-	return mw.next.Delete(ContextWithTripInfo(ctx, trip), ID)
+	return mw.next.ListTrash(ctx, userID)
+}
+
+func (mw rbacMiddleware) Restore(ctx context.Context, ID string) error {
+	trip, _, err := mw.readTripWithPermission(ctx, ID, PermissionManageTrip)
+	if err != nil {
+		return err
+	}
+	return mw.next.Restore(ContextWithTripInfo(ctx, trip), ID)
+}
+
+// PurgeTrash is run by the server, not on behalf of a user.
+func (mw rbacMiddleware) PurgeTrash(ctx context.Context, now time.Time) ([]string, error) {
+	return nil, ErrRBAC
 }
 
 func (mw rbacMiddleware) DeleteAttachment(ctx context.Context, ID string, obj storage.Object) error {
-	ci, err := reqctx.ClientInfoFromCtx(ctx)
-	if err != nil || ci.HasEmptyID() {
-		return ErrRBAC
+	if _, _, err := mw.readTripWithPermission(ctx, ID, PermissionEditItinerary); err != nil {
+		return err
 	}
 	return mw.next.DeleteAttachment(ctx, ID, obj)
 }
 
 func (mw rbacMiddleware) DownloadAttachmentPresignedURL(ctx context.Context, ID, path, filename string) (string, error) {
-	ci, err := reqctx.ClientInfoFromCtx(ctx)
-	if err != nil || ci.HasEmptyID() {
-		return "", ErrRBAC
+	if _, _, err := mw.readTripWithPermission(ctx, ID, PermissionView); err != nil {
+		return "", err
 	}
 	return mw.next.DownloadAttachmentPresignedURL(ctx, ID, path, filename)
 }
 
 func (mw rbacMiddleware) UploadAttachmentPresignedURL(ctx context.Context, ID, filename, fileType string) (string, error) {
-	ci, err := reqctx.ClientInfoFromCtx(ctx)
-	if err != nil || ci.HasEmptyID() {
-		return "", ErrRBAC
+	if _, _, err := mw.readTripWithPermission(ctx, ID, PermissionEditItinerary); err != nil {
+		return "", err
 	}
 	return mw.next.UploadAttachmentPresignedURL(ctx, ID, filename, fileType)
 }
 
 func (mw rbacMiddleware) GenerateMediaItems(ctx context.Context, ID, userID string, params []media.NewMediaItemParams) (media.MediaItemList, media.MediaPresignedUrlList, error) {
-	ci, err := reqctx.ClientInfoFromCtx(ctx)
-	if err != nil || ci.HasEmptyID() {
-		return nil, nil, ErrRBAC
+	_, ci, err := mw.readTripWithPermission(ctx, ID, PermissionUploadMedia)
+	if err != nil {
+		return nil, nil, err
 	}
 	if ci.UserID != userID {
 		return nil, nil, ErrRBAC
@@ -335,26 +505,156 @@ func (mw rbacMiddleware) GenerateMediaItems(ctx context.Context, ID, userID stri
 }
 
 func (mw rbacMiddleware) SaveMediaItems(ctx context.Context, ID string, items media.MediaItemList) error {
-	ci, err := reqctx.ClientInfoFromCtx(ctx)
-	if err != nil || ci.HasEmptyID() {
-		return ErrRBAC
+	if _, _, err := mw.readTripWithPermission(ctx, ID, PermissionUploadMedia); err != nil {
+		return err
 	}
 	return mw.next.SaveMediaItems(ctx, ID, items)
 }
 
 func (mw rbacMiddleware) DeleteMediaItems(ctx context.Context, ID string, items media.MediaItemList) error {
-	ci, err := reqctx.ClientInfoFromCtx(ctx)
-	if err != nil || ci.HasEmptyID() {
-		return ErrRBAC
+	if _, _, err := mw.readTripWithPermission(ctx, ID, PermissionUploadMedia); err != nil {
+		return err
 	}
 	return mw.next.DeleteMediaItems(ctx, ID, items)
 }
 
 func (mw rbacMiddleware) GenerateGetSignedURLs(ctx context.Context, ID string, items media.MediaItemList) (media.MediaPresignedUrlList, error) {
-	ci, err := reqctx.ClientInfoFromCtx(ctx)
-	if err != nil || ci.HasEmptyID() {
-		return nil, ErrRBAC
+	if _, _, err := mw.readTripWithPermission(ctx, ID, PermissionView); err != nil {
+		return nil, err
 	}
 	return mw.next.GenerateGetSignedURLs(ctx, ID, items)
 
 }
+
+func (mw rbacMiddleware) ExportItinerary(
+	ctx context.Context,
+	ID,
+	format,
+	dtKey string,
+) (ExportFile, error) {
+	trip, _, err := mw.readTripWithPermission(ctx, ID, PermissionView)
+	if err != nil {
+		return ExportFile{}, err
+	}
+	return mw.next.ExportItinerary(ContextWithTripInfo(ctx, trip), ID, format, dtKey)
+}
+
+func (mw rbacMiddleware) GenerateBooklet(
+	ctx context.Context,
+	ID string,
+	opts BookletOptions,
+) (ExportFile, error) {
+	trip, _, err := mw.readTripWithPermission(ctx, ID, PermissionView)
+	if err != nil {
+		return ExportFile{}, err
+	}
+	return mw.next.GenerateBooklet(ContextWithTripInfo(ctx, trip), ID, opts)
+}
+
+// CopyActivities needs to read the trip and edit the other one,
+// moving the activities edits both.
+func (mw rbacMiddleware) CopyActivities(ctx context.Context, ID string, opts CopyActivitiesOptions) error {
+	permission := PermissionView
+	if opts.Move {
+		permission = PermissionEditItinerary
+	}
+	trip, _, err := mw.readTripWithPermission(ctx, ID, permission)
+	if err != nil {
+		return err
+	}
+	if _, _, err := mw.readTripWithPermission(ctx, opts.DestTripID, PermissionEditItinerary); err != nil {
+		return err
+	}
+	return mw.next.CopyActivities(ContextWithTripInfo(ctx, trip), ID, opts)
+}
+
+func (mw rbacMiddleware) ReadLedger(ctx context.Context, ID, currency string) (Ledger, error) {
+	trip, _, err := mw.readTripWithPermission(ctx, ID, PermissionView)
+	if err != nil {
+		return Ledger{}, err
+	}
+	return mw.next.ReadLedger(ContextWithTripInfo(ctx, trip), ID, currency)
+}
+
+func (mw rbacMiddleware) ReadBudgetSummary(ctx context.Context, ID, currency string) (BudgetSummary, error) {
+	trip, _, err := mw.readTripWithPermission(ctx, ID, PermissionView)
+	if err != nil {
+		return BudgetSummary{}, err
+	}
+	return mw.next.ReadBudgetSummary(ContextWithTripInfo(ctx, trip), ID, currency)
+}
+
+func (mw rbacMiddleware) ExportExpenses(ctx context.Context, ID, format, currency string) (ExportFile, error) {
+	trip, _, err := mw.readTripWithPermission(ctx, ID, PermissionView)
+	if err != nil {
+		return ExportFile{}, err
+	}
+	return mw.next.ExportExpenses(ContextWithTripInfo(ctx, trip), ID, format, currency)
+}
+
+func (mw rbacMiddleware) ImportBudgetItems(ctx context.Context, ID string, data []byte) (BudgetItemsList, error) {
+	trip, _, err := mw.readTripWithPermission(ctx, ID, PermissionEditBudget)
+	if err != nil {
+		return nil, err
+	}
+	return mw.next.ImportBudgetItems(ContextWithTripInfo(ctx, trip), ID, data)
+}
+
+// AcceptPollOption is restricted to the creator of the poll.
+func (mw rbacMiddleware) AcceptPollOption(ctx context.Context, ID, pollID, optionID string) error {
+	trip, ci, err := mw.readTripWithPermission(ctx, ID, PermissionEditItinerary)
+	if err != nil {
+		return err
+	}
+	poll, ok := trip.Polls[pollID]
+	if !ok {
+		return ErrPollNotFound
+	}
+	if poll.CreatorID != ci.UserID {
+		return ErrRBAC
+	}
+	return mw.next.AcceptPollOption(ContextWithTripInfo(ctx, trip), ID, pollID, optionID)
+}
+
+func (mw rbacMiddleware) UpdateMemberRole(ctx context.Context, ID, memberID, role string) error {
+	trip, _, err := mw.readTripWithPermission(ctx, ID, PermissionManageTrip)
+	if err != nil {
+		return err
+	}
+	return mw.next.UpdateMemberRole(ContextWithTripInfo(ctx, trip), ID, memberID, role)
+}
+
+func (mw rbacMiddleware) TransferOwnership(ctx context.Context, ID, memberID string) error {
+	trip, _, err := mw.readTripWithPermission(ctx, ID, PermissionManageTrip)
+	if err != nil {
+		return err
+	}
+	return mw.next.TransferOwnership(ContextWithTripInfo(ctx, trip), ID, memberID)
+}
+
+func (mw rbacMiddleware) RemoveMember(ctx context.Context, ID, memberID string) error {
+	trip, _, err := mw.readTripWithPermission(ctx, ID, PermissionManageMembers)
+	if err != nil {
+		return err
+	}
+	return mw.next.RemoveMember(ContextWithTripInfo(ctx, trip), ID, memberID)
+}
+
+func (mw rbacMiddleware) LeaveTrip(ctx context.Context, ID, memberID string) error {
+	trip, ci, err := mw.readTripWithPermission(ctx, ID, PermissionView)
+	if err != nil {
+		return err
+	}
+	if ci.UserID != memberID {
+		return ErrRBAC
+	}
+	return mw.next.LeaveTrip(ContextWithTripInfo(ctx, trip), ID, memberID)
+}
+
+func (mw rbacMiddleware) UpdatePrivacyPolicy(ctx context.Context, ID string, policy PrivacyPolicy) error {
+	trip, _, err := mw.readTripWithPermission(ctx, ID, PermissionManageSharing)
+	if err != nil {
+		return err
+	}
+	return mw.next.UpdatePrivacyPolicy(ContextWithTripInfo(ctx, trip), ID, policy)
+}
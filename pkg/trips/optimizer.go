@@ -0,0 +1,539 @@
+package trips
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/travelreys/travelreys/pkg/maps"
+)
+
+const (
+	// LabelActivityPinned keeps an activity on its day when optimising.
+	LabelActivityPinned = "pinned"
+
+	// LabelOptimizerTravelMode is the trip label holding the travel
+	// mode used by the trip optimiser. Defaults to maps.DefaultDirectionMode.
+	LabelOptimizerTravelMode = "optimizer|travelMode"
+
+	optimizerMaxClusterIterations = 10
+	optimizerMax2OptPasses        = 50
+
+	// optimizerUnreachableCost is the cost of travelling between
+	// places that are not connected in the chosen travel mode.
+	optimizerUnreachableCost = 1e12
+
+	// optimizerClosedDayDistance is added to the distance between a day
+	// and the activities whose places are closed on that day, in meters.
+	optimizerClosedDayDistance = 1e7
+
+	// optimizerClosedCost is added to the cost of a day's path for each
+	// activity visited outside of its place's opening hours, in seconds.
+	optimizerClosedCost = 6 * 60 * 60
+
+	// optimizerDayStartHour is when the days' visits start, unless
+	// an activity with a start time begins earlier.
+	optimizerDayStartHour = 9
+
+	// straightLineTravelSpeed is the speed, in meters per second,
+	// assumed when travelling in a straight line between places.
+	straightLineTravelSpeed = 25.0 / 3.6
+)
+
+// TravelCostFunc returns the matrix of travel times in seconds between
+// places, where cost[i][j] is the time to travel from places[i] to
+// places[j], or a negative value if there is no route.
+type TravelCostFunc func(places []maps.Place) [][]float64
+
+// StraightLineTravelCost uses the straight-line distance between places,
+// at straightLineTravelSpeed, as the travel time.
+func StraightLineTravelCost(places []maps.Place) [][]float64 {
+	cost := make([][]float64, len(places))
+	for i := range places {
+		cost[i] = make([]float64, len(places))
+		for j := range places {
+			cost[i][j] = maps.HaversineDistance(places[i].LatLng, places[j].LatLng) / straightLineTravelSpeed
+		}
+	}
+	return cost
+}
+
+func (a Activity) IsPinned() bool {
+	return a.Labels[LabelActivityPinned] == "true"
+}
+
+// IsMovable reports whether the optimiser may move the activity to another day.
+// Pinned activities and activities with a start time stay on their day.
+func (a Activity) IsMovable() bool {
+	return !a.IsPinned() && a.StartTime.IsZero() && hasLatLng(a.Place)
+}
+
+// TripPlan maps each itinerary date key to its activities, in visiting order.
+type TripPlan map[string]ActivityList
+
+type optimizerDay struct {
+	dtKey     string
+	date      time.Time
+	lodging   *Lodging
+	fixed     ActivityList
+	assigned  ActivityList
+	center    maps.LatLng
+	hasCenter bool
+}
+
+func (day *optimizerDay) updateCenter() {
+	points := []maps.LatLng{}
+	if day.lodging != nil && hasLatLng(day.lodging.Place) {
+		points = append(points, day.lodging.Place.LatLng)
+	}
+	for _, list := range []ActivityList{day.fixed, day.assigned} {
+		for _, act := range list {
+			if hasLatLng(act.Place) {
+				points = append(points, act.Place.LatLng)
+			}
+		}
+	}
+	if len(points) == 0 {
+		return
+	}
+	center := maps.LatLng{}
+	for _, p := range points {
+		center.Lat += p.Lat
+		center.Lng += p.Lng
+	}
+	center.Lat /= float64(len(points))
+	center.Lng /= float64(len(points))
+	day.center = center
+	day.hasCenter = true
+}
+
+// OptimizeTrip reorganises the trip's activities across its days.
+// Movable activities are clustered into days by geography, around each
+// day's nightly lodging and fixed activities, so that every day has
+// a similar number of activities, avoiding the days their places are
+// closed. Each day is then ordered using a nearest-neighbour tour
+// improved by 2-opt, keeping activities with a start time in
+// chronological order and visiting the others within their places'
+// opening hours where possible.
+func OptimizeTrip(trip *Trip, costFn TravelCostFunc) TripPlan {
+	plan := TripPlan{}
+	dtKeys := GetSortedItineraryKeys(trip)
+	if len(dtKeys) == 0 {
+		return plan
+	}
+
+	days := []*optimizerDay{}
+	movable := ActivityList{}
+	numActivities := 0
+	for _, dtKey := range dtKeys {
+		day := &optimizerDay{
+			dtKey:   dtKey,
+			date:    trip.Itineraries[dtKey].GetDate(),
+			lodging: trip.Lodgings.nightlyLodging(dtKey),
+		}
+		for _, act := range trip.Itineraries[dtKey].SortActivities() {
+			numActivities++
+			if act.IsMovable() {
+				movable = append(movable, act)
+				continue
+			}
+			day.fixed = append(day.fixed, act)
+		}
+		days = append(days, day)
+	}
+
+	capacity := int(math.Ceil(float64(numActivities) / float64(len(days))))
+	clusterActivities(days, movable, capacity)
+
+	for _, day := range days {
+		plan[day.dtKey] = day.order(costFn)
+	}
+	return plan
+}
+
+func clusterActivities(days []*optimizerDay, movable ActivityList, capacity int) {
+	if len(movable) == 0 {
+		return
+	}
+
+	for _, day := range days {
+		day.updateCenter()
+	}
+	seedDayCenters(days, movable)
+
+	prev := map[string]int{}
+	for iter := 0; iter < optimizerMaxClusterIterations; iter++ {
+		assignment := assignActivities(days, movable, capacity)
+		changed := false
+		for id, dayIdx := range assignment {
+			if prev[id] != dayIdx {
+				changed = true
+			}
+		}
+		prev = assignment
+		if !changed && iter > 0 {
+			break
+		}
+		for _, day := range days {
+			day.updateCenter()
+		}
+	}
+}
+
+// seedDayCenters picks the activities furthest away from the existing
+// centers as the centers of days without lodging and fixed activities.
+func seedDayCenters(days []*optimizerDay, movable ActivityList) {
+	used := map[string]bool{}
+	for _, day := range days {
+		if day.hasCenter {
+			continue
+		}
+		var (
+			seed     *Activity
+			seedDist = -1.0
+		)
+		for _, act := range movable {
+			if used[act.ID] {
+				continue
+			}
+			dist := math.MaxFloat64
+			for _, other := range days {
+				if other.hasCenter {
+					dist = math.Min(dist, maps.HaversineDistance(other.center, act.Place.LatLng))
+				}
+			}
+			if dist > seedDist {
+				seed, seedDist = act, dist
+			}
+		}
+		if seed == nil {
+			return
+		}
+		used[seed.ID] = true
+		day.center = seed.Place.LatLng
+		day.hasCenter = true
+	}
+}
+
+// assignActivities greedily assigns each movable activity to the closest
+// day center with remaining capacity.
+func assignActivities(days []*optimizerDay, movable ActivityList, capacity int) map[string]int {
+	type candidate struct {
+		actIdx int
+		dayIdx int
+		dist   float64
+	}
+
+	candidates := []candidate{}
+	remaining := []int{}
+	for dayIdx, day := range days {
+		day.assigned = ActivityList{}
+		remaining = append(remaining, capacity-len(day.fixed))
+		if !day.hasCenter {
+			continue
+		}
+		for actIdx, act := range movable {
+			dist := maps.HaversineDistance(day.center, act.Place.LatLng)
+			if !act.Place.OpeningHours.IsOpenOn(day.date) {
+				dist += optimizerClosedDayDistance
+			}
+			candidates = append(candidates, candidate{actIdx, dayIdx, dist})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].dist < candidates[j].dist
+	})
+
+	assignment := map[string]int{}
+	for _, c := range candidates {
+		act := movable[c.actIdx]
+		if _, ok := assignment[act.ID]; ok || remaining[c.dayIdx] <= 0 {
+			continue
+		}
+		assignment[act.ID] = c.dayIdx
+		remaining[c.dayIdx]--
+	}
+
+	// Leftovers go to the least busy day, preferably an open one
+	for _, act := range movable {
+		if _, ok := assignment[act.ID]; ok {
+			continue
+		}
+		best, bestOpen := 0, false
+		for dayIdx, day := range days {
+			open := act.Place.OpeningHours.IsOpenOn(day.date)
+			if (open && !bestOpen) || (open == bestOpen && remaining[dayIdx] > remaining[best]) {
+				best, bestOpen = dayIdx, open
+			}
+		}
+		assignment[act.ID] = best
+		remaining[best]--
+	}
+
+	for _, act := range movable {
+		day := days[assignment[act.ID]]
+		day.assigned = append(day.assigned, act)
+	}
+	return assignment
+}
+
+// order returns the day's activities in visiting order. Activities
+// without a place stay right after the activity they originally followed.
+func (day *optimizerDay) order(costFn TravelCostFunc) ActivityList {
+	all := append(ActivityList{}, day.fixed...)
+	all = append(all, day.assigned...)
+	sort.Sort(all)
+
+	stops := ActivityList{}
+	followers := map[string]ActivityList{}
+	head := ActivityList{}
+	var last *Activity
+	for _, act := range all {
+		if hasLatLng(act.Place) {
+			stops = append(stops, act)
+			last = act
+			continue
+		}
+		if last == nil {
+			head = append(head, act)
+			continue
+		}
+		followers[last.ID] = append(followers[last.ID], act)
+	}
+
+	places := []maps.Place{}
+	hasStart := day.lodging != nil && hasLatLng(day.lodging.Place)
+	if hasStart {
+		places = append(places, day.lodging.Place)
+	}
+	for _, act := range stops {
+		places = append(places, act.Place)
+	}
+
+	ordered := stops
+	if len(stops) > 1 {
+		ordered = orderStops(stops, hasStart, costFn(places), day.start(stops))
+	}
+
+	result := append(ActivityList{}, head...)
+	for _, act := range ordered {
+		result = append(result, act)
+		result = append(result, followers[act.ID]...)
+	}
+	return result
+}
+
+// start returns when the day's visits start, in the time zone of its
+// places if known, or earlier if an activity with a start time does.
+func (day *optimizerDay) start(stops ActivityList) time.Time {
+	loc := day.date.Location()
+	if day.lodging != nil && day.lodging.Place.OpeningHours.UTCOffset != nil {
+		loc = day.lodging.Place.OpeningHours.Location(loc)
+	}
+	for _, act := range stops {
+		if act.Place.OpeningHours.UTCOffset != nil {
+			loc = act.Place.OpeningHours.Location(loc)
+			break
+		}
+	}
+	y, m, d := day.date.Date()
+	start := time.Date(y, m, d, optimizerDayStartHour, 0, 0, 0, loc)
+	for _, act := range stops {
+		if !act.StartTime.IsZero() && act.StartTime.Before(start) {
+			start = act.StartTime
+		}
+	}
+	return start
+}
+
+type stopsRoute struct {
+	stops    ActivityList
+	hasStart bool
+	cost     [][]float64
+	rank     []int
+	dayStart time.Time
+}
+
+// orderStops finds a short path through the stops, starting from the
+// lodging if any, using nearest-neighbour and 2-opt. Visits outside of
+// the places' opening hours, from dayStart, add to the path's cost.
+func orderStops(stops ActivityList, hasStart bool, cost [][]float64, dayStart time.Time) ActivityList {
+	r := stopsRoute{stops: stops, hasStart: hasStart, cost: cost, dayStart: dayStart}
+
+	// Timed activities have to be visited in chronological order
+	timed := []int{}
+	r.rank = make([]int, len(stops))
+	for i, act := range stops {
+		r.rank[i] = -1
+		if !act.StartTime.IsZero() {
+			timed = append(timed, i)
+		}
+	}
+	sort.SliceStable(timed, func(i, j int) bool {
+		return stops[timed[i]].StartTime.Before(stops[timed[j]].StartTime)
+	})
+	for rank, i := range timed {
+		r.rank[i] = rank
+	}
+
+	firsts := []int{-1}
+	if !hasStart {
+		firsts = []int{}
+		for i := range stops {
+			if r.rank[i] <= 0 {
+				firsts = append(firsts, i)
+			}
+		}
+	}
+
+	var (
+		best     []int
+		bestCost = math.MaxFloat64
+	)
+	for _, first := range firsts {
+		seq := r.twoOpt(r.nearestNeighbour(first))
+		if c := r.pathCost(seq); c < bestCost {
+			best, bestCost = seq, c
+		}
+	}
+
+	result := ActivityList{}
+	for _, i := range best {
+		result = append(result, stops[i])
+	}
+	return result
+}
+
+// node returns the index of the stop in the cost matrix.
+func (r stopsRoute) node(i int) int {
+	if r.hasStart {
+		return i + 1
+	}
+	return i
+}
+
+func (r stopsRoute) travel(from, to int) float64 {
+	c := r.cost[r.node(from)][r.node(to)]
+	if c < 0 {
+		return optimizerUnreachableCost
+	}
+	return c
+}
+
+// pathCost returns the travel time of the path, and the cost of the
+// visits it makes outside of the places' opening hours.
+func (r stopsRoute) pathCost(seq []int) float64 {
+	total := 0.0
+	clock := r.dayStart
+	for idx, i := range seq {
+		travel := 0.0
+		switch {
+		case idx > 0:
+			travel = r.travel(seq[idx-1], i)
+		case r.hasStart:
+			if travel = r.cost[0][r.node(i)]; travel < 0 {
+				travel = optimizerUnreachableCost
+			}
+		}
+		total += travel
+
+		act := r.stops[i]
+		if !act.StartTime.IsZero() {
+			clock = act.StartTime.Add(act.PlannedDuration())
+			continue
+		}
+		if travel < optimizerUnreachableCost {
+			clock = clock.Add(time.Duration(travel) * time.Second)
+		}
+		end := clock.Add(act.PlannedDuration())
+		if !act.Place.OpeningHours.IsOpenBetween(clock, end) {
+			total += optimizerClosedCost
+		}
+		clock = end
+	}
+	return total
+}
+
+func (r stopsRoute) isValid(seq []int) bool {
+	next := 0
+	for _, i := range seq {
+		if r.rank[i] < 0 {
+			continue
+		}
+		if r.rank[i] != next {
+			return false
+		}
+		next++
+	}
+	return true
+}
+
+// nearestNeighbour builds a path starting at the given stop, or at
+// the lodging if first is negative.
+func (r stopsRoute) nearestNeighbour(first int) []int {
+	visited := make([]bool, len(r.stops))
+	seq := []int{}
+	nextRank := 0
+	curr := first
+	if first >= 0 {
+		seq = append(seq, first)
+		visited[first] = true
+		if r.rank[first] == 0 {
+			nextRank++
+		}
+	}
+
+	for len(seq) < len(r.stops) {
+		best, bestCost := -1, math.MaxFloat64
+		for i := range r.stops {
+			if visited[i] || (r.rank[i] >= 0 && r.rank[i] != nextRank) {
+				continue
+			}
+			var c float64
+			if curr < 0 {
+				c = r.cost[0][r.node(i)]
+				if c < 0 {
+					c = optimizerUnreachableCost
+				}
+			} else {
+				c = r.travel(curr, i)
+			}
+			if c < bestCost {
+				best, bestCost = i, c
+			}
+		}
+		if r.rank[best] >= 0 {
+			nextRank++
+		}
+		visited[best] = true
+		seq = append(seq, best)
+		curr = best
+	}
+	return seq
+}
+
+func (r stopsRoute) twoOpt(seq []int) []int {
+	bestCost := r.pathCost(seq)
+	for pass := 0; pass < optimizerMax2OptPasses; pass++ {
+		improved := false
+		for i := 0; i < len(seq)-1; i++ {
+			for j := i + 1; j < len(seq); j++ {
+				candidate := append([]int{}, seq...)
+				for a, b := i, j; a < b; a, b = a+1, b-1 {
+					candidate[a], candidate[b] = candidate[b], candidate[a]
+				}
+				if !r.isValid(candidate) {
+					continue
+				}
+				if c := r.pathCost(candidate); c < bestCost-1e-9 {
+					seq, bestCost = candidate, c
+					improved = true
+				}
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+	return seq
+}
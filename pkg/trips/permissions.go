@@ -0,0 +1,259 @@
+package trips
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/travelreys/travelreys/pkg/common"
+)
+
+var (
+	ErrMemberNotFound    = errors.New("trips.ErrMemberNotFound")
+	ErrInvalidMemberRole = errors.New("trips.ErrInvalidMemberRole")
+)
+
+const (
+	PermissionView          = "view"
+	PermissionEditItinerary = "editItinerary"
+	PermissionEditBudget    = "editBudget"
+	PermissionManageMembers = "manageMembers"
+	PermissionManageSharing = "manageSharing"
+	PermissionUploadMedia   = "uploadMedia"
+
+	// PermissionManageTrip covers archiving, deleting and restoring the
+	// trip, changing the members' roles and transferring the ownership.
+	PermissionManageTrip = "manageTrip"
+
+	// permissionDenied is held by no role, e.g for the paths of the
+	// trip which are only changed by the server.
+	permissionDenied = ""
+)
+
+// itineraryPaths are the other top-level paths of the trip, which
+// need PermissionEditItinerary unless pathPermission says otherwise.
+var itineraryPaths = []string{
+	"name",
+	"coverImage",
+	"startDate",
+	"endDate",
+	"notes",
+	"transits",
+	"lodgings",
+	"links",
+	"itineraries",
+	"ideas",
+	"polls",
+	"checklists",
+	"files",
+	"labels",
+	"tags",
+}
+
+// RolePermissions is the permission matrix of the member roles.
+var RolePermissions = map[string][]string{
+	MemberRoleCreator: {
+		PermissionView,
+		PermissionEditItinerary,
+		PermissionEditBudget,
+		PermissionManageMembers,
+		PermissionManageSharing,
+		PermissionUploadMedia,
+		PermissionManageTrip,
+	},
+	MemberRoleCollaborator: {
+		PermissionView,
+		PermissionEditItinerary,
+		PermissionEditBudget,
+		PermissionManageMembers,
+		PermissionManageSharing,
+		PermissionUploadMedia,
+	},
+	MemberRoleParticipant: {
+		PermissionView,
+		PermissionUploadMedia,
+	},
+}
+
+// AssignableMemberRoles are the roles the creator can give to members.
+var AssignableMemberRoles = []string{
+	MemberRoleCollaborator,
+	MemberRoleParticipant,
+}
+
+// MemberRole returns the role of the member in the trip,
+// or an empty string if they are not a member.
+func (t Trip) MemberRole(memberID string) string {
+	if memberID == "" {
+		return ""
+	}
+	if t.Creator.ID == memberID {
+		return MemberRoleCreator
+	}
+	mem, ok := t.Members[memberID]
+	if !ok || mem == nil {
+		return ""
+	}
+	// Members added before roles were enforced collaborate, and only
+	// the trip's creator holds the creator role.
+	if mem.Role == "" || mem.Role == MemberRoleCreator {
+		return MemberRoleCollaborator
+	}
+	return mem.Role
+}
+
+func (t Trip) HasPermission(memberID, permission string) bool {
+	return common.StringContains(RolePermissions[t.MemberRole(memberID)], permission)
+}
+
+// CanApplyOps checks that the member has the permissions
+// to change every part of the trip touched by the ops.
+func (t Trip) CanApplyOps(memberID string, ops []SyncOp) bool {
+	for _, op := range ops {
+		if !t.HasPermission(memberID, pathPermission(op.Op, op.Path, memberID)) {
+			return false
+		}
+		hasFrom := op.Op == "move" || op.Op == "copy"
+		if hasFrom && !t.HasPermission(memberID, pathPermission(op.Op, op.From, memberID)) {
+			return false
+		}
+		if !t.HasPermission(memberID, PermissionManageTrip) && !t.keepsMemberRoles(op) {
+			return false
+		}
+		if !t.canActFor(memberID, op) {
+			return false
+		}
+	}
+	return true
+}
+
+// keepsMemberRoles checks that the op, when it writes whole members at
+// /members or /members/<memberID>, keeps the roles of the current members
+// and only gives assignable roles to new ones.
+func (t Trip) keepsMemberRoles(op SyncOp) bool {
+	tkns := strings.Split(op.Path, "/")
+	if len(tkns) < 2 || tkns[1] != "members" || len(tkns) > 3 || op.Op == "remove" {
+		return true
+	}
+	if op.Op == "move" || op.Op == "copy" {
+		return false
+	}
+
+	members := MembersMap{}
+	if len(tkns) == 2 {
+		if decodeSyncOpValue(op, &members) != nil {
+			return false
+		}
+	} else {
+		var mem Member
+		if decodeSyncOpValue(op, &mem) != nil {
+			return false
+		}
+		members[tkns[2]] = &mem
+	}
+	for ID, mem := range members {
+		if mem == nil {
+			continue
+		}
+		role := mem.Role
+		if role == "" {
+			role = MemberRoleCollaborator
+		}
+		curr := t.MemberRole(ID)
+		if curr == "" && !common.StringContains(AssignableMemberRoles, role) {
+			return false
+		}
+		if curr != "" && curr != role {
+			return false
+		}
+	}
+	return true
+}
+
+// canActFor checks that the ops made on behalf of a member, e.g
+// voting or settling up, are made by them whatever the sender's permissions.
+func (t Trip) canActFor(memberID string, op SyncOp) bool {
+	tkns := strings.Split(op.Path, "/")
+	if len(tkns) < 2 {
+		return true
+	}
+	if "/"+tkns[1] == JSONPathSettlementsRoot {
+		return t.canChangeSettlement(memberID, op, tkns)
+	}
+	if "/"+tkns[1] == JSONPathIdeasRoot && !t.canVoteIdea(memberID, op, tkns) {
+		return false
+	}
+	isPollsOp := "/"+tkns[1] == JSONPathPollsRoot || strings.HasPrefix(op.From, JSONPathPollsRoot+"/")
+	if isPollsOp && !t.canVotePoll(memberID, op, tkns) {
+		return false
+	}
+	return t.canSettlePriceItem(memberID, op)
+}
+
+// pathPermission returns the permission needed to change the trip at
+// the JSON patch path. Voting, ticking off to-dos and leaving the trip
+// are open to every member. The whole trip and the paths not listed,
+// e.g its ID, cannot be changed by anyone.
+func pathPermission(opType, path, memberID string) string {
+	tkns := strings.Split(path, "/")
+	if len(tkns) < 2 {
+		return permissionDenied
+	}
+
+	switch tkns[1] {
+	case "creator", "archived", "deleted", "deletedAt":
+		return PermissionManageTrip
+	case "privacy":
+		return PermissionManageSharing
+	case "members":
+		// /members/<memberID>/role
+		if len(tkns) > 3 && tkns[3] == "role" {
+			return PermissionManageTrip
+		}
+		if isLeavingPath(opType, tkns, memberID) {
+			return PermissionView
+		}
+		return PermissionManageMembers
+	case "membersId":
+		if isLeavingPath(opType, tkns, memberID) {
+			return PermissionView
+		}
+		return PermissionManageMembers
+	case "labels":
+		if len(tkns) == 2 || tkns[2] == LabelSharingAccess {
+			return PermissionManageSharing
+		}
+		if tkns[2] == LabelMemberContentPolicy {
+			return PermissionManageMembers
+		}
+	case "budget", "settlements":
+		return PermissionEditBudget
+	case "mediaItems":
+		return PermissionUploadMedia
+	case "ideas":
+		// /ideas/<ideaID>/labels/vote|<memberID>
+		if len(tkns) == 5 && tkns[3] == "labels" && tkns[4] == MakeIdeaVoteLabel(memberID) {
+			return PermissionView
+		}
+	case "polls":
+		// /polls/<pollID>/options/<optionID>/votes/<memberID>
+		if len(tkns) == 7 && tkns[3] == "options" && tkns[5] == "votes" && tkns[6] == memberID {
+			return PermissionView
+		}
+	case "checklists":
+		// /checklists/<checklistID>/items/<itemID>/checked
+		if len(tkns) == 6 && tkns[3] == "items" &&
+			common.StringContains([]string{"checked", "checkedBy", "checkedAt"}, tkns[5]) {
+			return PermissionView
+		}
+	}
+	if !common.StringContains(itineraryPaths, tkns[1]) {
+		return permissionDenied
+	}
+	return PermissionEditItinerary
+}
+
+// isLeavingPath returns true if the op removes the member from the
+// trip, i.e /members/<memberID> or /membersId/<memberID>.
+func isLeavingPath(opType string, tkns []string, memberID string) bool {
+	return opType == "remove" && len(tkns) == 3 && tkns[2] == memberID
+}
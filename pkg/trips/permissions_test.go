@@ -0,0 +1,76 @@
+package trips
+
+import "testing"
+
+func TestTripCanApplyOps(t *testing.T) {
+	trip := Trip{
+		Creator: NewCreator("creator"),
+		Members: MembersMap{
+			"collab": &Member{ID: "collab", Role: MemberRoleCollaborator},
+			"part":   &Member{ID: "part", Role: MemberRoleParticipant},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		memberID string
+		ops      []SyncOp
+		want     bool
+	}{
+		{
+			name:     "collaborator cannot replace the whole trip",
+			memberID: "collab",
+			ops:      []SyncOp{MakeRepSyncOp("", Trip{Creator: NewCreator("collab")})},
+			want:     false,
+		},
+		{
+			name:     "creator cannot replace the whole trip",
+			memberID: "creator",
+			ops:      []SyncOp{MakeRepSyncOp("", Trip{Creator: NewCreator("creator")})},
+			want:     false,
+		},
+		{
+			name:     "collaborator cannot replace the trip's root",
+			memberID: "collab",
+			ops:      []SyncOp{MakeRepSyncOp("/", "")},
+			want:     false,
+		},
+		{
+			name:     "collaborator cannot change unlisted paths",
+			memberID: "collab",
+			ops:      []SyncOp{MakeRepSyncOp("/id", "other")},
+			want:     false,
+		},
+		{
+			name:     "collaborator cannot move the trip into a listed path",
+			memberID: "collab",
+			ops:      []SyncOp{MakeMoveSyncOp("", "/notes")},
+			want:     false,
+		},
+		{
+			name:     "collaborator cannot take ownership",
+			memberID: "collab",
+			ops:      []SyncOp{MakeRepSyncOp("/creator", NewCreator("collab"))},
+			want:     false,
+		},
+		{
+			name:     "collaborator edits the itinerary",
+			memberID: "collab",
+			ops:      []SyncOp{MakeRepSyncOp("/notes", "notes")},
+			want:     true,
+		},
+		{
+			name:     "participant cannot edit the itinerary",
+			memberID: "part",
+			ops:      []SyncOp{MakeRepSyncOp("/notes", "notes")},
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := trip.CanApplyOps(tt.memberID, tt.ops); got != tt.want {
+				t.Errorf("CanApplyOps() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package trips
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/travelreys/travelreys/pkg/common"
+)
+
+const (
+	JSONPathPollsRoot = "/polls"
+)
+
+var (
+	ErrPollNotFound       = errors.New("trips.ErrPollNotFound")
+	ErrPollOptionNotFound = errors.New("trips.ErrPollOptionNotFound")
+	ErrPollClosed         = errors.New("trips.ErrPollClosed")
+)
+
+// PollOption is an alternative members vote on. It may propose a
+// lodging, an activity or a link, which is added to the trip when the
+// option is accepted.
+type PollOption struct {
+	ID       string    `json:"id" bson:"id"`
+	Title    string    `json:"title" bson:"title"`
+	Lodging  *Lodging  `json:"lodging,omitempty" bson:"lodging,omitempty"`
+	Activity *Activity `json:"activity,omitempty" bson:"activity,omitempty"`
+	Link     *Link     `json:"link,omitempty" bson:"link,omitempty"`
+
+	// DtKey is the day an accepted activity is added to.
+	// The activity is added to the ideas when empty.
+	DtKey string `json:"dtKey" bson:"dtKey"`
+
+	// Votes maps the voting members to the time of their vote.
+	Votes  map[string]time.Time `json:"votes" bson:"votes"`
+	Labels common.Labels        `json:"labels" bson:"labels"`
+}
+
+func NewPollOption(title string) PollOption {
+	return PollOption{
+		ID:     uuid.NewString(),
+		Title:  title,
+		Votes:  map[string]time.Time{},
+		Labels: common.Labels{},
+	}
+}
+
+// Voters returns the IDs of the members who voted for the option.
+func (o PollOption) Voters() []string {
+	voters := []string{}
+	for memberID := range o.Votes {
+		voters = append(voters, memberID)
+	}
+	sort.Strings(voters)
+	return voters
+}
+
+type PollOptionsMap map[string]*PollOption
+
+type Poll struct {
+	ID        string `json:"id" bson:"id"`
+	Title     string `json:"title" bson:"title"`
+	CreatorID string `json:"creatorID" bson:"creatorID"`
+
+	// MultipleChoice allows members to vote for more than one option.
+	MultipleChoice bool           `json:"multipleChoice" bson:"multipleChoice"`
+	Options        PollOptionsMap `json:"options" bson:"options"`
+
+	AcceptedOptionID string `json:"acceptedOptionID" bson:"acceptedOptionID"`
+
+	CreatedAt time.Time     `json:"createdAt" bson:"createdAt"`
+	Labels    common.Labels `json:"labels" bson:"labels"`
+}
+
+type PollsMap map[string]*Poll
+
+func NewPoll(creatorID, title string, multipleChoice bool) Poll {
+	return Poll{
+		ID:             uuid.NewString(),
+		Title:          title,
+		CreatorID:      creatorID,
+		MultipleChoice: multipleChoice,
+		Options:        PollOptionsMap{},
+		CreatedAt:      time.Now(),
+		Labels:         common.Labels{},
+	}
+}
+
+func (p Poll) IsClosed() bool {
+	return p.AcceptedOptionID != ""
+}
+
+// SortByVotes returns the options, most voted first.
+func (p Poll) SortByVotes() []*PollOption {
+	list := []*PollOption{}
+	for _, opt := range p.Options {
+		list = append(list, opt)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		vi, vj := len(list[i].Votes), len(list[j].Votes)
+		if vi != vj {
+			return vi > vj
+		}
+		return list[i].Title < list[j].Title
+	})
+	return list
+}
+
+// canVotePoll checks that the votes changed by the op on a poll are the
+// member's own, e.g /polls/<pollID>/options/<optionID>/votes/<memberID>.
+func (t Trip) canVotePoll(memberID string, op SyncOp, tkns []string) bool {
+	if op.Op == "move" || op.Op == "copy" {
+		return t.canMovePollVotes(memberID, op)
+	}
+	if len(tkns) < 3 {
+		return true
+	}
+	if len(tkns) == 7 && tkns[3] == "options" && tkns[5] == "votes" {
+		return tkns[6] == memberID
+	}
+
+	curr := PollOptionsMap{}
+	if poll, ok := t.Polls[tkns[2]]; ok && poll.Options != nil {
+		curr = poll.Options
+	}
+	options := PollOptionsMap{}
+	switch {
+	case len(tkns) == 3:
+		if op.Op == "remove" {
+			return true
+		}
+		var poll Poll
+		if decodeSyncOpValue(op, &poll) != nil {
+			return false
+		}
+		options = poll.Options
+	case len(tkns) == 4 && tkns[3] == "options":
+		if op.Op != "remove" && decodeSyncOpValue(op, &options) != nil {
+			return false
+		}
+	case len(tkns) == 5 && tkns[3] == "options":
+		if op.Op == "remove" {
+			return true
+		}
+		var opt PollOption
+		if decodeSyncOpValue(op, &opt) != nil {
+			return false
+		}
+		options[tkns[4]] = &opt
+	case len(tkns) == 6 && tkns[3] == "options" && tkns[5] == "votes":
+		votes := map[string]time.Time{}
+		if op.Op != "remove" && decodeSyncOpValue(op, &votes) != nil {
+			return false
+		}
+		options[tkns[4]] = &PollOption{Votes: votes}
+	default:
+		return true
+	}
+
+	for optID, opt := range options {
+		if opt == nil {
+			continue
+		}
+		currVotes := map[string]time.Time{}
+		if currOpt, ok := curr[optID]; ok && currOpt != nil {
+			currVotes = currOpt.Votes
+		}
+		for _, pair := range [][2]map[string]time.Time{{opt.Votes, currVotes}, {currVotes, opt.Votes}} {
+			for voterID, votedAt := range pair[0] {
+				if other, ok := pair[1][voterID]; voterID != memberID && (!ok || !other.Equal(votedAt)) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// canMovePollVotes checks a move or a copy as the addition of the
+// value at op.From to op.Path, and for a move its removal from op.From.
+func (t Trip) canMovePollVotes(memberID string, op SyncOp) bool {
+	val, err := t.valueAt(op.From)
+	if err != nil {
+		return false
+	}
+	checks := []SyncOp{MakeAddSyncOp(op.Path, val)}
+	if op.Op == "move" {
+		checks = append(checks, MakeRemoveSyncOp(op.From, ""))
+	}
+	for _, check := range checks {
+		tkns := strings.Split(check.Path, "/")
+		if len(tkns) < 2 || "/"+tkns[1] != JSONPathPollsRoot {
+			continue
+		}
+		if !t.canVotePoll(memberID, check, tkns) {
+			return false
+		}
+	}
+	return true
+}
+
+// Polls Sync Ops
+
+func MakePollPath(pollID string) string {
+	return fmt.Sprintf("%s/%s", JSONPathPollsRoot, pollID)
+}
+
+func MakePollOptionPath(pollID, optionID string) string {
+	return fmt.Sprintf("%s/options/%s", MakePollPath(pollID), optionID)
+}
+
+// SyncMsgTOBUpdateOpAddPoll
+func MakeSyncMsgTOBUpdateOpAddPollOps(poll Poll) []SyncOp {
+	return []SyncOp{MakeAddSyncOp(MakePollPath(poll.ID), poll)}
+}
+
+// SyncMsgTOBUpdateOpDeletePoll
+func MakeSyncMsgTOBUpdateOpDeletePollOps(pollID string) []SyncOp {
+	return []SyncOp{MakeRemoveSyncOp(MakePollPath(pollID), "")}
+}
+
+// SyncMsgTOBUpdateOpVotePoll
+func MakeSyncMsgTOBUpdateOpVotePollOps(pollID, optionID, memberID string, vote bool) []SyncOp {
+	path := fmt.Sprintf("%s/votes/%s", MakePollOptionPath(pollID, optionID), memberID)
+	if !vote {
+		return []SyncOp{MakeRemoveSyncOp(path, "")}
+	}
+	return []SyncOp{MakeAddSyncOp(path, time.Now())}
+}
+
+// SyncMsgTOBUpdateOpAcceptPollOption closes the poll and adds the
+// lodging, activity or link proposed by the option to the trip.
+func MakeSyncMsgTOBUpdateOpAcceptPollOptionOps(pollID string, opt PollOption) []SyncOp {
+	ops := []SyncOp{
+		MakeRepSyncOp(fmt.Sprintf("%s/acceptedOptionID", MakePollPath(pollID)), opt.ID),
+	}
+	switch {
+	case opt.Lodging != nil:
+		lodging := *opt.Lodging
+		lodging.ID = uuid.NewString()
+		ops = append(ops, MakeAddSyncOp(fmt.Sprintf("/lodgings/%s", lodging.ID), lodging))
+	case opt.Activity != nil:
+		act := *opt.Activity
+		act.ID = uuid.NewString()
+		path := MakeIdeaPath(act.ID)
+		if opt.DtKey != "" {
+			path = MakeActivityPath(opt.DtKey, act.ID)
+		}
+		ops = append(ops, MakeAddSyncOp(path, act))
+	case opt.Link != nil:
+		link := *opt.Link
+		link.ID = uuid.NewString()
+		ops = append(ops, MakeAddSyncOp(fmt.Sprintf("/links/%s", link.ID), link))
+	}
+	return ops
+}
@@ -0,0 +1,141 @@
+package trips
+
+import (
+	"math"
+	"strings"
+
+	"github.com/travelreys/travelreys/pkg/common"
+	"github.com/travelreys/travelreys/pkg/maps"
+	"github.com/travelreys/travelreys/pkg/media"
+)
+
+const (
+	// Media shown in the public views of the trip.
+	PrivacyMediaAll      = "all"
+	PrivacyMediaTrip     = "trip"
+	PrivacyMediaSelected = "selected"
+	PrivacyMediaNone     = "none"
+
+	// Fuzzed coordinates are rounded to 2 decimals, about a kilometer.
+	fuzzedCoordinatesScale = 100
+
+	maxPrivacyMediaItemIDs = 200
+)
+
+var (
+	PrivacyMediaList = []string{
+		PrivacyMediaAll,
+		PrivacyMediaTrip,
+		PrivacyMediaSelected,
+		PrivacyMediaNone,
+	}
+)
+
+// PrivacyPolicy decides what the public views of the trip show,
+// i.e its public page, the feeds of the followers and the link previews.
+// The zero value shows everything, except for members' private details.
+type PrivacyPolicy struct {
+	HideLodgings  bool `json:"hideLodgings" bson:"hideLodgings"`
+	FuzzLocations bool `json:"fuzzLocations" bson:"fuzzLocations"`
+	HideNotes     bool `json:"hideNotes" bson:"hideNotes"`
+
+	// HideMembers hides who joined the trip, the creator who
+	// published it is still shown.
+	HideMembers bool `json:"hideMembers" bson:"hideMembers"`
+
+	Media string `json:"media" bson:"media"`
+
+	// MediaItemIDs are the media shown with PrivacyMediaSelected.
+	MediaItemIDs []string `json:"mediaItemIDs" bson:"mediaItemIDs"`
+}
+
+func NewPrivacyPolicy() PrivacyPolicy {
+	return PrivacyPolicy{Media: PrivacyMediaAll, MediaItemIDs: []string{}}
+}
+
+func (p PrivacyPolicy) IsValid() bool {
+	if p.Media != "" && !common.StringContains(PrivacyMediaList, p.Media) {
+		return false
+	}
+	return len(p.MediaItemIDs) <= maxPrivacyMediaItemIDs
+}
+
+// IsMediaItemVisible checks if the media item, stored under
+// the key of the trip's media items, can be shown.
+func (p PrivacyPolicy) IsMediaItemVisible(key, ID string) bool {
+	switch p.Media {
+	case PrivacyMediaTrip:
+		return key == MediaItemKeyTrip
+	case PrivacyMediaSelected:
+		return common.StringContains(p.MediaItemIDs, ID)
+	case PrivacyMediaNone:
+		return false
+	}
+	return true
+}
+
+func (p PrivacyPolicy) FilterMediaItems(items map[string]media.MediaItemList) map[string]media.MediaItemList {
+	result := map[string]media.MediaItemList{}
+	for key, list := range items {
+		visible := media.MediaItemList{}
+		for _, item := range list {
+			if p.IsMediaItemVisible(key, item.ID) {
+				visible = append(visible, item)
+			}
+		}
+		result[key] = visible
+	}
+	return result
+}
+
+// IsCoverImageVisible checks if the cover image can be shown. Images
+// from the web are always shown, those from the trip follow the media policy.
+func (p PrivacyPolicy) IsCoverImageVisible(ci CoverImage) bool {
+	if ci.Source == CoverImageSourceWeb {
+		return true
+	}
+	key, ID, err := ci.SplitTripImageKey()
+	if err != nil {
+		return false
+	}
+	return p.IsMediaItemVisible(key, ID)
+}
+
+// RedactPlace returns a copy of the place without its exact location
+// if the policy fuzzes locations. The address is reduced to the
+// city and the country, and the coordinates are rounded.
+func (p PrivacyPolicy) RedactPlace(place maps.Place) maps.Place {
+	if !p.FuzzLocations {
+		return place
+	}
+	area := []string{}
+	for _, key := range []string{maps.LabelCity, maps.LabelCountry} {
+		if place.Labels[key] != "" {
+			area = append(area, place.Labels[key])
+		}
+	}
+
+	place.ID = ""
+	place.Address = strings.Join(area, ", ")
+	place.PhoneNumber = ""
+	place.LatLng = maps.LatLng{
+		Lat: math.Round(place.LatLng.Lat*fuzzedCoordinatesScale) / fuzzedCoordinatesScale,
+		Lng: math.Round(place.LatLng.Lng*fuzzedCoordinatesScale) / fuzzedCoordinatesScale,
+	}
+	labels := common.Labels{}
+	for _, key := range []string{maps.LabelCity, maps.LabelState, maps.LabelCountry} {
+		if val, ok := place.Labels[key]; ok {
+			labels[key] = val
+		}
+	}
+	place.Labels = labels
+	return place
+}
+
+// SyncMsgTOBUpdateOpUpdateTripPrivacyPolicy
+func MakeSyncMsgTOBUpdateOpUpdateTripPrivacyPolicyOps(policy PrivacyPolicy) []SyncOp {
+	if policy.MediaItemIDs == nil {
+		policy.MediaItemIDs = []string{}
+	}
+	return []SyncOp{MakeRepSyncOp("/privacy", policy)}
+}
@@ -0,0 +1,104 @@
+package trips
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/travelreys/travelreys/pkg/finance"
+	"github.com/travelreys/travelreys/pkg/storage"
+)
+
+const (
+	// LabelReceiptExpensePath marks a file as the receipt of the
+	// price item at the path.
+	LabelReceiptExpensePath = "receipt|expensePath"
+)
+
+func (trip Trip) receiptFiles(item finance.PriceItem) storage.ObjectList {
+	files := storage.ObjectList{}
+	for _, fileID := range item.Receipts {
+		if obj, ok := trip.Files[fileID]; ok && obj != nil {
+			files = append(files, *obj)
+		}
+	}
+	return files
+}
+
+// RemovedReceipts returns the receipt files which the ops removed
+// explicitly, with their price item or its receipts, from the previous
+// trip and which no price item references anymore. Files outside of the
+// trip's attachments are never returned.
+func (trip Trip) RemovedReceipts(prev Trip, ops []SyncOp) []*storage.Object {
+	referenced := map[string]bool{}
+	for _, e := range trip.priceItems() {
+		for _, fileID := range e.PriceItem.Receipts {
+			referenced[fileID] = true
+		}
+	}
+
+	removed := []*storage.Object{}
+	for _, e := range prev.priceItems() {
+		for idx, fileID := range e.PriceItem.Receipts {
+			obj, ok := trip.Files[fileID]
+			if !ok || obj == nil || referenced[fileID] {
+				continue
+			}
+			if obj.Labels[LabelReceiptExpensePath] == "" || !trip.isAttachmentPath(obj.Path) {
+				continue
+			}
+			if isReceiptRemovedBy(ops, e.Path, idx) {
+				referenced[fileID] = true
+				removed = append(removed, obj)
+			}
+		}
+	}
+	return removed
+}
+
+// isReceiptRemovedBy returns true if one of the ops removes the receipt
+// at idx of the price item at expensePath, or any of its parents.
+func isReceiptRemovedBy(ops []SyncOp, expensePath string, idx int) bool {
+	receiptPath := fmt.Sprintf("%s/receipts/%d", expensePath, idx)
+	for _, op := range ops {
+		if op.Op != "remove" || op.Path == "" {
+			continue
+		}
+		if op.Path == receiptPath || strings.HasPrefix(receiptPath, op.Path+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// isAttachmentPath returns true if the path is one of the
+// trip's files in the attachments bucket, i.e <tripID>/<fileID>.
+func (trip Trip) isAttachmentPath(path string) bool {
+	return trip.ID != "" && strings.HasPrefix(path, trip.ID+"/")
+}
+
+// Receipts Sync Ops
+
+// SyncMsgTOBUpdateOpAddReceipt adds the uploaded file to the trip and
+// references it from the price item at expensePath.
+func MakeSyncMsgTOBUpdateOpAddReceiptOps(expensePath string, item finance.PriceItem, obj storage.Object) []SyncOp {
+	if obj.Labels == nil {
+		obj.Labels = map[string]string{}
+	}
+	obj.Labels[LabelReceiptExpensePath] = expensePath
+	ops := []SyncOp{MakeAddSyncOp(fmt.Sprintf("/files/%s", obj.ID), obj)}
+	if len(item.Receipts) == 0 {
+		return append(ops, MakeAddSyncOp(fmt.Sprintf("%s/receipts", expensePath), []string{obj.ID}))
+	}
+	return append(ops, MakeAddSyncOp(fmt.Sprintf("%s/receipts/-", expensePath), obj.ID))
+}
+
+// SyncMsgTOBUpdateOpDeleteReceipt removes the reference to the file. The
+// coordinator deletes the file once it is not referenced anymore.
+func MakeSyncMsgTOBUpdateOpDeleteReceiptOps(expensePath string, item finance.PriceItem, fileID string) []SyncOp {
+	for idx, id := range item.Receipts {
+		if id == fileID {
+			return []SyncOp{MakeRemoveSyncOp(fmt.Sprintf("%s/receipts/%d", expensePath, idx), "")}
+		}
+	}
+	return []SyncOp{}
+}
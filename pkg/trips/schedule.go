@@ -0,0 +1,224 @@
+package trips
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// LabelScheduleWarnings is a derived activity label maintained by
+	// the coordinator. Its value is a comma separated list of warnings.
+	LabelScheduleWarnings = "schedule|warnings"
+
+	// LabelPlannedDuration is the planned duration of an activity,
+	// as a Go duration string (e.g 1h30m).
+	LabelPlannedDuration = "plannedDuration"
+
+	DefaultActivityDuration = 1 * time.Hour
+
+	ScheduleWarningOverlap             = "overlap"
+	ScheduleWarningImpossibleTransfer  = "impossibleTransfer"
+	ScheduleWarningLodgingCheckin      = "lodgingCheckin"
+	ScheduleWarningLodgingCheckout     = "lodgingCheckout"
+	ScheduleWarningOutsideOpeningHours = "outsideOpeningHours"
+
+	scheduleWarningsDelimiter = ","
+)
+
+// PlannedDuration returns the time the activity is expected to take.
+// It is read from LabelPlannedDuration, then from the activity's start
+// and end times, and defaults to DefaultActivityDuration.
+func (a Activity) PlannedDuration() time.Duration {
+	if d, err := time.ParseDuration(a.Labels[LabelPlannedDuration]); err == nil && d > 0 {
+		return d
+	}
+	if !a.StartTime.IsZero() && a.EndTime.After(a.StartTime) {
+		return a.EndTime.Sub(a.StartTime)
+	}
+	return DefaultActivityDuration
+}
+
+// ScheduleEntry is the computed time slot of an activity in the day.
+type ScheduleEntry struct {
+	ActivityID string    `json:"activityID"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+
+	// Travel is the duration of the route from the previous stop.
+	Travel   time.Duration `json:"travel"`
+	Warnings []string      `json:"warnings"`
+}
+
+func (e ScheduleEntry) IsTimed() bool {
+	return !e.Start.IsZero()
+}
+
+func (e ScheduleEntry) WarningsLabel() string {
+	return strings.Join(e.Warnings, scheduleWarningsDelimiter)
+}
+
+func (e *ScheduleEntry) addWarning(warning string) {
+	for _, w := range e.Warnings {
+		if w == warning {
+			return
+		}
+	}
+	e.Warnings = append(e.Warnings, warning)
+}
+
+// ItinerarySchedule is the list of schedule entries of a day, in
+// fractional index order.
+type ItinerarySchedule []*ScheduleEntry
+
+func (s ItinerarySchedule) Warnings() map[string]string {
+	result := map[string]string{}
+	for _, e := range s {
+		result[e.ActivityID] = e.WarningsLabel()
+	}
+	return result
+}
+
+// Schedule combines the activities' times, the route durations between
+// them and the lodging check-in/out of the day into a schedule, and
+// flags the entries which cannot happen as planned.
+func (itin Itinerary) Schedule(lodgings LodgingsMap, transits TransitsMap) ItinerarySchedule {
+	sorted := itin.SortActivities()
+	schedule := ItinerarySchedule{}
+	for _, act := range sorted {
+		entry := &ScheduleEntry{
+			ActivityID: act.ID,
+			Start:      act.StartTime,
+			End:        act.EndTime,
+			Warnings:   []string{},
+		}
+		if entry.IsTimed() && entry.End.Before(entry.Start) {
+			entry.End = entry.Start
+		}
+		schedule = append(schedule, entry)
+	}
+
+	// Route durations, including the route from the lodging
+	// or arriving transit to the first activity
+	var arrival time.Time
+	for idx, entry := range schedule {
+		if idx == 0 {
+			entry.Travel, arrival = itin.firstTravel(sorted[0], lodgings, transits)
+			continue
+		}
+		key := itin.routePairingKey(sorted[idx-1], sorted[idx])
+		if route, err := itin.Routes[key].GetMostCommonSenseRoute(); err == nil {
+			entry.Travel = route.Duration
+		}
+	}
+
+	// Impossible transfers from the arriving transit
+	// and between consecutive timed activities
+	if len(schedule) > 0 && schedule[0].IsTimed() && !arrival.IsZero() {
+		if schedule[0].Start.Before(arrival.Add(schedule[0].Travel)) {
+			schedule[0].addWarning(ScheduleWarningImpossibleTransfer)
+		}
+	}
+	for idx := 1; idx < len(schedule); idx++ {
+		prev, entry := schedule[idx-1], schedule[idx]
+		if !(prev.IsTimed() && entry.IsTimed()) || entry.Start.Before(prev.End) {
+			continue
+		}
+		if entry.Start.Sub(prev.End) < entry.Travel {
+			entry.addWarning(ScheduleWarningImpossibleTransfer)
+		}
+	}
+
+	// Overlaps between any pair of timed activities
+	timed := ItinerarySchedule{}
+	for _, entry := range schedule {
+		if entry.IsTimed() {
+			timed = append(timed, entry)
+		}
+	}
+	sort.SliceStable(timed, func(i, j int) bool {
+		return timed[i].Start.Before(timed[j].Start)
+	})
+	for i := 0; i < len(timed); i++ {
+		for j := i + 1; j < len(timed); j++ {
+			if !timed[j].Start.Before(timed[i].End) {
+				break
+			}
+			timed[i].addWarning(ScheduleWarningOverlap)
+			timed[j].addWarning(ScheduleWarningOverlap)
+		}
+	}
+
+	// Check-in and check-out are fixed events of the day
+	for _, l := range lodgings {
+		for _, entry := range timed {
+			if scheduleContains(entry, l.CheckinTime) {
+				entry.addWarning(ScheduleWarningLodgingCheckin)
+			}
+			if scheduleContains(entry, l.CheckoutTime) {
+				entry.addWarning(ScheduleWarningLodgingCheckout)
+			}
+		}
+	}
+
+	// Opening hours
+	for idx, entry := range schedule {
+		if !entry.IsTimed() {
+			continue
+		}
+		if !sorted[idx].Place.OpeningHours.IsOpenBetween(entry.Start, entry.End) {
+			entry.addWarning(ScheduleWarningOutsideOpeningHours)
+		}
+	}
+
+	return schedule
+}
+
+// scheduleContains reports whether t falls strictly within the entry.
+// Lodging times at midnight only carry a date and are ignored.
+// firstTravel returns the duration of the route to the first activity,
+// with the arrival time of the transit it starts from. The latest
+// arriving transit is used, else the first lodging by ID.
+func (itin Itinerary) firstTravel(
+	first *Activity,
+	lodgings LodgingsMap,
+	transits TransitsMap,
+) (time.Duration, time.Time) {
+	keys := []string{}
+	for key := range itin.RoutePairings(lodgings, transits) {
+		if strings.HasSuffix(key, LabelDelimeter+first.ID) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var (
+		travel  time.Duration
+		arrival time.Time
+		found   bool
+	)
+	for _, key := range keys {
+		route, err := itin.Routes[key].GetMostCommonSenseRoute()
+		if err != nil {
+			continue
+		}
+		t, isTransit := transits[strings.TrimSuffix(key, LabelDelimeter+first.ID)]
+		if !isTransit {
+			if !found {
+				travel, found = route.Duration, true
+			}
+			continue
+		}
+		if arrival.IsZero() || t.ArrivalTime.After(arrival) {
+			travel, arrival, found = route.Duration, t.ArrivalTime, true
+		}
+	}
+	return travel, arrival
+}
+
+func scheduleContains(entry *ScheduleEntry, t time.Time) bool {
+	if t.IsZero() || (t.Hour() == 0 && t.Minute() == 0) {
+		return false
+	}
+	return t.After(entry.Start) && t.Before(entry.End)
+}
@@ -0,0 +1,68 @@
+package trips
+
+import (
+	"sort"
+
+	"github.com/travelreys/travelreys/pkg/maps"
+)
+
+const (
+	bsonKeyIndexCountries      = "index.countries"
+	bsonKeyIndexCities         = "index.cities"
+	bsonKeyIndexActivityTitles = "index.activityTitles"
+)
+
+// SearchIndex holds the fields derived from the trip to filter and
+// search trips with. It is refreshed whenever the trip is saved.
+type SearchIndex struct {
+	Countries      []string `json:"countries" bson:"countries"`
+	Cities         []string `json:"cities" bson:"cities"`
+	ActivityTitles []string `json:"activityTitles" bson:"activityTitles"`
+}
+
+// MakeSearchIndex derives the destinations of the trip from the places
+// of its lodgings, transits' arrivals and scheduled activities.
+func (trip Trip) MakeSearchIndex() SearchIndex {
+	countries := map[string]bool{}
+	cities := map[string]bool{}
+	addPlace := func(place maps.Place) {
+		if country := place.Labels[maps.LabelCountry]; country != "" {
+			countries[country] = true
+		}
+		if city := place.Labels[maps.LabelCity]; city != "" {
+			cities[city] = true
+		}
+	}
+
+	titles := []string{}
+	for _, l := range trip.Lodgings {
+		addPlace(l.Place)
+	}
+	for _, t := range trip.Transits {
+		addPlace(t.ArrivalLocation)
+	}
+	for _, itin := range trip.Itineraries {
+		for _, act := range itin.Activities {
+			addPlace(act.Place)
+			if act.Title != "" {
+				titles = append(titles, act.Title)
+			}
+		}
+	}
+	sort.Strings(titles)
+
+	return SearchIndex{
+		Countries:      sortedKeys(countries),
+		Cities:         sortedKeys(cities),
+		ActivityTitles: titles,
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
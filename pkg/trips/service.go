@@ -1,6 +1,7 @@
 package trips
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"math/rand"
@@ -9,8 +10,11 @@ import (
 	"time"
 
 	"github.com/travelreys/travelreys/pkg/auth"
+	"github.com/travelreys/travelreys/pkg/email"
+	"github.com/travelreys/travelreys/pkg/finance"
 	"github.com/travelreys/travelreys/pkg/images"
 	"github.com/travelreys/travelreys/pkg/media"
+	"github.com/travelreys/travelreys/pkg/reqctx"
 	"github.com/travelreys/travelreys/pkg/storage"
 	"go.uber.org/zap"
 )
@@ -30,6 +34,12 @@ type Service interface {
 	ListWithMembers(ctx context.Context, ff ListFilter) (TripsList, auth.UsersMap, error)
 	Delete(ctx context.Context, ID string) error
 
+	// Lifecycle
+	Archive(ctx context.Context, ID string, archived bool) error
+	ListTrash(ctx context.Context, userID string) (TripsList, error)
+	Restore(ctx context.Context, ID string) error
+	PurgeTrash(ctx context.Context, now time.Time) ([]string, error)
+
 	// Attachments
 	UploadAttachmentPresignedURL(ctx context.Context, ID, fileID, fileType string) (string, error)
 	DownloadAttachmentPresignedURL(ctx context.Context, ID, path, fileID string) (string, error)
@@ -40,27 +50,58 @@ type Service interface {
 	SaveMediaItems(ctx context.Context, ID string, items media.MediaItemList) error
 	DeleteMediaItems(ctx context.Context, ID string, items media.MediaItemList) error
 	GenerateGetSignedURLs(ctx context.Context, ID string, items media.MediaItemList) (media.MediaPresignedUrlList, error)
+
+	// Exports
+	ExportItinerary(ctx context.Context, ID, format, dtKey string) (ExportFile, error)
+	GenerateBooklet(ctx context.Context, ID string, opts BookletOptions) (ExportFile, error)
+
+	// Itineraries
+	CopyActivities(ctx context.Context, ID string, opts CopyActivitiesOptions) error
+
+	// Budget
+	ReadLedger(ctx context.Context, ID, currency string) (Ledger, error)
+	ReadBudgetSummary(ctx context.Context, ID, currency string) (BudgetSummary, error)
+	ExportExpenses(ctx context.Context, ID, format, currency string) (ExportFile, error)
+	ImportBudgetItems(ctx context.Context, ID string, data []byte) (BudgetItemsList, error)
+
+	// Polls
+	AcceptPollOption(ctx context.Context, ID, pollID, optionID string) error
+
+	// Members
+	UpdateMemberRole(ctx context.Context, ID, memberID, role string) error
+	TransferOwnership(ctx context.Context, ID, memberID string) error
+	RemoveMember(ctx context.Context, ID, memberID string) error
+	LeaveTrip(ctx context.Context, ID, memberID string) error
+
+	// Privacy
+	UpdatePrivacyPolicy(ctx context.Context, ID string, policy PrivacyPolicy) error
 }
 
 type service struct {
 	store      Store
+	syncSvc    SyncService
 	authSvc    auth.Service
 	imageSvc   images.Service
 	mediaSvc   media.Service
 	storageSvc storage.Service
+	finSvc     finance.Service
+	mailSvc    email.Service
 
 	logger *zap.Logger
 }
 
 func NewService(
 	store Store,
+	syncSvc SyncService,
 	authSvc auth.Service,
 	imageSvc images.Service,
 	mediaSvc media.Service,
 	storageSvc storage.Service,
+	finSvc finance.Service,
+	mailSvc email.Service,
 	logger *zap.Logger,
 ) Service {
-	return &service{store, authSvc, imageSvc, mediaSvc, storageSvc, logger}
+	return &service{store, syncSvc, authSvc, imageSvc, mediaSvc, storageSvc, finSvc, mailSvc, logger}
 }
 
 func (svc *service) tripFromContext(ctx context.Context, ID string) (*Trip, error) {
@@ -128,7 +169,10 @@ func (svc *service) ReadOGP(ctx context.Context, ID string) (TripOGP, error) {
 		return TripOGP{}, err
 	}
 
-	contentURL, _ := svc.augmentCoverImageURL(ctx, trip)
+	contentURL := ""
+	if trip.Privacy.IsCoverImageVisible(*trip.CoverImage) {
+		contentURL, _ = svc.augmentCoverImageURL(ctx, trip)
+	}
 	return trip.ToOGP(creator.Username, contentURL), nil
 }
 
@@ -272,6 +316,256 @@ func (svc *service) GenerateGetSignedURLs(ctx context.Context, ID string, items
 	return svc.mediaSvc.GenerateGetSignedURLs(ctx, items)
 }
 
+// Exports
+
+func (svc *service) ExportItinerary(ctx context.Context, ID, format, dtKey string) (ExportFile, error) {
+	trip, err := svc.tripFromContext(ctx, ID)
+	if err != nil {
+		return ExportFile{}, err
+	}
+	return ExportItinerary(trip, format, dtKey)
+}
+
+func (svc *service) GenerateBooklet(ctx context.Context, ID string, opts BookletOptions) (ExportFile, error) {
+	trip, err := svc.tripFromContext(ctx, ID)
+	if err != nil {
+		return ExportFile{}, err
+	}
+	if opts.Locale == "" {
+		opts.Locale = svc.userLocale(ctx)
+	}
+	return GenerateBooklet(trip, opts)
+}
+
+// Budget
+
+func (svc *service) ReadLedger(ctx context.Context, ID, currency string) (Ledger, error) {
+	trip, err := svc.tripFromContext(ctx, ID)
+	if err != nil {
+		return Ledger{}, err
+	}
+	if currency == "" {
+		currency = svc.userCurrency(ctx)
+	}
+	return MakeLedger(trip, svc.fxRates(ctx, currency, trip)), nil
+}
+
+func (svc *service) ReadBudgetSummary(ctx context.Context, ID, currency string) (BudgetSummary, error) {
+	trip, err := svc.tripFromContext(ctx, ID)
+	if err != nil {
+		return BudgetSummary{}, err
+	}
+	if currency == "" {
+		currency = trip.Budget.Currency()
+	}
+	return MakeBudgetSummary(trip, svc.fxRates(ctx, currency, trip)), nil
+}
+
+func (svc *service) ExportExpenses(ctx context.Context, ID, format, currency string) (ExportFile, error) {
+	trip, err := svc.tripFromContext(ctx, ID)
+	if err != nil {
+		return ExportFile{}, err
+	}
+	if currency == "" {
+		currency = trip.Budget.Currency()
+	}
+
+	names := map[string]string{}
+	users, err := svc.authSvc.List(ctx, auth.ListFilter{IDs: trip.GetMemberIDs()})
+	if err != nil {
+		svc.logger.Warn("ExportExpenses", zap.Error(err))
+	}
+	for _, usr := range users {
+		names[usr.ID] = usr.Name
+	}
+	return ExportExpenses(trip, format, svc.fxRates(ctx, currency, trip), names)
+}
+
+func (svc *service) ImportBudgetItems(ctx context.Context, ID string, data []byte) (BudgetItemsList, error) {
+	trip, err := svc.tripFromContext(ctx, ID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := ImportBudgetItemsCSV(bytes.NewReader(data), trip.Budget.Currency())
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return items, nil
+	}
+	err = svc.syncUpdate(
+		ctx,
+		ID,
+		SyncMsgTOBUpdateOpImportBudgetItems,
+		MakeSyncMsgTOBUpdateOpImportBudgetItemsOps(items),
+	)
+	return items, err
+}
+
+func (svc *service) AcceptPollOption(ctx context.Context, ID, pollID, optionID string) error {
+	trip, err := svc.tripFromContext(ctx, ID)
+	if err != nil {
+		return err
+	}
+	poll, ok := trip.Polls[pollID]
+	if !ok {
+		return ErrPollNotFound
+	}
+	if poll.IsClosed() {
+		return ErrPollClosed
+	}
+	opt, ok := poll.Options[optionID]
+	if !ok {
+		return ErrPollOptionNotFound
+	}
+	if opt.DtKey != "" {
+		if _, ok := trip.Itineraries[opt.DtKey]; !ok {
+			return ErrItineraryNotFound
+		}
+	}
+	return svc.syncUpdate(
+		ctx,
+		ID,
+		SyncMsgTOBUpdateOpAcceptPollOption,
+		MakeSyncMsgTOBUpdateOpAcceptPollOptionOps(pollID, *opt),
+	)
+}
+
+// Members
+
+func (svc *service) UpdateMemberRole(ctx context.Context, ID, memberID, role string) error {
+	trip, err := svc.tripFromContext(ctx, ID)
+	if err != nil {
+		return err
+	}
+	if _, ok := trip.Members[memberID]; !ok {
+		return ErrMemberNotFound
+	}
+	return svc.syncUpdate(
+		ctx,
+		ID,
+		SyncMsgTOBUpdateOpUpdateTripMemberRole,
+		MakeSyncMsgTOBUpdateOpUpdateTripMemberRoleOps(memberID, role),
+	)
+}
+
+// TransferOwnership makes the member the creator of the trip.
+// The previous creator stays on as a collaborator.
+func (svc *service) TransferOwnership(ctx context.Context, ID, memberID string) error {
+	trip, err := svc.tripFromContext(ctx, ID)
+	if err != nil {
+		return err
+	}
+	member, ok := trip.Members[memberID]
+	if !ok {
+		return ErrMemberNotFound
+	}
+	return svc.syncUpdate(
+		ctx,
+		ID,
+		SyncMsgTOBUpdateOpTransferTripOwnership,
+		MakeSyncMsgTOBUpdateOpTransferTripOwnershipOps(trip.Creator, *member),
+	)
+}
+
+func (svc *service) RemoveMember(ctx context.Context, ID, memberID string) error {
+	return svc.removeMember(ctx, ID, memberID, false)
+}
+
+// LeaveTrip removes the member from the trip. The creator
+// has to transfer the ownership before leaving.
+func (svc *service) LeaveTrip(ctx context.Context, ID, memberID string) error {
+	return svc.removeMember(ctx, ID, memberID, true)
+}
+
+func (svc *service) removeMember(ctx context.Context, ID, memberID string, hasLeft bool) error {
+	trip, err := svc.tripFromContext(ctx, ID)
+	if err != nil {
+		return err
+	}
+	if hasLeft && trip.Creator.ID == memberID {
+		return ErrCreatorCannotLeave
+	}
+	if _, ok := trip.Members[memberID]; !ok {
+		return ErrMemberNotFound
+	}
+
+	err = svc.syncUpdate(
+		ctx,
+		ID,
+		SyncMsgTOBUpdateOpRemoveTripMember,
+		MakeSyncMsgTOBUpdateOpRemoveTripMemberOps(memberID),
+	)
+	if err == nil {
+		go svc.sendMemberRemovedEmail(context.Background(), trip, memberID, hasLeft)
+	}
+	return err
+}
+
+// Privacy
+
+func (svc *service) UpdatePrivacyPolicy(ctx context.Context, ID string, policy PrivacyPolicy) error {
+	if policy.Media == "" {
+		policy.Media = PrivacyMediaAll
+	}
+	return svc.syncUpdate(
+		ctx,
+		ID,
+		SyncMsgTOBUpdateOpUpdateTripPrivacy,
+		MakeSyncMsgTOBUpdateOpUpdateTripPrivacyPolicyOps(policy),
+	)
+}
+
+// syncUpdate applies the ops to the trip through the collaboration
+// session, on behalf of the requesting user.
+func (svc *service) syncUpdate(ctx context.Context, tripID, op string, ops []SyncOp) error {
+	ci, err := reqctx.ClientInfoFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+	return SyncUpdate(ctx, svc.syncSvc, tripID, ci.UserID, op, ops)
+}
+
+// fxRates returns the exchange rates for the base currency at the dates
+// of the trip's transactions. When rates are unavailable, only amounts
+// in the base currency can be converted.
+func (svc *service) fxRates(ctx context.Context, base string, trip *Trip) finance.ExchangeRatesByDate {
+	rates, err := svc.finSvc.GetFxRatesForDates(ctx, base, trip.TransactionDates())
+	if err != nil {
+		svc.logger.Warn("fxRates", zap.String("base", base), zap.Error(err))
+		return finance.NewExchangeRatesByDate(finance.ExchangeRates{
+			Base: base, Rates: map[string]float64{base: 1},
+		})
+	}
+	return rates
+}
+
+// userCurrency returns the default currency of the requesting user.
+func (svc *service) userCurrency(ctx context.Context) string {
+	ci, err := reqctx.ClientInfoFromCtx(ctx)
+	if err != nil || ci.HasEmptyID() {
+		return DefaultLedgerCurrency
+	}
+	user, err := svc.authSvc.Read(ctx, ci.UserID)
+	if err != nil || user.Labels[auth.LabelDefaultCurrency] == "" {
+		return DefaultLedgerCurrency
+	}
+	return user.Labels[auth.LabelDefaultCurrency]
+}
+
+// userLocale returns the default locale of the requesting user, if any.
+func (svc *service) userLocale(ctx context.Context) string {
+	ci, err := reqctx.ClientInfoFromCtx(ctx)
+	if err != nil || ci.HasEmptyID() {
+		return ""
+	}
+	user, err := svc.authSvc.Read(ctx, ci.UserID)
+	if err != nil {
+		return ""
+	}
+	return user.Labels[auth.LabelDefaultLocale]
+}
+
 func (svc *service) augmentMediaItemURLs(ctx context.Context, trip *Trip) {
 	for key := range trip.MediaItems {
 		urls, _ := svc.mediaSvc.GenerateGetSignedURLs(ctx, trip.MediaItems[key])
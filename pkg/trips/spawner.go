@@ -3,8 +3,10 @@ package trips
 import (
 	"sync"
 
+	"github.com/travelreys/travelreys/pkg/finance"
 	"github.com/travelreys/travelreys/pkg/maps"
 	"github.com/travelreys/travelreys/pkg/media"
+	"github.com/travelreys/travelreys/pkg/storage"
 	"go.uber.org/zap"
 )
 
@@ -12,8 +14,10 @@ type Spawner struct {
 	crds map[string]struct{} // map of coordinators by tripIDs
 	mu   sync.Mutex
 
-	mapsSvc  maps.Service
-	mediaSvc media.Service
+	mapsSvc    maps.Service
+	mediaSvc   media.Service
+	finSvc     finance.Service
+	storageSvc storage.Service
 
 	store     Store
 	sessStore SessionStore
@@ -24,19 +28,23 @@ type Spawner struct {
 func NewSpawner(
 	mapsSvc maps.Service,
 	mediaSvc media.Service,
+	finSvc finance.Service,
+	storageSvc storage.Service,
 	store Store,
 	sessStore SessionStore,
 	msgStore SyncMsgStore,
 	logger *zap.Logger,
 ) *Spawner {
 	return &Spawner{
-		crds:      make(map[string]struct{}),
-		mapsSvc:   mapsSvc,
-		mediaSvc:  mediaSvc,
-		store:     store,
-		sessStore: sessStore,
-		msgStore:  msgStore,
-		logger:    logger.Named("trips.spawner"),
+		crds:       make(map[string]struct{}),
+		mapsSvc:    mapsSvc,
+		mediaSvc:   mediaSvc,
+		finSvc:     finSvc,
+		storageSvc: storageSvc,
+		store:      store,
+		sessStore:  sessStore,
+		msgStore:   msgStore,
+		logger:     logger.Named("trips.spawner"),
 	}
 }
 
@@ -81,6 +89,8 @@ func (spwn *Spawner) Run() error {
 			msg.TripID,
 			spwn.mapsSvc,
 			spwn.mediaSvc,
+			spwn.finSvc,
+			spwn.storageSvc,
 			spwn.store,
 			spwn.sessStore,
 			spwn.msgStore,
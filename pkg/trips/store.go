@@ -3,6 +3,7 @@ package trips
 import (
 	context "context"
 	"errors"
+	"time"
 
 	"github.com/travelreys/travelreys/pkg/common"
 	"go.mongodb.org/mongo-driver/bson"
@@ -16,7 +17,10 @@ const (
 
 	bsonKeyID        = "id"
 	bsonKeyCreatorId = "creator.id"
+	bsonKeyArchived  = "archived"
 	bsonKeyDeleted   = "deleted"
+	bsonKeyDeletedAt = "deletedAt"
+	bsonKeyIndex     = "index"
 
 	storeLoggerName = "trips.store"
 )
@@ -31,6 +35,9 @@ type Store interface {
 	Read(ctx context.Context, ID string) (*Trip, error)
 	List(ctx context.Context, ff ListFilter) (TripsList, error)
 	Delete(ctx context.Context, ID string) error
+
+	// Migrate brings the trips saved by previous versions up to date.
+	Migrate(ctx context.Context) error
 }
 
 type store struct {
@@ -46,11 +53,20 @@ func NewStore(ctx context.Context, db *mongo.Database, logger *zap.Logger) Store
 		{Keys: bson.M{bsonKeyID: 1}},
 		{Keys: bson.M{bsonKeyCreatorId: 1}},
 		{Keys: bson.M{"membersId.$**": 1}},
+		{Keys: bson.M{bsonKeyIndexCountries: 1}},
+		{Keys: bson.M{bsonKeyIndexCities: 1}},
+		{Keys: bson.D{
+			{Key: "name", Value: "text"},
+			{Key: "notes", Value: "text"},
+			{Key: bsonKeyIndexActivityTitles, Value: "text"},
+		}},
 	})
 	return &store{db, coll, logger.Named(storeLoggerName)}
 }
 
 func (s *store) Save(ctx context.Context, trip *Trip) error {
+	trip.Index = trip.MakeSearchIndex()
+	trip.UpdatedAt = time.Now()
 	saveFF := bson.M{bsonKeyID: trip.ID}
 	opts := options.Replace().SetUpsert(true)
 	_, err := s.coll.ReplaceOne(ctx, saveFF, trip, opts)
@@ -81,7 +97,10 @@ func (s *store) List(ctx context.Context, ff ListFilter) (TripsList, error) {
 		return list, nil
 	}
 
-	opts := options.Find().SetSort(bson.M{"startDate": -1})
+	opts := options.Find().SetSort(ff.toSortBSON())
+	if ff.Limit > 0 {
+		opts.SetLimit(ff.Limit)
+	}
 	cursor, err := s.coll.Find(ctx, bsonM, opts)
 	if err != nil {
 		s.logger.Error(
@@ -96,10 +115,65 @@ func (s *store) List(ctx context.Context, ff ListFilter) (TripsList, error) {
 }
 
 func (s *store) Delete(ctx context.Context, ID string) error {
-	_, err := s.coll.DeleteOne(ctx, bson.M{ID: ID})
+	_, err := s.coll.DeleteOne(ctx, bson.M{bsonKeyID: ID})
 	if err != nil {
 		s.logger.Error("Delete", zap.String("id", ID), zap.Error(err))
 		return ErrUnexpectedStoreError
 	}
 	return err
 }
+
+func (s *store) Migrate(ctx context.Context) error {
+	if err := s.backfillDeletedAt(ctx); err != nil {
+		return err
+	}
+	return s.backfillSearchIndex(ctx)
+}
+
+// backfillDeletedAt starts the trash retention of the trips deleted
+// before their deletion time was recorded, instead of purging them.
+func (s *store) backfillDeletedAt(ctx context.Context) error {
+	res, err := s.coll.UpdateMany(
+		ctx,
+		bson.M{bsonKeyDeleted: true, bsonKeyDeletedAt: nil},
+		bson.M{"$set": bson.M{bsonKeyDeletedAt: time.Now()}},
+	)
+	if err != nil {
+		s.logger.Error("backfillDeletedAt", zap.Error(err))
+		return ErrUnexpectedStoreError
+	}
+	s.logger.Info("backfillDeletedAt", zap.Int64("count", res.ModifiedCount))
+	return nil
+}
+
+// backfillSearchIndex indexes the trips saved before they had a
+// search index, which are otherwise only indexed on their next save.
+func (s *store) backfillSearchIndex(ctx context.Context) error {
+	cursor, err := s.coll.Find(ctx, bson.M{bsonKeyIndex: bson.M{"$exists": false}})
+	if err != nil {
+		s.logger.Error("backfillSearchIndex", zap.Error(err))
+		return ErrUnexpectedStoreError
+	}
+	defer cursor.Close(ctx)
+
+	count := 0
+	for cursor.Next(ctx) {
+		var trip Trip
+		if err := cursor.Decode(&trip); err != nil {
+			s.logger.Error("backfillSearchIndex", zap.Error(err))
+			continue
+		}
+		_, err := s.coll.UpdateOne(
+			ctx,
+			bson.M{bsonKeyID: trip.ID},
+			bson.M{"$set": bson.M{bsonKeyIndex: trip.MakeSearchIndex()}},
+		)
+		if err != nil {
+			s.logger.Error("backfillSearchIndex", zap.String("id", trip.ID), zap.Error(err))
+			continue
+		}
+		count++
+	}
+	s.logger.Info("backfillSearchIndex", zap.Int("count", count))
+	return cursor.Err()
+}
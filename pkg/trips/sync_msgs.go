@@ -40,6 +40,7 @@ const (
 	SyncMsgBroadcastTopicPing        = "SyncMsgBroadcastTopicPing"
 	SyncMsgBroadcastTopiCursor       = "SyncMsgBroadcastTopicCursor"
 	SyncMsgBroadcastTopiFormPresence = "SyncMsgBroadcastTopicFormPresence"
+	SyncMsgBroadcastTopicComment     = "SyncMsgBroadcastTopicComment"
 )
 
 type SyncMsgBroadcast struct {
@@ -50,6 +51,7 @@ type SyncMsgBroadcast struct {
 	Ping         *SyncMsgBroadcastPayloadPing         `json:"ping,omitempty"`
 	Cursor       *SyncMsgBroadcastPayloadCursor       `json:"cursor,omitempty"`
 	FormPresence *SyncMsgBroadcastPayloadFormPresence `json:"formPresence,omitempty"`
+	Comment      *SyncMsgBroadcastPayloadComment      `json:"comment,omitempty"`
 }
 
 type SyncMsgBroadcastPayloadPing struct {
@@ -62,6 +64,37 @@ type SyncMsgBroadcastPayloadFormPresence struct {
 	EditPath string `json:"editPath"`
 }
 
+const (
+	SyncMsgBroadcastCommentActionAdd    = "add"
+	SyncMsgBroadcastCommentActionUpdate = "update"
+	SyncMsgBroadcastCommentActionDelete = "delete"
+)
+
+type SyncMsgBroadcastPayloadComment struct {
+	Action  string      `json:"action"`
+	Comment interface{} `json:"comment"`
+}
+
+func MakeSyncMsgBroadcastTopicComment(
+	tripID,
+	mem,
+	action string,
+	comment interface{},
+) SyncMsgBroadcast {
+	return SyncMsgBroadcast{
+		SyncMsg: SyncMsg{
+			Type:     SyncMsgTypeBroadcast,
+			TripID:   tripID,
+			MemberID: mem,
+		},
+		Topic: SyncMsgBroadcastTopicComment,
+		Comment: &SyncMsgBroadcastPayloadComment{
+			Action:  action,
+			Comment: comment,
+		},
+	}
+}
+
 func MakeSyncMsgBroadcastTopicPing(
 	connID,
 	tripID string,
@@ -84,21 +117,65 @@ const (
 	SyncMsgTOBTopicUpdate = "SyncMsgTOBTopicUpdate"
 
 	// Trip
-	SyncMsgTOBUpdateOpDeleteTrip        = "SyncMsgTOBUpdateOpDeleteTrip"
-	SyncMsgTOBUpdateOpUpdateTripDates   = "SyncMsgTOBUpdateOpUpdateTripDates"
-	SyncMsgTOBUpdateOpUpdateTripMembers = "SyncMsgTOBUpdateOpUpdateTripMembers"
+	SyncMsgTOBUpdateOpArchiveTrip           = "SyncMsgTOBUpdateOpArchiveTrip"
+	SyncMsgTOBUpdateOpDeleteTrip            = "SyncMsgTOBUpdateOpDeleteTrip"
+	SyncMsgTOBUpdateOpOptimizeTrip          = "SyncMsgTOBUpdateOpOptimizeTrip"
+	SyncMsgTOBUpdateOpRemoveTripMember      = "SyncMsgTOBUpdateOpRemoveTripMember"
+	SyncMsgTOBUpdateOpTransferTripOwnership = "SyncMsgTOBUpdateOpTransferTripOwnership"
+	SyncMsgTOBUpdateOpUpdateTripDates       = "SyncMsgTOBUpdateOpUpdateTripDates"
+	SyncMsgTOBUpdateOpUpdateTripMembers     = "SyncMsgTOBUpdateOpUpdateTripMembers"
+	SyncMsgTOBUpdateOpUpdateTripMemberRole  = "SyncMsgTOBUpdateOpUpdateTripMemberRole"
+	SyncMsgTOBUpdateOpUpdateTripPrivacy     = "SyncMsgTOBUpdateOpUpdateTripPrivacy"
 
 	// Lodgings
 	SyncMsgTOBUpdateOpAddLodging    = "SyncMsgTOBUpdateOpAddLodging"
 	SyncMsgTOBUpdateOpDeleteLodging = "SyncMsgTOBUpdateOpDeleteLodging"
 	SyncMsgTOBUpdateOpUpdateLodging = "SyncMsgTOBUpdateOpUpdateLodging"
 
+	// Transits
+	SyncMsgTOBUpdateOpAddTransit    = "SyncMsgTOBUpdateOpAddTransit"
+	SyncMsgTOBUpdateOpDeleteTransit = "SyncMsgTOBUpdateOpDeleteTransit"
+	SyncMsgTOBUpdateOpUpdateTransit = "SyncMsgTOBUpdateOpUpdateTransit"
+
 	// Itinerary
+	SyncMsgTOBUpdateOpCascadeActivityTimes        = "SyncMsgTOBUpdateOpCascadeActivityTimes"
+	SyncMsgTOBUpdateOpCopyActivities              = "SyncMsgTOBUpdateOpCopyActivities"
 	SyncMsgTOBUpdateOpDeleteActivity              = "SyncMsgTOBUpdateOpDeleteActivity"
 	SyncMsgTOBUpdateOpOptimizeItinerary           = "SyncMsgTOBUpdateOpOptimizeItinerary"
 	SyncMsgTOBUpdateOpReorderActivityToAnotherDay = "SyncMsgTOBUpdateOpReorderActivityToAnotherDay"
 	SyncMsgTOBUpdateOpReorderItinerary            = "SyncMsgTOBUpdateOpReorderItinerary"
 	SyncMsgTOBUpdateOpUpdateActivityPlace         = "SyncMsgTOBUpdateOpUpdateActivityPlace"
+	SyncMsgTOBUpdateOpUpdateRouteMode             = "SyncMsgTOBUpdateOpUpdateRouteMode"
+
+	// Ideas
+	SyncMsgTOBUpdateOpAddIdea             = "SyncMsgTOBUpdateOpAddIdea"
+	SyncMsgTOBUpdateOpDeleteIdea          = "SyncMsgTOBUpdateOpDeleteIdea"
+	SyncMsgTOBUpdateOpUpdateIdea          = "SyncMsgTOBUpdateOpUpdateIdea"
+	SyncMsgTOBUpdateOpVoteIdea            = "SyncMsgTOBUpdateOpVoteIdea"
+	SyncMsgTOBUpdateOpMoveIdeaToItinerary = "SyncMsgTOBUpdateOpMoveIdeaToItinerary"
+	SyncMsgTOBUpdateOpMoveActivityToIdeas = "SyncMsgTOBUpdateOpMoveActivityToIdeas"
+
+	// Polls
+	SyncMsgTOBUpdateOpAddPoll          = "SyncMsgTOBUpdateOpAddPoll"
+	SyncMsgTOBUpdateOpDeletePoll       = "SyncMsgTOBUpdateOpDeletePoll"
+	SyncMsgTOBUpdateOpVotePoll         = "SyncMsgTOBUpdateOpVotePoll"
+	SyncMsgTOBUpdateOpAcceptPollOption = "SyncMsgTOBUpdateOpAcceptPollOption"
+
+	// Checklists
+	SyncMsgTOBUpdateOpAddChecklist         = "SyncMsgTOBUpdateOpAddChecklist"
+	SyncMsgTOBUpdateOpDeleteChecklist      = "SyncMsgTOBUpdateOpDeleteChecklist"
+	SyncMsgTOBUpdateOpAddChecklistItem     = "SyncMsgTOBUpdateOpAddChecklistItem"
+	SyncMsgTOBUpdateOpUpdateChecklistItem  = "SyncMsgTOBUpdateOpUpdateChecklistItem"
+	SyncMsgTOBUpdateOpDeleteChecklistItem  = "SyncMsgTOBUpdateOpDeleteChecklistItem"
+	SyncMsgTOBUpdateOpToggleChecklistItem  = "SyncMsgTOBUpdateOpToggleChecklistItem"
+	SyncMsgTOBUpdateOpReorderChecklistItem = "SyncMsgTOBUpdateOpReorderChecklistItem"
+
+	// Budget
+	SyncMsgTOBUpdateOpSettlePriceItem   = "SyncMsgTOBUpdateOpSettlePriceItem"
+	SyncMsgTOBUpdateOpSettleTransfer    = "SyncMsgTOBUpdateOpSettleTransfer"
+	SyncMsgTOBUpdateOpAddReceipt        = "SyncMsgTOBUpdateOpAddReceipt"
+	SyncMsgTOBUpdateOpDeleteReceipt     = "SyncMsgTOBUpdateOpDeleteReceipt"
+	SyncMsgTOBUpdateOpImportBudgetItems = "SyncMsgTOBUpdateOpImportBudgetItems"
 
 	// Media
 	SyncMsgTOBUpdateOpAddMediaItem = "SyncMsgTOBUpdateOpAddMediaItem"
@@ -131,6 +208,10 @@ type SyncMsgTOBPayloadLeave struct {
 type SyncMsgTOBPayloadUpdate struct {
 	Op  string   `json:"op"`
 	Ops []SyncOp `json:"ops"`
+
+	// Err is set when the coordinator rejects the update,
+	// in which case the ops are dropped.
+	Err string `json:"error,omitempty"`
 }
 
 func MakeSyncMsgTOBTopicJoin(
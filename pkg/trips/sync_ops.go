@@ -21,6 +21,10 @@ func MakeRepSyncOp(path string, val interface{}) SyncOp {
 	return SyncOp{"replace", path, val, ""}
 }
 
+func MakeMoveSyncOp(from, path string) SyncOp {
+	return SyncOp{"move", path, nil, from}
+}
+
 // SyncMsgTOBUpdateOpUpdateTripMembers
 func MakeSyncMsgTOBUpdateOpUpdateTripMembersOps(mem Member) []SyncOp {
 	return []SyncOp{
@@ -28,3 +32,22 @@ func MakeSyncMsgTOBUpdateOpUpdateTripMembersOps(mem Member) []SyncOp {
 		MakeAddSyncOp(fmt.Sprintf("/membersId/%s", mem.ID), mem.ID),
 	}
 }
+
+// SyncMsgTOBUpdateOpUpdateTripMemberRole
+func MakeSyncMsgTOBUpdateOpUpdateTripMemberRoleOps(memberID, role string) []SyncOp {
+	return []SyncOp{MakeRepSyncOp(fmt.Sprintf("/members/%s/role", memberID), role)}
+}
+
+// SyncMsgTOBUpdateOpTransferTripOwnership makes the member the creator
+// of the trip, and the previous creator a collaborator.
+func MakeSyncMsgTOBUpdateOpTransferTripOwnershipOps(creator, member Member) []SyncOp {
+	creator.Role = MemberRoleCollaborator
+	member.Role = MemberRoleCreator
+	return []SyncOp{
+		MakeRemoveSyncOp(fmt.Sprintf("/members/%s", member.ID), ""),
+		MakeRemoveSyncOp(fmt.Sprintf("/membersId/%s", member.ID), ""),
+		MakeRepSyncOp("/creator", member),
+		MakeAddSyncOp(fmt.Sprintf("/members/%s", creator.ID), creator),
+		MakeAddSyncOp(fmt.Sprintf("/membersId/%s", creator.ID), creator.ID),
+	}
+}
@@ -3,18 +3,34 @@ package trips
 import (
 	context "context"
 	"errors"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/travelreys/travelreys/pkg/common"
 )
 
+const (
+	// syncMsgWaitInterval leaves time for the coordinator
+	// to start after a member joins the session.
+	syncMsgWaitInterval = 500 * time.Millisecond
+
+	// syncUpdateTimeout bounds how long SyncUpdate waits for
+	// the coordinator to apply the update.
+	syncUpdateTimeout = 10 * time.Second
+)
+
 var (
 	ErrInvalidOp     = errors.New("trips.ErrInvalidOp")
 	ErrInvalidOpData = errors.New("trips.ErrInvalidOpData")
+
+	ErrUpdateNotApplied = errors.New("trips.ErrUpdateNotApplied")
+	ErrUpdateTimeout    = errors.New("trips.ErrUpdateTimeout")
 )
 
 // Service handles the control & data updates made by users in the collaboration session.
 type SyncService interface {
 	Ping(ctx context.Context, msg *SyncMsgBroadcast) error
+	Broadcast(ctx context.Context, msg *SyncMsgBroadcast) error
 
 	Join(ctx context.Context, msg *SyncMsgTOB) error
 	Leave(ctx context.Context, msg *SyncMsgTOB) error
@@ -49,6 +65,11 @@ func (p *syncService) Ping(ctx context.Context, msg *SyncMsgBroadcast) error {
 	return p.sessStore.AddSessCtx(ctx, sessCtx)
 }
 
+// Broadcast sends the message to all clients connected to the trip.
+func (p *syncService) Broadcast(ctx context.Context, msg *SyncMsgBroadcast) error {
+	return p.msgStore.PubBroadcastResp(msg.TripID, msg)
+}
+
 // TOB
 
 func (p *syncService) Join(ctx context.Context, msg *SyncMsgTOB) error {
@@ -97,3 +118,77 @@ func (p *syncService) SubSyncMsgTOBResp(
 ) (<-chan SyncMsgTOB, chan<- bool, error) {
 	return p.msgStore.SubTOBResp(tripID)
 }
+
+// SyncUpdate applies the ops to the trip on behalf of the member,
+// through a session which is left once the update is applied so that
+// the coordinator stops when no one else is connected. It returns the
+// coordinator's error if the update is not applied.
+func SyncUpdate(
+	ctx context.Context,
+	syncSvc SyncService,
+	tripID,
+	memberID,
+	op string,
+	ops []SyncOp,
+) error {
+	// Subscribed before joining so that the response cannot be missed.
+	respCh, done, err := syncSvc.SubSyncMsgTOBResp(ctx, tripID)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		go func() {
+			for range respCh {
+			}
+		}()
+		done <- true
+	}()
+
+	connID := uuid.NewString()
+	joinMsg := MakeSyncMsgTOBTopicJoin(connID, tripID, memberID)
+	if err := syncSvc.Join(ctx, &joinMsg); err != nil {
+		return err
+	}
+	defer func() {
+		leaveMsg := MakeSyncMsgTOBTopicLeave(connID, tripID, memberID)
+		syncSvc.Leave(ctx, &leaveMsg)
+	}()
+	time.Sleep(syncMsgWaitInterval)
+
+	updateMsg := MakeSyncMsgTOBTopicUpdate(connID, tripID, memberID, op, ops)
+	if err := syncSvc.Update(ctx, &updateMsg); err != nil {
+		return err
+	}
+
+	timeout := time.After(syncUpdateTimeout)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timeout:
+			return ErrUpdateTimeout
+		case msg, ok := <-respCh:
+			if !ok {
+				return ErrUpdateTimeout
+			}
+			if msg.ConnID != connID ||
+				msg.Topic != SyncMsgTOBTopicUpdate ||
+				msg.Update == nil {
+				continue
+			}
+			return syncUpdateErr(msg.Update.Err)
+		}
+	}
+}
+
+// syncUpdateErr maps the error reported by the coordinator back to
+// the error it was created from.
+func syncUpdateErr(msg string) error {
+	switch msg {
+	case "":
+		return nil
+	case ErrRBAC.Error():
+		return ErrRBAC
+	}
+	return ErrUpdateNotApplied
+}
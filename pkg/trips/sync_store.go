@@ -17,6 +17,9 @@ const (
 	GroupSpawners     = "spawners"
 	GroupCoordinators = "coordinators"
 
+	// SubjAllTrips is the trip ID matching the subjs of every trip.
+	SubjAllTrips = "*"
+
 	defaultSyncSessionConnTTL = 5 * time.Minute
 
 	sessStoreLogger    = "coordinator.sessStore"
@@ -174,6 +177,7 @@ type SyncMsgStore interface {
 	SubTOBReqQueue(tripID, groupName string) (<-chan SyncMsgTOB, chan<- bool, error)
 	PubTOBResp(tripID string, msg *SyncMsgTOB) error
 	SubTOBResp(tripID string) (<-chan SyncMsgTOB, chan<- bool, error)
+	SubTOBRespQueue(tripID, groupName string) (<-chan SyncMsgTOB, chan<- bool, error)
 }
 
 type syncMsgStore struct {
@@ -337,3 +341,7 @@ func (s *syncMsgStore) PubTOBResp(tripID string, msg *SyncMsgTOB) error {
 func (s *syncMsgStore) SubTOBResp(tripID string) (<-chan SyncMsgTOB, chan<- bool, error) {
 	return s.subTOB(SubjTOBResponse(tripID), tripID)
 }
+
+func (s *syncMsgStore) SubTOBRespQueue(tripID, groupName string) (<-chan SyncMsgTOB, chan<- bool, error) {
+	return s.subTOBQueue(SubjTOBResponse(tripID), tripID, groupName)
+}
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/travelreys/travelreys/pkg/common"
+	"github.com/travelreys/travelreys/pkg/reqctx"
 	"go.uber.org/zap"
 )
 
@@ -41,9 +43,15 @@ func NewWebsocketServer(svc SyncService, logger *zap.Logger) *WebsocketServer {
 	return &WebsocketServer{svc: svc, logger: logger}
 }
 
-// HandleFunc upgrades the HTTP connection to the WebSocket
-// protocol and then creates a ConnHandler.
+// HandleFunc upgrades the HTTP connection of the signed in user to
+// the WebSocket protocol and then creates a ConnHandler.
 func (srv *WebsocketServer) HandleFunc(w http.ResponseWriter, r *http.Request) {
+	ci, err := reqctx.ClientInfoFromCtx(reqctx.ContextWithClientInfo(r.Context(), r))
+	if err != nil || ci.HasEmptyID() {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
 	ws, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		srv.logger.Error("upgrader.Upgrade", zap.Error(err))
@@ -51,7 +59,7 @@ func (srv *WebsocketServer) HandleFunc(w http.ResponseWriter, r *http.Request) {
 	}
 	defer ws.Close()
 
-	h := ConnHandler{svc: srv.svc, ws: ws, logger: srv.logger}
+	h := ConnHandler{svc: srv.svc, ws: ws, memberID: ci.UserID, logger: srv.logger}
 	h.Run()
 }
 
@@ -63,8 +71,10 @@ func (srv *WebsocketServer) HandleFunc(w http.ResponseWriter, r *http.Request) {
 type ConnHandler struct {
 	ws *websocket.Conn
 
-	connID   string
-	tripID   string
+	connID string
+	tripID string
+	// memberID is the signed in user of the connection,
+	// on whose behalf every message is sent.
 	memberID string
 
 	svc        SyncService
@@ -178,8 +188,12 @@ func (h *ConnHandler) ProcessDataMessage(msg *SyncMsgTOB) error {
 	h.logger.Debug("recv data msg", zap.String("op", msg.Topic))
 
 	ctx := context.Background()
+	msg.MemberID = h.memberID
 	switch msg.Topic {
 	case SyncMsgTOBTopicJoin:
+		if h.tripID != "" {
+			return ErrRBAC
+		}
 		h.connID = msg.ConnID
 		h.logger.Info("new client", zap.String("connID", msg.ConnID))
 
@@ -197,18 +211,28 @@ func (h *ConnHandler) ProcessDataMessage(msg *SyncMsgTOB) error {
 		h.dataMsgCh = dataMsgCh
 		h.dataDoneCh = dataDoneCh
 		h.tripID = msg.TripID
-		h.memberID = msg.MemberID
+		// Join rejects the users who are not members of the trip.
 		if err = h.svc.Join(ctx, msg); err != nil {
+			h.ctrlDoneCh <- true
+			h.dataDoneCh <- true
+			h.tripID = ""
 			return err
 		}
 		go h.WriteMessage()
 		return nil
-	case SyncMsgTOBTopicLeave:
+	}
+
+	// Messages are sent to the session the connection joined.
+	if h.tripID == "" {
+		return ErrRBAC
+	}
+	msg.ConnID = h.connID
+	msg.TripID = h.tripID
+	if msg.Topic == SyncMsgTOBTopicLeave {
 		h.ctrlDoneCh <- true
 		h.dataDoneCh <- true
 		return h.svc.Leave(ctx, msg)
 	}
-
 	return h.svc.Update(context.Background(), msg)
 }
 
@@ -234,9 +258,27 @@ func (h *ConnHandler) WriteMessage() {
 			msg.ConnID = h.connID
 			h.logger.Debug("recv tob", zap.String("op", msg.Topic))
 			h.ws.WriteJSON(msg)
+
+			// Disconnect the members removed from the trip. Closing
+			// the connection makes Run leave the session.
+			if isMemberRemoved(&msg, h.memberID) {
+				h.ws.WriteJSON(ErrMessage{Err: ErrRBAC.Error()})
+				h.ws.Close()
+				return
+			}
 		case <-pingTicker.C:
 			h.logger.Debug("ping")
 			h.ws.WriteJSON(MakeSyncMsgBroadcastTopicPing(h.connID, h.tripID, h.memberID))
 		}
 	}
 }
+
+func isMemberRemoved(msg *SyncMsgTOB, memberID string) bool {
+	if msg.Topic != SyncMsgTOBTopicUpdate || msg.Update == nil {
+		return false
+	}
+	if msg.Update.Op != SyncMsgTOBUpdateOpRemoveTripMember {
+		return false
+	}
+	return common.StringContains(RemovedMemberIDs(msg.Update.Ops), memberID)
+}
@@ -5,8 +5,13 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	kithttp "github.com/go-kit/kit/transport/http"
 	"github.com/gorilla/mux"
@@ -16,12 +21,21 @@ import (
 )
 
 const (
-	URLPathVarID = "id"
+	URLPathVarID       = "id"
+	URLPathVarPollID   = "pollID"
+	URLPathVarMemberID = "memberID"
 )
 
 func errToHttpCode(err error) int {
-	notFoundErrors := []error{ErrTripNotFound}
-	appErrors := []error{ErrUnexpectedStoreError}
+	notFoundErrors := []error{
+		ErrTripNotFound,
+		ErrItineraryNotFound,
+		ErrPollNotFound,
+		ErrPollOptionNotFound,
+		ErrMemberNotFound,
+		ErrActivityNotFound,
+	}
+	appErrors := []error{ErrUnexpectedStoreError, ErrCreatorCannotLeave, ErrTripNotInTrash, ErrUpdateNotApplied, ErrUpdateTimeout}
 
 	if common.ErrorContains(notFoundErrors, err) {
 		return http.StatusNotFound
@@ -35,6 +49,13 @@ func errToHttpCode(err error) int {
 	if errors.Is(err, common.ErrValidation) {
 		return http.StatusBadRequest
 	}
+	if errors.Is(err, ErrInvalidExportFormat) ||
+		errors.Is(err, ErrInvalidExpensesCSV) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, ErrPollClosed) {
+		return http.StatusConflict
+	}
 	return http.StatusInternalServerError
 }
 
@@ -53,6 +74,22 @@ func encodeResponse(ctx context.Context, w http.ResponseWriter, response interfa
 	return json.NewEncoder(gw).Encode(response)
 }
 
+func encodeExportFileResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if e, ok := response.(common.Errorer); ok && e.Error() != nil {
+		common.EncodeErrorFactory(errToHttpCode)(ctx, e.Error(), w)
+		return nil
+	}
+	resp, ok := response.(ExportItineraryResponse)
+	if !ok {
+		return common.ErrorEncodeInvalidResponse
+	}
+	w.Header().Set("Content-Type", resp.File.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", resp.File.Filename))
+	w.Header().Set("Cache-Control", "no-store")
+	_, err := w.Write(resp.File.Data)
+	return err
+}
+
 func MakeHandler(svc Service) http.Handler {
 	r := mux.NewRouter()
 
@@ -81,6 +118,15 @@ func MakeHandler(svc Service) http.Handler {
 	deleteHandler := kithttp.NewServer(
 		NewDeleteEndpoint(svc), decodeDeleteRequest, encodeResponse, opts...,
 	)
+	archiveHandler := kithttp.NewServer(
+		NewArchiveEndpoint(svc), decodeArchiveRequest, encodeResponse, opts...,
+	)
+	listTrashHandler := kithttp.NewServer(
+		NewListTrashEndpoint(svc), decodeListTrashRequest, encodeResponse, opts...,
+	)
+	restoreHandler := kithttp.NewServer(
+		NewRestoreEndpoint(svc), decodeRestoreRequest, encodeResponse, opts...,
+	)
 	deleteAttachmentHandler := kithttp.NewServer(
 		NewDeleteAttachmentEndpoint(svc),
 		decodeDeleteAttachmentRequest, encodeResponse, opts...,
@@ -114,12 +160,80 @@ func MakeHandler(svc Service) http.Handler {
 		decodeGenerateSignedURLsRequest, encodeResponse, opts...,
 	)
 
+	exportItineraryHandler := kithttp.NewServer(
+		NewExportItineraryEndpoint(svc),
+		decodeExportItineraryRequest, encodeExportFileResponse, opts...,
+	)
+
+	generateBookletHandler := kithttp.NewServer(
+		NewGenerateBookletEndpoint(svc),
+		decodeGenerateBookletRequest, encodeExportFileResponse, opts...,
+	)
+
+	copyActivitiesHandler := kithttp.NewServer(
+		NewCopyActivitiesEndpoint(svc),
+		decodeCopyActivitiesRequest, encodeResponse, opts...,
+	)
+
+	readLedgerHandler := kithttp.NewServer(
+		NewReadLedgerEndpoint(svc),
+		decodeReadLedgerRequest, encodeResponse, opts...,
+	)
+
+	readBudgetSummaryHandler := kithttp.NewServer(
+		NewReadBudgetSummaryEndpoint(svc),
+		decodeReadBudgetSummaryRequest, encodeResponse, opts...,
+	)
+	exportExpensesHandler := kithttp.NewServer(
+		NewExportExpensesEndpoint(svc),
+		decodeExportExpensesRequest, encodeExportFileResponse, opts...,
+	)
+
+	importBudgetItemsHandler := kithttp.NewServer(
+		NewImportBudgetItemsEndpoint(svc),
+		decodeImportBudgetItemsRequest, encodeResponse, opts...,
+	)
+	acceptPollOptionHandler := kithttp.NewServer(
+		NewAcceptPollOptionEndpoint(svc),
+		decodeAcceptPollOptionRequest, encodeResponse, opts...,
+	)
+
+	updateMemberRoleHandler := kithttp.NewServer(
+		NewUpdateMemberRoleEndpoint(svc),
+		decodeUpdateMemberRoleRequest, encodeResponse, opts...,
+	)
+	transferOwnershipHandler := kithttp.NewServer(
+		NewTransferOwnershipEndpoint(svc),
+		decodeTransferOwnershipRequest, encodeResponse, opts...,
+	)
+	removeMemberHandler := kithttp.NewServer(
+		NewRemoveMemberEndpoint(svc),
+		decodeRemoveMemberRequest, encodeResponse, opts...,
+	)
+	leaveTripHandler := kithttp.NewServer(
+		NewLeaveTripEndpoint(svc),
+		decodeLeaveTripRequest, encodeResponse, opts...,
+	)
+
+	updatePrivacyPolicyHandler := kithttp.NewServer(
+		NewUpdatePrivacyPolicyEndpoint(svc),
+		decodeUpdatePrivacyPolicyRequest, encodeResponse, opts...,
+	)
+
 	r.Handle("/api/v1/trips", createHandler).Methods(http.MethodPost)
 	r.Handle("/api/v1/trips", listHandler).Methods(http.MethodGet)
+	r.Handle("/api/v1/trips/trash", listTrashHandler).Methods(http.MethodGet)
 	r.Handle("/api/v1/trips/{id}", readHandler).Methods(http.MethodGet)
 	r.Handle("/api/v1/trips/{id}/ogp", readOGPHandler).Methods(http.MethodGet)
 	r.Handle("/api/v1/trips/{id}/members", readMembersHandler).Methods(http.MethodGet)
 	r.Handle("/api/v1/trips/{id}", deleteHandler).Methods(http.MethodDelete)
+	r.Handle("/api/v1/trips/{id}/archive", archiveHandler).Methods(http.MethodPut)
+	r.Handle("/api/v1/trips/{id}/restore", restoreHandler).Methods(http.MethodPost)
+	r.Handle("/api/v1/trips/{id}/members/{memberID}/role", updateMemberRoleHandler).Methods(http.MethodPut)
+	r.Handle("/api/v1/trips/{id}/members/{memberID}", removeMemberHandler).Methods(http.MethodDelete)
+	r.Handle("/api/v1/trips/{id}/creator", transferOwnershipHandler).Methods(http.MethodPut)
+	r.Handle("/api/v1/trips/{id}/leave", leaveTripHandler).Methods(http.MethodPost)
+	r.Handle("/api/v1/trips/{id}/privacy", updatePrivacyPolicyHandler).Methods(http.MethodPut)
 
 	r.Handle("/api/v1/trips/{id}/storage", deleteAttachmentHandler).Methods(http.MethodDelete)
 	r.Handle("/api/v1/trips/{id}/storage/download/pre-signed", downloadAttachmentPresignedURLHandler).Methods(http.MethodGet)
@@ -130,6 +244,18 @@ func MakeHandler(svc Service) http.Handler {
 	r.Handle("/api/v1/trips/{id}/media/items/generate", generateMediaItemsHandler).Methods(http.MethodPost)
 	r.Handle("/api/v1/trips/{id}/media/pre-signed", generateSignedURLsHandler).Methods(http.MethodPost)
 
+	r.Handle("/api/v1/trips/{id}/export", exportItineraryHandler).Methods(http.MethodGet)
+	r.Handle("/api/v1/trips/{id}/booklet", generateBookletHandler).Methods(http.MethodGet)
+
+	r.Handle("/api/v1/trips/{id}/itineraries/copy", copyActivitiesHandler).Methods(http.MethodPost)
+
+	r.Handle("/api/v1/trips/{id}/ledger", readLedgerHandler).Methods(http.MethodGet)
+	r.Handle("/api/v1/trips/{id}/budget/summary", readBudgetSummaryHandler).Methods(http.MethodGet)
+	r.Handle("/api/v1/trips/{id}/budget/import", importBudgetItemsHandler).Methods(http.MethodPost)
+	r.Handle("/api/v1/trips/{id}/expenses/export", exportExpensesHandler).Methods(http.MethodGet)
+
+	r.Handle("/api/v1/trips/{id}/polls/{pollID}/accept", acceptPollOptionHandler).Methods(http.MethodPut)
+
 	return r
 }
 
@@ -172,14 +298,51 @@ func decodeListRequest(ctx context.Context, r *http.Request) (interface{}, error
 	if ci.UserID == "" {
 		return nil, ErrRBAC
 	}
-	ff := ListFilter{UserID: &ci.UserID}
+	q := r.URL.Query()
+	ff := ListFilter{
+		UserID:    &ci.UserID,
+		Status:    q.Get("status"),
+		Countries: q["country"],
+		Cities:    q["city"],
+		Tags:      q["tag"],
+		Query:     q.Get("q"),
+		SortBy:    q.Get("sortBy"),
+		Order:     q.Get("order"),
+		Cursor:    q.Get("cursor"),
+		Archived:  common.BoolPtr(q.Get("archived") == "true"),
+	}
+	if limit := q.Get("limit"); limit != "" {
+		ff.Limit, err = strconv.ParseInt(limit, 10, 64)
+		if err != nil {
+			return nil, common.ErrInvalidRequest
+		}
+	}
+	if ff.StartsAfter, err = parseTimeQueryParam(q.Get("startsAfter")); err != nil {
+		return nil, common.ErrInvalidRequest
+	}
+	if ff.StartsBefore, err = parseTimeQueryParam(q.Get("startsBefore")); err != nil {
+		return nil, common.ErrInvalidRequest
+	}
+
 	req := ListRequest{ListFilter: ff}
-	if r.URL.Query().Get("withMembers") == "true" {
+	if q.Get("withMembers") == "true" {
 		req.WithMembers = true
 	}
 	return req, nil
 }
 
+// parseTimeQueryParam parses the RFC3339 time, if set.
+func parseTimeQueryParam(val string) (*time.Time, error) {
+	if val == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
 func decodeDeleteRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	vars := mux.Vars(r)
 	ID, ok := vars[URLPathVarID]
@@ -189,6 +352,40 @@ func decodeDeleteRequest(_ context.Context, r *http.Request) (interface{}, error
 	return DeleteRequest{ID}, nil
 }
 
+func decodeArchiveRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	ID, ok := vars[URLPathVarID]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	req := ArchiveRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, common.ErrInvalidJSONBody
+	}
+	req.ID = ID
+	return req, nil
+}
+
+func decodeListTrashRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	ci, err := reqctx.ClientInfoFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ci.UserID == "" {
+		return nil, ErrRBAC
+	}
+	return ListTrashRequest{UserID: ci.UserID}, nil
+}
+
+func decodeRestoreRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	ID, ok := vars[URLPathVarID]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	return RestoreRequest{ID}, nil
+}
+
 // Attachments
 
 func decodeDeleteAttachmentRequest(_ context.Context, r *http.Request) (interface{}, error) {
@@ -297,3 +494,209 @@ func decodeGenerateSignedURLsRequest(_ context.Context, r *http.Request) (interf
 
 	return req, nil
 }
+
+// Exports
+
+func decodeExportItineraryRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	ID, ok := vars[URLPathVarID]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	return ExportItineraryRequest{
+		ID:     ID,
+		Format: r.URL.Query().Get("format"),
+		Date:   r.URL.Query().Get("date"),
+	}, nil
+}
+
+func decodeGenerateBookletRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	ID, ok := vars[URLPathVarID]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	q := r.URL.Query()
+	opts := BookletOptions{
+		Format:   q.Get("format"),
+		Sections: []string{},
+		Locale:   q.Get("locale"),
+	}
+	if opts.Format == "" {
+		opts.Format = BookletFormatPDF
+	}
+	if sections := q.Get("sections"); sections != "" {
+		opts.Sections = strings.Split(sections, ",")
+	}
+	return GenerateBookletRequest{ID: ID, Opts: opts}, nil
+}
+
+func decodeCopyActivitiesRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	ID, ok := vars[URLPathVarID]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	opts := CopyActivitiesOptions{}
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		return nil, common.ErrInvalidJSONBody
+	}
+	return CopyActivitiesRequest{ID: ID, Opts: opts}, nil
+}
+
+// Budget
+
+func decodeReadLedgerRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	ID, ok := vars[URLPathVarID]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	return ReadLedgerRequest{
+		ID:       ID,
+		Currency: r.URL.Query().Get("currency"),
+	}, nil
+}
+
+func decodeReadBudgetSummaryRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	ID, ok := vars[URLPathVarID]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	return ReadBudgetSummaryRequest{
+		ID:       ID,
+		Currency: r.URL.Query().Get("currency"),
+	}, nil
+}
+
+func decodeExportExpensesRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	ID, ok := vars[URLPathVarID]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = ExpenseExportFormatCSV
+	}
+	return ExportExpensesRequest{
+		ID:       ID,
+		Format:   format,
+		Currency: q.Get("currency"),
+	}, nil
+}
+
+// decodeImportBudgetItemsRequest reads the CSV file from the request body.
+func decodeImportBudgetItemsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	ID, ok := vars[URLPathVarID]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxImportBudgetItemsCSVSize))
+	if err != nil {
+		return nil, common.ErrInvalidRequest
+	}
+	return ImportBudgetItemsRequest{ID: ID, Data: data}, nil
+}
+
+func decodeAcceptPollOptionRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	ID, ok := vars[URLPathVarID]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	pollID, ok := vars[URLPathVarPollID]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	req := AcceptPollOptionRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, common.ErrInvalidJSONBody
+	}
+	req.ID = ID
+	req.PollID = pollID
+	return req, nil
+}
+
+// Members
+
+func decodeUpdateMemberRoleRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	ID, ok := vars[URLPathVarID]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	memberID, ok := vars[URLPathVarMemberID]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	req := UpdateMemberRoleRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, common.ErrInvalidJSONBody
+	}
+	req.ID = ID
+	req.MemberID = memberID
+	return req, nil
+}
+
+func decodeTransferOwnershipRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	ID, ok := vars[URLPathVarID]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	req := TransferOwnershipRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, common.ErrInvalidJSONBody
+	}
+	req.ID = ID
+	return req, nil
+}
+
+func decodeRemoveMemberRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	ID, ok := vars[URLPathVarID]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	memberID, ok := vars[URLPathVarMemberID]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	return RemoveMemberRequest{ID: ID, MemberID: memberID}, nil
+}
+
+func decodeLeaveTripRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	ci, err := reqctx.ClientInfoFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ci.UserID == "" {
+		return nil, ErrRBAC
+	}
+	vars := mux.Vars(r)
+	ID, ok := vars[URLPathVarID]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	return LeaveTripRequest{ID: ID, MemberID: ci.UserID}, nil
+}
+
+// Privacy
+
+func decodeUpdatePrivacyPolicyRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	ID, ok := vars[URLPathVarID]
+	if !ok {
+		return nil, common.ErrInvalidRequest
+	}
+	req := UpdatePrivacyPolicyRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, common.ErrInvalidJSONBody
+	}
+	req.ID = ID
+	return req, nil
+}
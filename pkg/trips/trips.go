@@ -63,8 +63,19 @@ type Trip struct {
 	Budget   Budget      `json:"budget" bson:"budget"`
 	Links    LinksMap    `json:"links" bson:"links"`
 
+	Settlements SettlementsMap `json:"settlements" bson:"settlements"`
+
 	Itineraries ItineraryMap `json:"itineraries" bson:"itineraries"`
 
+	// Ideas are activities members want to do, not bound to a date yet.
+	Ideas ActivityMap `json:"ideas" bson:"ideas"`
+
+	// Polls let members vote on alternatives.
+	Polls PollsMap `json:"polls" bson:"polls"`
+
+	// Checklists hold the to-dos of the trip, e.g visas or packing.
+	Checklists ChecklistsMap `json:"checklists" bson:"checklists"`
+
 	// Media, Attachements
 	MediaItems map[string]media.MediaItemList `json:"mediaItems" bson:"mediaItems"`
 	Files      FilesMap                       `json:"files" bson:"files"`
@@ -72,9 +83,19 @@ type Trip struct {
 	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
 	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
 
-	Deleted bool          `json:"deleted" bson:"deleted"`
-	Labels  common.Labels `json:"labels" bson:"labels"`
-	Tags    common.Tags   `json:"tags" bson:"tags"`
+	// Privacy decides what the public views of the trip show.
+	Privacy PrivacyPolicy `json:"privacy" bson:"privacy"`
+
+	// Index is only used to filter and search trips.
+	Index SearchIndex `json:"-" bson:"index"`
+
+	// Archived trips are kept out of the trips list, but not deleted.
+	Archived bool `json:"archived" bson:"archived"`
+
+	Deleted   bool          `json:"deleted" bson:"deleted"`
+	DeletedAt *time.Time    `json:"deletedAt" bson:"deletedAt"`
+	Labels    common.Labels `json:"labels" bson:"labels"`
+	Tags      common.Tags   `json:"tags" bson:"tags"`
 }
 
 type TripsList []*Trip
@@ -101,12 +122,17 @@ func NewTrip(creator Member, name string) *Trip {
 		Transits:    TransitsMap{},
 		Lodgings:    LodgingsMap{},
 		Itineraries: ItineraryMap{},
+		Ideas:       ActivityMap{},
+		Polls:       PollsMap{},
+		Checklists:  ChecklistsMap{},
 		Budget:      NewBudget(),
 		Links:       LinksMap{},
+		Settlements: SettlementsMap{},
 		MediaItems: map[string]media.MediaItemList{
 			MediaItemKeyTrip: {},
 		},
 		Files:     FilesMap{},
+		Privacy:   NewPrivacyPolicy(),
 		UpdatedAt: time.Now(),
 		CreatedAt: time.Now(),
 		Deleted:   false,
@@ -141,7 +167,9 @@ func (trip Trip) IsSharingEnabled() bool {
 }
 
 func (trip *Trip) Delete() {
+	now := time.Now()
 	trip.Deleted = true
+	trip.DeletedAt = &now
 }
 
 const (
@@ -265,21 +293,54 @@ func (m LodgingsMap) GetLodgingsForDate(dt time.Time) LodgingsMap {
 	return results
 }
 
+// SleepingLodging returns the lodging the travellers sleep at
+// on the night of the given date.
+func (m LodgingsMap) SleepingLodging(dtKey string) *Lodging {
+	for _, l := range sortedLodgings(m) {
+		checkin := l.CheckinTime.Format(ItineraryDtKeyFormat)
+		checkout := l.CheckoutTime.Format(ItineraryDtKeyFormat)
+		if checkin <= dtKey && dtKey < checkout {
+			return l
+		}
+	}
+	return nil
+}
+
+// nightlyLodging returns the lodging the travellers sleep at on the
+// night of the given date, or the lodging they check out from.
+func (m LodgingsMap) nightlyLodging(dtKey string) *Lodging {
+	if l := m.SleepingLodging(dtKey); l != nil {
+		return l
+	}
+	for _, l := range sortedLodgings(m) {
+		if l.CheckoutTime.Format(ItineraryDtKeyFormat) == dtKey {
+			return l
+		}
+	}
+	return nil
+}
+
 type Budget struct {
 	ID     string          `json:"id" bson:"id"`
 	Amount finance.Price   `json:"amount" bson:"amount"`
 	Items  BudgetItemsList `json:"items" bson:"items"`
 
+	// CategoryAmounts and DailyAmount are optional budgets per
+	// category and per day, in the currency of Amount.
+	CategoryAmounts map[string]float64 `json:"categoryAmounts" bson:"categoryAmounts"`
+	DailyAmount     float64            `json:"dailyAmount" bson:"dailyAmount"`
+
 	Labels common.Labels `json:"labels" bson:"labels"`
 	Tags   common.Tags   `json:"tags" bson:"tags"`
 }
 
 func NewBudget() Budget {
 	return Budget{
-		Amount: finance.Price{},
-		Items:  BudgetItemsList{},
-		Labels: common.Labels{},
-		Tags:   common.Tags{},
+		Amount:          finance.Price{},
+		Items:           BudgetItemsList{},
+		CategoryAmounts: map[string]float64{},
+		Labels:          common.Labels{},
+		Tags:            common.Tags{},
 	}
 }
 